@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -16,6 +18,8 @@ import (
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/build"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/certs"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/correlation"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/migrations"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/mail"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
@@ -23,6 +27,7 @@ import (
 	"github.com/Builder-Lawyers/builder-backend/internal/presentation/rest"
 	"github.com/Builder-Lawyers/builder-backend/internal/presentation/scheduler"
 	"github.com/Builder-Lawyers/builder-backend/pkg/db"
+	"github.com/Builder-Lawyers/builder-backend/pkg/env"
 	awsConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
@@ -33,6 +38,10 @@ import (
 )
 
 func Init() {
+	// every record logged with a context carries correlation ID of a request or an event.
+	// Default handler can't be wrapped, it writes through the log package, which is redirected to the new default
+	slog.SetDefault(slog.New(correlation.NewLogHandler(slog.NewTextHandler(os.Stderr, nil))))
+
 	// DB
 	dbConfig := db.NewConfig()
 	pool, err := pgxpool.New(context.Background(), dbConfig.GetDSN())
@@ -43,6 +52,16 @@ func Init() {
 	if err != nil {
 		log.Panicf("failed to connect to db: %v", err)
 	}
+	// replicas apply pending migrations on start, concurrent runs wait on a lock
+	if env.GetEnv("MIGRATE_ON_START", "true") == "true" {
+		migrator, err := migrations.NewMigrator(pool)
+		if err != nil {
+			log.Panicf("failed to load migrations: %v", err)
+		}
+		if _, err = migrator.Up(context.Background()); err != nil {
+			log.Panicf("failed to migrate db: %v", err)
+		}
+	}
 	uowFactory := db.NewUoWFactory(pool)
 
 	// Configs
@@ -67,6 +86,14 @@ func Init() {
 		log.Panic("can't load aws config", err)
 	}
 	s3 := storage.NewStorage(cfg)
+	// objects are kept in a local directory instead of s3 for development without aws
+	if dir := env.GetEnv("STORAGE_LOCAL_DIR", ""); dir != "" {
+		localStore, err := storage.NewLocalStore(dir, env.GetEnv("STORAGE_LOCAL_URL", ""))
+		if err != nil {
+			log.Panic("can't create local storage", err)
+		}
+		s3 = storage.NewBlobStorage(localStore)
+	}
 	dnsProvisioner := dns.NewDNSProvisioner(cfg, domainContact)
 	acmCerts := certs.NewACMCertificates(cfg)
 	cognito := cognitoidentityprovider.NewFromConfig(cfg, func(o *cognitoidentityprovider.Options) {
@@ -77,12 +104,16 @@ func Init() {
 	})
 
 	// FE Build
-	templateBuild := build.NewTemplateBuild(s3, provisionConfig)
+	templateBuild := build.NewTemplateBuild(s3, provisionConfig, build.NewExecutor(build.NewExecutorConfig()))
+	if err = templateBuild.CleanWorkspaces(context.Background()); err != nil {
+		slog.Error("err removing stale build workspaces", "err", err)
+	}
 
+	processors := application.NewProcessors(uowFactory, s3, templateBuild, acmCerts, provisionConfig, dnsProvisioner, mailServer)
 	handlers := &application.Handlers{
-		Commands:   application.NewCommands(uowFactory, s3, uploadConfig, templateBuild, provisionConfig, paymentConfig, oidcConfig, cognito, dnsProvisioner),
+		Commands:   application.NewCommands(uowFactory, s3, uploadConfig, templateBuild, provisionConfig, paymentConfig, oidcConfig, cognito, dnsProvisioner, processors.Registry),
 		Queries:    application.NewQueries(uowFactory, s3, provisionConfig, dnsProvisioner),
-		Processors: application.NewProcessors(uowFactory, s3, templateBuild, acmCerts, provisionConfig, dnsProvisioner, mailServer),
+		Processors: processors,
 	}
 	handler := rest.NewServer(handlers.Queries, handlers.Commands)
 	app := fiber.New(fiber.Config{
@@ -91,14 +122,15 @@ func Init() {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:3000",
 		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,OPTIONS",
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,Cookie",
-		ExposeHeaders:    "Set-Cookie,Authorization",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,Cookie," + correlation.Header,
+		ExposeHeaders:    "Set-Cookie,Authorization," + correlation.Header,
 		AllowCredentials: true,
 	}))
+	app.Use(rest.Correlation())
 	app.Static("/docs", "./api")
 	rest.RegisterHandlers(app, handler)
 
-	outboxPoller := scheduler.NewOutboxPoller(handlers.Processors, uowFactory, outboxConfig)
+	outboxPoller := scheduler.NewOutboxPoller(handlers.Processors.Registry, uowFactory, outboxConfig)
 	go outboxPoller.Start()
 
 	templatesQueuePoller := queue.NewTemplateChangesPoller(sqsClient, templateChangesConfig, handlers.Commands.RebuildTemplate)
@@ -117,10 +149,19 @@ func Init() {
 
 	_ = <-c
 	fmt.Println("Gracefully shutting down...")
-	_ = app.Shutdown()
-	outboxPoller.Stop()
+	// in-flight requests, events and builds are awaited within the same deadline
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout())
+	defer cancel()
+	if err = app.ShutdownWithContext(shutdownCtx); err != nil {
+		slog.Error("err shutting down server", "err", err)
+	}
 	if templateChangesConfig.Enabled {
-		templatesQueuePoller.Stop()
+		if err = templatesQueuePoller.Stop(shutdownCtx); err != nil {
+			slog.Error("err stopping template changes poller", "err", err)
+		}
+	}
+	if err = outboxPoller.Stop(shutdownCtx); err != nil {
+		slog.Error("err stopping outbox poller", "err", err)
 	}
 
 	fmt.Println("Running cleanup tasks...")
@@ -128,3 +169,11 @@ func Init() {
 	uowFactory.Pool.Close()
 	fmt.Println("Fiber was successfully shutdown.")
 }
+
+func shutdownTimeout() time.Duration {
+	timeout, err := strconv.Atoi(env.GetEnv("SHUTDOWN_TIMEOUT", "25"))
+	if err != nil || timeout <= 0 {
+		timeout = 25
+	}
+	return time.Duration(timeout) * time.Second
+}
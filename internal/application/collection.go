@@ -4,16 +4,20 @@ import (
 	"github.com/Builder-Lawyers/builder-backend/internal/application/commands/ai"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/commands/auth"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/commands/file"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/commands/outbox"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/commands/payment"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/commands/site"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/commands/template"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/processors"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/publishing"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/query"
 	authCfg "github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/build"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/certs"
 	aiCfg "github.com/Builder-Lawyers/builder-backend/internal/infra/client/openai"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/mail"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
@@ -34,62 +38,92 @@ type Commands struct {
 	Payment         *payment.Payment
 	CreateSite      *site.CreateSite
 	UpdateSite      *site.UpdateSite
+	ManageVersions  *site.ManageVersions
+	PreviewSite     *site.PreviewSite
+	CancelBuild     *site.CancelBuild
 	DeleteSite      *site.DeleteSite
+	ReactivateSite  *site.ReactivateSite
 	CreateTemplate  *template.CreateTemplate
 	RebuildTemplate *template.RebuildTemplate
 	UpdateTemplate  *template.UpdateTemplate
+	ManageOutbox    *outbox.ManageOutbox
 }
 
 type Queries struct {
-	GetSite     *query.GetSite
-	CheckDomain *query.CheckDomain
-	GetTemplate *query.GetTemplate
+	GetSite        *query.GetSite
+	GetSiteHistory *query.GetSiteHistory
+	ListSites      *query.ListSites
+	ListVersions   *query.ListSiteVersions
+	ListPreviews   *query.ListSitePreviews
+	GetBuild       *query.GetSiteBuild
+	CheckDomain    *query.CheckDomain
+	GetTemplate    *query.GetTemplate
+	GetOutboxEvent *query.GetOutboxEvent
 }
 
 type Processors struct {
-	DeactivateSite    *processors.DeactivateSite
-	ProvisionSite     *processors.ProvisionSite
-	ProvisionCDN      *processors.ProvisionCDN
-	FinalizeProvision *processors.FinalizeProvision
-	SendMail          *processors.SendMail
+	Registry *events.Registry
 }
 
 func NewCommands(uowFactory *db.UOWFactory, storage *storage.Storage, uploadConfig file.UploadConfig,
 	templateBuild *build.TemplateBuild, provisionConfig config.ProvisionConfig, paymentConfig payment.PaymentConfig,
-	oidcConfig authCfg.OIDCConfig, cognito *cognitoidentityprovider.Client, dnsProvisioner *dns.DNSProvisioner,
+	oidcConfig authCfg.OIDCConfig, cognito *cognitoidentityprovider.Client, dnsProvider dns.Provider,
+	registry *events.Registry,
 ) *Commands {
+	repos := repo.NewRepositories()
 	return &Commands{
 		EnrichContent:   ai.NewEnrichContent(aiCfg.NewOpenAIClient(aiCfg.NewOpenAIConfig())),
-		Auth:            auth.NewAuth(uowFactory, oidcConfig, cognito),
+		Auth:            auth.NewAuth(uowFactory, repos, oidcConfig, cognito),
 		UploadFile:      file.NewUploadFile(uowFactory, storage, uploadConfig),
-		Payment:         payment.NewPayment(uowFactory, paymentConfig),
-		CreateSite:      site.NewCreateSite(uowFactory),
-		UpdateSite:      site.NewUpdateSite(uowFactory, templateBuild, dnsProvisioner, storage, provisionConfig),
-		DeleteSite:      site.NewDeleteSite(uowFactory),
-		CreateTemplate:  template.NewCreateTemplate(uowFactory),
-		RebuildTemplate: template.NewRebuildTemplate(uowFactory, storage, templateBuild, dnsProvisioner, provisionConfig),
-		UpdateTemplate:  template.NewUpdateTemplate(uowFactory),
+		Payment:         payment.NewPayment(uowFactory, repos, paymentConfig),
+		CreateSite:      site.NewCreateSite(uowFactory, repos),
+		UpdateSite:      site.NewUpdateSite(uowFactory, repos),
+		ManageVersions:  site.NewManageVersions(uowFactory, repos, publishing.NewPublisher(repos, templateBuild, dnsProvider, storage, provisionConfig)),
+		PreviewSite:     site.NewPreviewSite(uowFactory, repos),
+		CancelBuild:     site.NewCancelBuild(uowFactory, repos),
+		DeleteSite:      site.NewDeleteSite(uowFactory, repos, provisionConfig.DeletionGracePeriod),
+		ReactivateSite:  site.NewReactivateSite(uowFactory, repos),
+		CreateTemplate:  template.NewCreateTemplate(uowFactory, repos),
+		RebuildTemplate: template.NewRebuildTemplate(uowFactory, repos, storage, templateBuild, dnsProvider, provisionConfig),
+		UpdateTemplate:  template.NewUpdateTemplate(uowFactory, repos),
+		ManageOutbox:    outbox.NewManageOutbox(uowFactory, registry),
 	}
 }
 
 func NewQueries(uowFactory *db.UOWFactory, storage *storage.Storage, provisionConfig config.ProvisionConfig,
-	dnsProvisioner *dns.DNSProvisioner,
+	dnsProvider dns.Provider,
 ) *Queries {
 	return &Queries{
-		GetSite:     query.NewGetSite(provisionConfig, uowFactory, dnsProvisioner),
-		CheckDomain: query.NewCheckDomain(dnsProvisioner),
-		GetTemplate: query.NewGetTemplate(uowFactory, storage, provisionConfig),
+		GetSite:        query.NewGetSite(provisionConfig, uowFactory, dnsProvider),
+		GetSiteHistory: query.NewGetSiteHistory(uowFactory),
+		ListSites:      query.NewListSites(uowFactory),
+		ListVersions:   query.NewListSiteVersions(uowFactory),
+		ListPreviews:   query.NewListSitePreviews(uowFactory),
+		GetBuild:       query.NewGetSiteBuild(uowFactory),
+		CheckDomain:    query.NewCheckDomain(dnsProvider),
+		GetTemplate:    query.NewGetTemplate(uowFactory, storage, provisionConfig),
+		GetOutboxEvent: query.NewGetOutboxEvent(uowFactory),
 	}
 }
 
 func NewProcessors(uowFactory *db.UOWFactory, storage *storage.Storage, build *build.TemplateBuild,
-	certs *certs.ACMCertificates, provisionConfig config.ProvisionConfig, dnsProvisioner *dns.DNSProvisioner, mail *mail.MailServer,
+	certs certs.Manager, provisionConfig config.ProvisionConfig, dnsProvider dns.Provider, mail mail.Sender,
 ) *Processors {
+	registry := events.NewRegistry()
+	publisher := publishing.NewPublisher(repo.NewRepositories(), build, dnsProvider, storage, provisionConfig)
+	processors.NewDeactivateSite(uowFactory, dnsProvider, dnsProvider, provisionConfig).Register(registry)
+	processors.NewReactivateSite(uowFactory, dnsProvider, dnsProvider).Register(registry)
+	processors.NewDeleteSite(uowFactory, storage, dnsProvider, certs, provisionConfig).Register(registry)
+	processors.NewCancelSubscription().Register(registry)
+	processors.NewProvisionSite(provisionConfig, uowFactory, storage, publisher, dnsProvider, dnsProvider, certs).Register(registry)
+	processors.NewProvisionCDN(provisionConfig, uowFactory, dnsProvider, dnsProvider).Register(registry)
+	processors.NewFinalizeProvision(provisionConfig, uowFactory, dnsProvider, dnsProvider).Register(registry)
+	processors.NewFinalizePreview(provisionConfig, uowFactory, dnsProvider, dnsProvider).Register(registry)
+	processors.NewExpirePreview(provisionConfig, uowFactory, storage, dnsProvider, dnsProvider).Register(registry)
+	processors.NewRebuildSite(provisionConfig, uowFactory, publisher, dnsProvider).Register(registry)
+	processors.NewSendMail(mail, uowFactory).Register(registry)
+
 	return &Processors{
-		DeactivateSite:    processors.NewDeactivateSite(uowFactory, dnsProvisioner, provisionConfig),
-		ProvisionSite:     processors.NewProvisionSite(provisionConfig, uowFactory, storage, build, dnsProvisioner, certs),
-		ProvisionCDN:      processors.NewProvisionCDN(provisionConfig, uowFactory, dnsProvisioner),
-		FinalizeProvision: processors.NewFinalizeProvision(provisionConfig, uowFactory, dnsProvisioner),
-		SendMail:          processors.NewSendMail(mail, uowFactory),
+		Registry: registry,
 	}
 }
@@ -2,9 +2,10 @@ package auth
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -12,12 +13,10 @@ import (
 	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
-	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/mail"
-	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
-	"github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
 	"github.com/MicahParks/keyfunc/v3"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
@@ -26,15 +25,21 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+const cognitoProvider = "Cognito"
+
 type Auth struct {
-	uowFactory *dbs.UOWFactory
+	uowFactory interfaces.UoWFactory
+	repos      interfaces.Repositories
 	cfg        auth.OIDCConfig
 	cognito    *cognitoidentityprovider.Client
 }
 
-func NewAuth(uowFactory *dbs.UOWFactory, oidcCfg auth.OIDCConfig, cognito *cognitoidentityprovider.Client) *Auth {
+func NewAuth(uowFactory interfaces.UoWFactory, repos interfaces.Repositories, oidcCfg auth.OIDCConfig,
+	cognito *cognitoidentityprovider.Client,
+) *Auth {
 	return &Auth{
 		uowFactory: uowFactory,
+		repos:      repos,
 		cfg:        oidcCfg,
 		cognito:    cognito,
 	}
@@ -62,29 +67,19 @@ func (c *Auth) CreateSession(ctx context.Context, req dto.CreateSession) (string
 	claims := idToken.Claims.(jwt.MapClaims)
 
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return "", err
 	}
 	defer uow.Finalize(&err)
 
-	var userID uuid.UUID
-	err = tx.QueryRow(ctx, "SELECT id FROM builder.users WHERE email = $1",
-		claims["email"].(string),
-	).Scan(&userID)
+	user, err := c.repos.Users(tx).GetUserByEmail(ctx, claims["email"].(string))
 	if err != nil {
 		return "", fmt.Errorf("error getting user by email, %v", err)
 	}
 
-	session := db.Session{
-		ID:           uuid.New(),
-		UserID:       userID,
-		RefreshToken: req.RefreshToken,
-		ExpiresAt:    time.Now().Add(time.Hour * time.Duration(c.cfg.SessionLifetimeHours)),
-	}
-
-	_, err = tx.Exec(ctx, "INSERT INTO builder.sessions(id, user_id, refresh_token, expires_at) VALUES ($1,$2,$3,$4)",
-		session.ID, session.UserID, session.RefreshToken, session.ExpiresAt)
+	session := c.newSession(user.ID, req.RefreshToken)
+	err = c.repos.Sessions(tx).InsertSession(ctx, session)
 	if err != nil {
 		return "", fmt.Errorf("error creating a session, %v", err)
 	}
@@ -94,36 +89,43 @@ func (c *Auth) CreateSession(ctx context.Context, req dto.CreateSession) (string
 
 func (c *Auth) CreateConfirmationCode(ctx context.Context, req *dto.CreateConfirmation) error {
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer uow.Finalize(&err)
 
-	code := uuid.New()
-	expiresAt := time.Now().Add(time.Minute * time.Duration(c.cfg.ConfirmationExpirationMins))
-
-	_, err = tx.Exec(ctx, "INSERT INTO builder.confirmation_codes(code, sub_id, email, expires_at) VALUES ($1,$2,$3,$4)",
-		code, req.UserID, req.Email, expiresAt)
+	userRepo := c.repos.Users(tx)
+	code := db.ConfirmationCode{
+		Code:      uuid.New(),
+		SubID:     req.UserID.String(),
+		Email:     req.Email,
+		ExpiresAt: time.Now().Add(time.Minute * time.Duration(c.cfg.ConfirmationExpirationMins)),
+	}
+	err = userRepo.InsertConfirmationCode(ctx, code)
 	if err != nil {
 		return fmt.Errorf("err generating a confirmation code, %v", err)
 	}
 
 	newUserID := uuid.New()
-	_, err = tx.Exec(ctx, "INSERT INTO builder.users(id, status, email, created_at) VALUES ($1,$2,$3,$4)",
-		newUserID, consts.UserStatusNotConfirmed, req.Email, time.Now())
+	err = userRepo.InsertUser(ctx, db.User{
+		ID:        newUserID,
+		Status:    consts.UserStatusNotConfirmed,
+		Email:     req.Email,
+		CreatedAt: time.Now(),
+	})
 	if err != nil {
 		return fmt.Errorf("err creating user, %v", err)
 	}
 
-	_, err = tx.Exec(ctx, "INSERT INTO builder.user_identities(id, provider, sub) VALUES($1,$2,$3)", newUserID, "Cognito", req.UserID)
+	err = userRepo.InsertIdentity(ctx, db.UserIdentity{UserID: newUserID, Provider: cognitoProvider, Sub: req.UserID.String()})
 	if err != nil {
 		return fmt.Errorf("err creating user cognito identity, %v", err)
 	}
 
 	registrationConfirmData := mail.RegistrationConfirmData{
 		Year:        strconv.Itoa(time.Now().Year()),
-		RedirectURL: fmt.Sprintf("%v/%v", c.cfg.RedirectURL, code),
+		RedirectURL: fmt.Sprintf("%v/%v", c.cfg.RedirectURL, code.Code),
 	}
 
 	sendMail := events.SendMail{
@@ -132,8 +134,7 @@ func (c *Auth) CreateConfirmationCode(ctx context.Context, req *dto.CreateConfir
 		Data:    registrationConfirmData,
 	}
 
-	eventRepo := repo.NewEventRepo(tx)
-	err = eventRepo.InsertEvent(ctx, sendMail)
+	err = c.repos.Events(tx).InsertEvent(ctx, sendMail)
 	if err != nil {
 		return err
 	}
@@ -143,42 +144,42 @@ func (c *Auth) CreateConfirmationCode(ctx context.Context, req *dto.CreateConfir
 
 func (c *Auth) VerifyCode(ctx context.Context, req *dto.VerifyCode) (*dto.SessionInfo, string, error) {
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, "", err
 	}
 	defer uow.Finalize(&err)
 
-	var status sql.NullString
-	err = tx.QueryRow(ctx, "SELECT u.status FROM builder.confirmation_codes c "+
-		"LEFT JOIN builder.users u "+
-		"ON c.sub_id::uuid = u.id "+
-		"WHERE c.code = $1", req.Code,
-	).Scan(&status)
+	userRepo := c.repos.Users(tx)
+	codeID, err := uuid.Parse(req.Code)
 	if err != nil {
 		return nil, "", fmt.Errorf("err getting confirmation code, %v", err)
 	}
-
-	if status.Valid && consts.UserStatus(status.String) == consts.UserConfirmed {
-		return nil, "", fmt.Errorf("code is already used to confirm user")
+	code, err := userRepo.GetConfirmationCode(ctx, codeID)
+	if err != nil {
+		return nil, "", fmt.Errorf("err getting confirmation code, %v", err)
 	}
 
-	var subID uuid.UUID
-	var email string
-	var expiresAt time.Time
-	err = tx.QueryRow(ctx, "SELECT sub_id, email, expires_at FROM builder.confirmation_codes WHERE code = $1", req.Code).Scan(&subID, &email, &expiresAt)
+	userID, err := userRepo.GetUserIDByIdentity(ctx, cognitoProvider, code.SubID)
 	if err != nil {
-		return nil, "", fmt.Errorf("err getting confirmation code, %v", err)
+		return nil, "", fmt.Errorf("err getting user from cognito sub, %v", err)
+	}
+	user, err := userRepo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("err getting user, %v", err)
+	}
+	if user.Status == consts.UserConfirmed {
+		return nil, "", fmt.Errorf("code is already used to confirm user")
 	}
 
-	if expiresAt.Before(time.Now()) {
+	if code.ExpiresAt.Before(time.Now()) {
 		// TODO: clear user from db and cognito so he can re-register
 		return nil, "", fmt.Errorf("code is expired")
 	}
 
 	input := &cognitoidentityprovider.AdminConfirmSignUpInput{
 		UserPoolId: aws.String(c.cfg.UserPoolID),
-		Username:   aws.String(email),
+		Username:   aws.String(code.Email),
 	}
 
 	_, err = c.cognito.AdminConfirmSignUp(ctx, input)
@@ -186,18 +187,12 @@ func (c *Auth) VerifyCode(ctx context.Context, req *dto.VerifyCode) (*dto.Sessio
 		return nil, "", fmt.Errorf("failed to confirm user: %v", err)
 	}
 
-	var userID uuid.UUID
-	err = tx.QueryRow(ctx, "SELECT id FROM builder.user_identities WHERE provider = $1 AND sub = $2", "Cognito", subID).Scan(&userID)
-	if err != nil {
-		return nil, "", fmt.Errorf("err getting user from cognito sub, %v", err)
-	}
-
-	_, err = tx.Exec(ctx, "UPDATE builder.users SET status = $1 WHERE id = $2", consts.UserConfirmed, userID)
+	err = userRepo.UpdateUserStatus(ctx, userID, consts.UserConfirmed)
 	if err != nil {
 		return nil, "", fmt.Errorf("err updating user status, %v", err)
 	}
 
-	_, err = tx.Exec(ctx, "DELETE FROM builder.confirmation_codes WHERE code = $1", req.Code)
+	err = userRepo.DeleteConfirmationCode(ctx, code.Code)
 	if err != nil {
 		return nil, "", fmt.Errorf("err deleting confirmation code")
 	}
@@ -212,21 +207,13 @@ func (c *Auth) VerifyCode(ctx context.Context, req *dto.VerifyCode) (*dto.Sessio
 		Data:    registrationSuccessData,
 	}
 
-	eventRepo := repo.NewEventRepo(tx)
-	err = eventRepo.InsertEvent(ctx, registrationSuccessMail)
+	err = c.repos.Events(tx).InsertEvent(ctx, registrationSuccessMail)
 	if err != nil {
 		return nil, "", err
 	}
 
-	session := db.Session{
-		ID:           uuid.New(),
-		UserID:       userID,
-		RefreshToken: uuid.NewString(),
-		ExpiresAt:    time.Now().Add(time.Hour * time.Duration(c.cfg.SessionLifetimeHours)),
-	}
-
-	_, err = tx.Exec(ctx, "INSERT INTO builder.sessions(id, user_id, refresh_token, expires_at) VALUES ($1,$2,$3,$4)",
-		session.ID, session.UserID, session.RefreshToken, session.ExpiresAt)
+	session := c.newSession(userID, uuid.NewString())
+	err = c.repos.Sessions(tx).InsertSession(ctx, session)
 	if err != nil {
 		return nil, "", fmt.Errorf("error creating a session, %v", err)
 	}
@@ -251,40 +238,36 @@ func (c *Auth) VerifyOauth(ctx context.Context, req *dto.VerifyOauthToken) (*dto
 	providerSub := claims["sub"].(string)
 
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, "", err
 	}
+	defer uow.Finalize(&err)
+
+	userRepo := c.repos.Users(tx)
 
 	// check if user from this provider is already registered
-	var existingUser sql.NullString
-	err = tx.QueryRow(ctx, "SELECT id FROM builder.user_identities WHERE provider = $1 AND sub = $2",
-		req.Provider, providerSub).Scan(&existingUser)
-	if err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			return nil, "", fmt.Errorf("err checking if user already registered, %v", err)
-		}
+	existingUserID, err := userRepo.GetUserIDByIdentity(ctx, string(req.Provider), providerSub)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", fmt.Errorf("err checking if user already registered, %v", err)
 	}
 	// user already registered with this provider -> create a new session or reuse existing
-	if existingUser.Valid {
-		_ = uow.Rollback()
-		return c.createSessionIfNotExists(ctx, uuid.MustParse(existingUser.String))
+	if err == nil {
+		var sessionInfo *dto.SessionInfo
+		var sessionID string
+		sessionInfo, sessionID, err = c.createSessionIfNotExists(ctx, tx, existingUserID)
+		return sessionInfo, sessionID, err
 	}
-	defer uow.Finalize(&err)
 
-	var existingUserAnotherProvider sql.NullString
-	err = tx.QueryRow(ctx, "SELECT id FROM builder.users WHERE email = $1", email).Scan(&existingUserAnotherProvider)
-	if err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			return nil, "", fmt.Errorf("err checking other identities, %v", err)
-		}
+	existingUserAnotherProvider, err := userRepo.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", fmt.Errorf("err checking other identities, %v", err)
 	}
 
 	// user already registered but with another provider -> create new user_identity
-	if existingUserAnotherProvider.Valid {
-		userID = uuid.MustParse(existingUserAnotherProvider.String)
-		_, err = tx.Exec(ctx, "INSERT INTO builder.user_identities(id, provider, sub) VALUES($1,$2,$3)",
-			userID, req.Provider, providerSub)
+	if err == nil {
+		userID = existingUserAnotherProvider.ID
+		err = userRepo.InsertIdentity(ctx, db.UserIdentity{UserID: userID, Provider: string(req.Provider), Sub: providerSub})
 		if err != nil {
 			return nil, "", fmt.Errorf("err creating new identity for existing user, %v", err)
 		}
@@ -300,13 +283,19 @@ func (c *Auth) VerifyOauth(ctx context.Context, req *dto.VerifyOauthToken) (*dto
 			}
 		}
 		userID = uuid.New()
-		_, err = tx.Exec(ctx, "INSERT INTO builder.users(id, first_name, second_name, status, email, created_at) VALUES($1,$2,$3,$4,$5,$6)",
-			userID, firstName, secondName, consts.UserConfirmed, email, time.Now())
+		err = userRepo.InsertUser(ctx, db.User{
+			ID:         userID,
+			FirstName:  firstName,
+			SecondName: secondName,
+			Status:     consts.UserConfirmed,
+			Email:      email,
+			CreatedAt:  time.Now(),
+		})
 		if err != nil {
 			return nil, "", fmt.Errorf("err inserting user, %v", err)
 		}
 
-		_, err = tx.Exec(ctx, "INSERT INTO builder.user_identities(id, provider, sub) VALUES($1,$2,$3)", userID, req.Provider, providerSub)
+		err = userRepo.InsertIdentity(ctx, db.UserIdentity{UserID: userID, Provider: string(req.Provider), Sub: providerSub})
 		if err != nil {
 			return nil, "", fmt.Errorf("err mapping user to identity, %v", err)
 		}
@@ -321,22 +310,14 @@ func (c *Auth) VerifyOauth(ctx context.Context, req *dto.VerifyOauthToken) (*dto
 			Data:    registrationSuccessData,
 		}
 
-		eventRepo := repo.NewEventRepo(tx)
-		err = eventRepo.InsertEvent(ctx, registrationSuccessMail)
+		err = c.repos.Events(tx).InsertEvent(ctx, registrationSuccessMail)
 		if err != nil {
 			return nil, "", err
 		}
 	}
 
-	session := db.Session{
-		ID:           uuid.New(),
-		UserID:       userID,
-		RefreshToken: uuid.NewString(),
-		ExpiresAt:    time.Now().Add(time.Hour * time.Duration(c.cfg.SessionLifetimeHours)),
-	}
-
-	_, err = tx.Exec(ctx, "INSERT INTO builder.sessions(id, user_id, refresh_token, expires_at) VALUES ($1,$2,$3,$4)",
-		session.ID, session.UserID, session.RefreshToken, session.ExpiresAt)
+	session := c.newSession(userID, uuid.NewString())
+	err = c.repos.Sessions(tx).InsertSession(ctx, session)
 	if err != nil {
 		return nil, "", fmt.Errorf("err creating oauth2 based session, %v", err)
 	}
@@ -349,10 +330,11 @@ func (c *Auth) VerifyOauth(ctx context.Context, req *dto.VerifyOauthToken) (*dto
 
 func (c *Auth) GetSession(ctx context.Context, id uuid.UUID) (*dto.SessionInfo, error) {
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	uow.SetRollbackOnly()
 	defer uow.Finalize(&err)
 
 	// TODO: retrieve from cache
@@ -361,7 +343,7 @@ func (c *Auth) GetSession(ctx context.Context, id uuid.UUID) (*dto.SessionInfo,
 
 func (c *Auth) DeleteUser(ctx context.Context, req *dto.DeleteUserRequest) error {
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return err
 	}
@@ -370,12 +352,15 @@ func (c *Auth) DeleteUser(ctx context.Context, req *dto.DeleteUserRequest) error
 	var errDB error
 	var userID uuid.UUID
 
-	err = tx.QueryRow(ctx, "SELECT id FROM builder.users WHERE email = $1", req.Email).Scan(&userID)
+	userRepo := c.repos.Users(tx)
+	user, err := userRepo.GetUserByEmail(ctx, req.Email)
 	if err != nil {
 		errDB = errors.Join(errDB, fmt.Errorf("err getting user by email %w", err))
+	} else {
+		userID = user.ID
 	}
 
-	identitiesToDelete, err := c.getUserIdentitiesToDelete(ctx, uow, userID)
+	identitiesToDelete, err := c.getUserIdentitiesToDelete(ctx, userRepo, userID)
 	if err != nil {
 		errDB = errors.Join(errDB, err)
 	}
@@ -391,7 +376,7 @@ func (c *Auth) DeleteUser(ctx context.Context, req *dto.DeleteUserRequest) error
 			}
 		}
 
-		_, err = tx.Exec(ctx, "DELETE FROM builder.user_identities WHERE id = $1", userID)
+		err = userRepo.DeleteIdentities(ctx, userID)
 		if err != nil {
 			return fmt.Errorf("err deleting user identities from db %v", err)
 		}
@@ -404,64 +389,61 @@ func (c *Auth) DeleteUser(ctx context.Context, req *dto.DeleteUserRequest) error
 			return fmt.Errorf("err deleting user from cognito by email: %v", err)
 		}
 	}
+	if errDB != nil {
+		slog.WarnContext(ctx, "user is partially deleted from db", "email", req.Email, "err", errDB)
+	}
 
 	return nil
 }
 
-func (c *Auth) getUserIdentitiesToDelete(ctx context.Context, uow interfaces.UoW, userID uuid.UUID) ([]string, error) {
+func (c *Auth) getUserIdentitiesToDelete(ctx context.Context, userRepo interfaces.UserRepo, userID uuid.UUID) ([]string, error) {
 	var errDb error
-	tx := uow.GetTx()
 
-	_, err := tx.Exec(ctx, "DELETE FROM builder.users WHERE id = $1", userID)
+	err := userRepo.DeleteUser(ctx, userID)
 	if err != nil {
 		errDb = errors.Join(errDb, fmt.Errorf("err deleting user from db %w", err))
 	}
 
-	rows, err := tx.Query(ctx, "SELECT provider, sub FROM builder.user_identities WHERE id = $1", userID)
+	identities, err := userRepo.GetIdentities(ctx, userID)
 	if err != nil {
 		errDb = errors.Join(errDb, fmt.Errorf("err getting user identities %w", err))
 	}
-	defer rows.Close()
 
 	var identitiesToDelete []string
-	for rows.Next() {
-		var provider string
-		var identity string
-		if err := rows.Scan(&provider, &identity); err != nil {
-			errDb = errors.Join(errDb, fmt.Errorf("err getting user identity %w", err))
+	for _, identity := range identities {
+		if identity.Provider == cognitoProvider {
+			identitiesToDelete = append(identitiesToDelete, identity.Sub)
 		}
-		if provider == "Cognito" {
-			identitiesToDelete = append(identitiesToDelete, identity)
-		}
-	}
-	if err := rows.Err(); err != nil {
-		errDb = errors.Join(errDb, fmt.Errorf("err closing rows %w", err))
 	}
 
-	return identitiesToDelete, nil
+	return identitiesToDelete, errDb
 }
 
 func (c *Auth) GetIdentity(ctx context.Context, id uuid.UUID) (*auth.Identity, error) {
 	if c.cfg.Mode == "TEST" {
 		return &auth.Identity{
-			UserID: c.cfg.TestUser,
+			UserID:  c.cfg.TestUser,
+			IsAdmin: slices.Contains(c.cfg.Admins, c.cfg.TestUser),
 		}, nil
 	}
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	uow.SetRollbackOnly()
 	defer uow.Finalize(&err)
 
 	// TODO: retrieve from cache
-	var identity auth.Identity
-	err = tx.QueryRow(ctx, "SELECT user_id FROM builder.sessions WHERE id = $1", id).Scan(&identity.UserID)
+	session, err := c.repos.Sessions(tx).GetSession(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("error getting session, %v", err)
 	}
 
-	return &identity, nil
+	return &auth.Identity{
+		UserID:  session.UserID,
+		IsAdmin: slices.Contains(c.cfg.Admins, session.UserID),
+	}, nil
 }
 
 func (c *Auth) ParseCookie(ctx context.Context, cookie string) (uuid.UUID, error) {
@@ -478,30 +460,40 @@ func (c *Auth) ParseCookie(ctx context.Context, cookie string) (uuid.UUID, error
 	return sessionID, nil
 }
 
-func (c *Auth) getSession(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (*dto.SessionInfo, error) {
-	var session dto.SessionInfo
-	var siteID sql.NullInt64
-	var templateID sql.NullInt16
-	err := tx.QueryRow(ctx,
-		"SELECT ss.user_id, u.email, s.id, s.template_id FROM builder.sessions ss "+
-			"JOIN builder.users u "+
-			"ON ss.user_id = u.id "+
-			"LEFT JOIN builder.sites s "+
-			"ON u.id = s.creator_id "+
-			"WHERE ss.id = $1 LIMIT 1", userID,
-	).Scan(&session.UserID, &session.Email, &siteID, &templateID)
+func (c *Auth) getSession(ctx context.Context, tx pgx.Tx, sessionID uuid.UUID) (*dto.SessionInfo, error) {
+	session, err := c.repos.Sessions(tx).GetSession(ctx, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("error getting session, %v", err)
 	}
 
-	if siteID.Valid {
-		session.UserSite = &dto.UserSite{
-			SiteID:     uint64(siteID.Int64),
-			TemplateID: uint8(templateID.Int16),
+	return c.getUserInfo(ctx, tx, session.UserID)
+}
+
+// getUserInfo returns user's email with the first site of a user, if any
+func (c *Auth) getUserInfo(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (*dto.SessionInfo, error) {
+	user, err := c.repos.Users(tx).GetUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("err getting user info, %v", err)
+	}
+
+	sessionInfo := &dto.SessionInfo{
+		UserID: user.ID,
+		Email:  user.Email,
+	}
+
+	site, err := c.repos.Sites(tx).GetFirstSiteOfCreator(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return sessionInfo, nil
 		}
+		return nil, fmt.Errorf("err getting user's site, %v", err)
+	}
+	sessionInfo.UserSite = &dto.UserSite{
+		SiteID:     site.ID,
+		TemplateID: site.TemplateID,
 	}
 
-	return &session, nil
+	return sessionInfo, nil
 }
 
 func (c *Auth) verifyGoogleIDToken(idToken string) (map[string]any, error) {
@@ -522,62 +514,35 @@ func (c *Auth) verifyGoogleIDToken(idToken string) (map[string]any, error) {
 	return claims, nil
 }
 
-func (c *Auth) createSessionIfNotExists(ctx context.Context, userID uuid.UUID) (*dto.SessionInfo, string, error) {
-	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+func (c *Auth) createSessionIfNotExists(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (*dto.SessionInfo, string, error) {
+	sessionRepo := c.repos.Sessions(tx)
+	session, err := sessionRepo.GetActiveSessionOfUser(ctx, userID, time.Now())
 	if err != nil {
-		return nil, "", err
-	}
-	defer uow.Finalize(&err)
-
-	var sessionID uuid.UUID
-	var existingSession sql.NullString
-	err = tx.QueryRow(ctx, "SELECT id FROM builder.sessions WHERE user_id = $1 AND expires_at < $2",
-		userID, time.Now()).Scan(&existingSession)
-	if err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			return nil, "", fmt.Errorf("error getting user by email, %v", err)
-		}
-	}
-
-	if !existingSession.Valid {
-		session := db.Session{
-			ID:           uuid.New(),
-			UserID:       userID,
-			RefreshToken: uuid.NewString(),
-			ExpiresAt:    time.Now().Add(time.Hour * time.Duration(c.cfg.SessionLifetimeHours)),
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, "", fmt.Errorf("err getting user's session, %v", err)
 		}
-		_, err = tx.Exec(ctx, "INSERT INTO builder.sessions(id, user_id, refresh_token, expires_at) VALUES ($1,$2,$3,$4)",
-			session.ID, session.UserID, session.RefreshToken, session.ExpiresAt)
-		if err != nil {
+		newSession := c.newSession(userID, uuid.NewString())
+		if err = sessionRepo.InsertSession(ctx, newSession); err != nil {
 			return nil, "", fmt.Errorf("err creating a session, %v", err)
 		}
-		sessionID = session.ID
+		session = &newSession
 	}
 
-	var siteID sql.NullInt64
-	var templateID sql.NullInt16
-	var email string
-	err = tx.QueryRow(ctx, "SELECT s.id, s.template_id, u.email FROM builder.users u "+
-		"LEFT JOIN builder.sites s ON u.id = s.creator_id "+
-		"WHERE u.id = $1 LIMIT 1", userID).Scan(&siteID, &templateID, &email)
+	sessionInfo, err := c.getUserInfo(ctx, tx, userID)
 	if err != nil {
 		return nil, "", fmt.Errorf("err getting existing user info, %v", err)
 	}
 
-	sessionInfo := &dto.SessionInfo{
-		UserID: userID,
-		Email:  email,
-	}
+	return sessionInfo, session.ID.String(), nil
+}
 
-	if siteID.Valid {
-		sessionInfo.UserSite = &dto.UserSite{
-			SiteID:     uint64(siteID.Int64),
-			TemplateID: uint8(templateID.Int16),
-		}
+func (c *Auth) newSession(userID uuid.UUID, refreshToken string) db.Session {
+	return db.Session{
+		ID:           uuid.New(),
+		UserID:       userID,
+		RefreshToken: refreshToken,
+		ExpiresAt:    time.Now().Add(time.Hour * time.Duration(c.cfg.SessionLifetimeHours)),
 	}
-
-	return sessionInfo, sessionID.String(), nil
 }
 
 func createUserFromClaims(claims jwt.MapClaims) (*db.User, error) {
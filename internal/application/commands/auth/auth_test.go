@@ -13,6 +13,7 @@ import (
 	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
 	"github.com/Builder-Lawyers/builder-backend/internal/testinfra"
 	"github.com/Builder-Lawyers/builder-backend/pkg/db"
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -48,7 +49,7 @@ func Test_CreateSession_Given_Existing_User_When_Called_Then_Create_Session_And_
 	defer clearTestState(t, email)
 	userID := createUser(ctx, email)
 	req := getRequest(ctx, email)
-	SUT := sut.NewAuth(db.NewUoWFactory(testinfra.Pool), auth.NewOIDCConfig(), cognitoClient)
+	SUT := sut.NewAuth(db.NewUoWFactory(testinfra.Pool), repo.NewRepositories(), auth.NewOIDCConfig(), cognitoClient)
 
 	sessionID, err := SUT.CreateSession(ctx, req)
 	require.NoError(t, err)
@@ -71,7 +72,7 @@ func Test_GetSession_Given_Valid_Session_Cookie_In_Request_When_Called_Then_Get_
 	userID := createUser(ctx, email)
 	siteID := createSite(ctx, userID)
 	req := getRequest(ctx, email)
-	SUT := sut.NewAuth(db.NewUoWFactory(testinfra.Pool), auth.NewOIDCConfig(), cognitoClient)
+	SUT := sut.NewAuth(db.NewUoWFactory(testinfra.Pool), repo.NewRepositories(), auth.NewOIDCConfig(), cognitoClient)
 	sessionID, err := SUT.CreateSession(ctx, req)
 	require.NoError(t, err)
 
@@ -47,7 +47,7 @@ func (c *UploadFile) Execute(ctx context.Context, fileHeader *multipart.FileHead
 	}
 
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +60,8 @@ func Test_Upload_File_When_Called_With_Valid_File_Then_Inserted_In_DB_And_Upload
 	require.NoError(t, err)
 
 	require.Equal(t, resp.FileID, createdFileID)
-	files := s3Storage.ListFiles(ctx, 1, &s3.ListObjectsV2Input{Bucket: aws.String(bucketName), Prefix: aws.String(prefix)})
+	files, err := s3Storage.ListKeys(ctx, prefix, 1)
+	require.NoError(t, err)
 	require.Len(t, files, 1)
 	objectKey := files[0][strings.LastIndex(files[0], "/")+1:] // get object key after prefix
 	require.Equal(t, createdFileID.String(), objectKey)
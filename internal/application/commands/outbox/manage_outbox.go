@@ -0,0 +1,183 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	actionReplay  = "Replay"
+	actionCancel  = "Cancel"
+	actionEnqueue = "Enqueue"
+)
+
+// ManageOutbox holds admin actions on outbox events, each of them is audited in the same transaction
+type ManageOutbox struct {
+	uowFactory *dbs.UOWFactory
+	registry   *events.Registry
+}
+
+func NewManageOutbox(uowFactory *dbs.UOWFactory, registry *events.Registry) *ManageOutbox {
+	return &ManageOutbox{uowFactory: uowFactory, registry: registry}
+}
+
+// Replay returns a failed event to the queue with a fresh retry budget
+func (c *ManageOutbox) Replay(ctx context.Context, id uint64, req *dto.OutboxActionRequest, identity *auth.Identity) error {
+	if !identity.IsAdmin {
+		return errs.PermissionsError{Err: fmt.Errorf("only admins can replay events")}
+	}
+
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer uow.Finalize(&err)
+
+	eventRepo := repo.NewEventRepo(tx)
+	outbox, err := getEvent(ctx, eventRepo, id)
+	if err != nil {
+		return err
+	}
+
+	status := consts.OutboxStatus(outbox.Status)
+	if status != consts.InError && status != consts.DeadLettered && status != consts.Unhandled {
+		err = errs.InvalidStateError{Err: fmt.Errorf("event %v in status %v can't be replayed", id, status)}
+		return err
+	}
+
+	if err = eventRepo.RequeueEvent(ctx, *outbox); err != nil {
+		return err
+	}
+
+	err = eventRepo.InsertAudit(ctx, newAudit(id, actionReplay, identity, &status, req.Reason))
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "event is replayed", "id", id, "event", outbox.Event, "by", identity.UserID)
+
+	return nil
+}
+
+// Cancel marks a pending event, so that it won't be processed
+func (c *ManageOutbox) Cancel(ctx context.Context, id uint64, req *dto.OutboxActionRequest, identity *auth.Identity) error {
+	if !identity.IsAdmin {
+		return errs.PermissionsError{Err: fmt.Errorf("only admins can cancel events")}
+	}
+
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer uow.Finalize(&err)
+
+	eventRepo := repo.NewEventRepo(tx)
+	outbox, err := getEvent(ctx, eventRepo, id)
+	if err != nil {
+		return err
+	}
+
+	// events in Processing are owned by a worker, they can only be cancelled after the attempt fails
+	status := consts.OutboxStatus(outbox.Status)
+	if status != consts.NotProcessed && status != consts.InError {
+		err = errs.InvalidStateError{Err: fmt.Errorf("event %v in status %v can't be cancelled", id, status)}
+		return err
+	}
+
+	if err = eventRepo.UpdateEventStatus(ctx, id, consts.Cancelled); err != nil {
+		return err
+	}
+
+	err = eventRepo.InsertAudit(ctx, newAudit(id, actionCancel, identity, &status, req.Reason))
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "event is cancelled", "id", id, "event", outbox.Event, "by", identity.UserID)
+
+	return nil
+}
+
+// Enqueue stores an event of a registered type, f.e. DeactivateSite for a site violating terms
+func (c *ManageOutbox) Enqueue(ctx context.Context, req *dto.EnqueueOutboxEventRequest, identity *auth.Identity) (uint64, error) {
+	if !identity.IsAdmin {
+		return 0, errs.PermissionsError{Err: fmt.Errorf("only admins can enqueue events")}
+	}
+
+	registration, ok := c.registry.Lookup(req.Event)
+	if !ok {
+		return 0, errs.ValidationError{Err: fmt.Errorf("no handler is registered for event %v", req.Event)}
+	}
+	payload, err := json.Marshal(req.Payload)
+	if err != nil {
+		return 0, errs.ValidationError{Err: err}
+	}
+	if err = registration.Validate(payload); err != nil {
+		return 0, errs.ValidationError{Err: err}
+	}
+
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer uow.Finalize(&err)
+
+	eventRepo := repo.NewEventRepo(tx)
+	id, err := eventRepo.InsertRawEvent(ctx, req.Event, payload)
+	if err != nil {
+		return 0, err
+	}
+
+	err = eventRepo.InsertAudit(ctx, newAudit(id, actionEnqueue, identity, nil, req.Reason))
+	if err != nil {
+		return 0, err
+	}
+	slog.InfoContext(ctx, "event is enqueued", "id", id, "event", req.Event, "by", identity.UserID)
+
+	return id, nil
+}
+
+func getEvent(ctx context.Context, eventRepo *repo.EventRepo, id uint64) (*db.Outbox, error) {
+	outbox, err := eventRepo.GetEventForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.NotFoundError{Err: fmt.Errorf("event %v doesn't exist", id)}
+		}
+		return nil, fmt.Errorf("err getting event, %v", err)
+	}
+
+	return outbox, nil
+}
+
+func newAudit(eventID uint64, action string, identity *auth.Identity, previousStatus *consts.OutboxStatus, reason *string) db.OutboxAudit {
+	audit := db.OutboxAudit{
+		EventID:   eventID,
+		Action:    action,
+		ActorID:   identity.UserID,
+		CreatedAt: time.Now(),
+	}
+	if previousStatus != nil {
+		audit.PreviousStatus = sql.NullInt16{Int16: int16(*previousStatus), Valid: true}
+	}
+	if reason != nil {
+		audit.Reason = sql.NullString{String: *reason, Valid: true}
+	}
+
+	return audit
+}
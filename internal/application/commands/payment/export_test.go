@@ -0,0 +1,8 @@
+package payment
+
+import "context"
+
+// ReactivatePaidSite runs the invoice.paid handling without fetching the subscription from stripe
+func (c *Payment) ReactivatePaidSite(ctx context.Context, subscriptionID string) error {
+	return c.reactivatePaidSite(ctx, subscriptionID)
+}
@@ -2,7 +2,6 @@ package payment
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,18 +15,24 @@ import (
 	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/lifecycle"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
-	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/mail"
-	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	"github.com/jackc/pgx/v5"
 	"github.com/stripe/stripe-go/v82"
 	"github.com/stripe/stripe-go/v82/checkout/session"
 	"github.com/stripe/stripe-go/v82/subscription"
 	"github.com/stripe/stripe-go/v82/webhook"
 )
 
+// paymentFailedReason - reason of deactivations, that are reverted when the subscription is paid again
+const paymentFailedReason = "Payment for subscription wasn't successful"
+
 type Payment struct {
-	uowFactory *dbs.UOWFactory
+	uowFactory interfaces.UoWFactory
+	repos      interfaces.Repositories
+	lifecycle  *lifecycle.SiteLifecycle
 	cfg        PaymentConfig
 }
 
@@ -45,11 +50,13 @@ func NewPaymentConfig() PaymentConfig {
 	}
 }
 
-func NewPayment(uowFactory *dbs.UOWFactory, cfg PaymentConfig) *Payment {
+func NewPayment(uowFactory interfaces.UoWFactory, repos interfaces.Repositories, cfg PaymentConfig) *Payment {
 	stripe.Key = cfg.apiKey
 	stripe.SetHTTPClient(&http.Client{Timeout: 10 * time.Second})
 	return &Payment{
 		uowFactory: uowFactory,
+		repos:      repos,
+		lifecycle:  lifecycle.NewSiteLifecycle(repos),
 		cfg:        cfg,
 	}
 }
@@ -57,32 +64,32 @@ func NewPayment(uowFactory *dbs.UOWFactory, cfg PaymentConfig) *Payment {
 func (c *Payment) CreatePayment(ctx context.Context, req *dto.CreatePaymentRequest, identity *auth.Identity) (string, error) {
 
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return "", err
 	}
-	// TODO: improve uow api to be able to only rollback at the end of a function, not commit
+	// only the subscription branch writes, it commits explicitly
+	uow.SetRollbackOnly()
 	defer uow.Finalize(&err)
-	var existingSubID sql.NullString
-	err = tx.QueryRow(ctx, "SELECT subscription_id FROM builder.sites WHERE id = $1", req.SiteID).Scan(&existingSubID)
+	siteRepo := c.repos.Sites(tx)
+	site, err := siteRepo.GetSite(ctx, uint64(req.SiteID))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			slog.Info("There's no subscription for site yet", "siteID", req.SiteID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			slog.InfoContext(ctx, "There's no subscription for site yet", "siteID", req.SiteID)
 		} else {
 			return "", fmt.Errorf("error retrieving subscription, %v", err)
 		}
 	}
 
-	if existingSubID.Valid {
+	if site != nil && site.SubscriptionID != "" {
 		return "", fmt.Errorf("subscription is already created for this site")
 	}
 
-	var stripePlanID string
-	err = tx.QueryRow(ctx, "SELECT stripe_id FROM builder.payment_plans WHERE id = $1",
-		req.PlanID).Scan(&stripePlanID)
+	plan, err := c.repos.Plans(tx).GetPlan(ctx, req.PlanID)
 	if err != nil {
 		return "", fmt.Errorf("error retrieving stripe price, %v", err)
 	}
+	stripePlanID := plan.StripeID
 
 	if req.PlanID == 1 {
 		s, err := subscription.New(&stripe.SubscriptionParams{
@@ -99,10 +106,13 @@ func (c *Payment) CreatePayment(ctx context.Context, req *dto.CreatePaymentReque
 			return "", fmt.Errorf("error creating sub, %v", err)
 		}
 
-		_, err = tx.Exec(ctx, "UPDATE builder.sites SET subscription_id = $1 WHERE id = $2", s.ID, req.SiteID)
+		err = siteRepo.UpdateSiteSubscription(ctx, uint64(req.SiteID), s.ID)
 		if err != nil {
 			return "", fmt.Errorf("err updating site subscription, %v", err)
 		}
+		if err = uow.Commit(); err != nil {
+			return "", fmt.Errorf("err saving site subscription, %v", err)
+		}
 
 		return s.ID, nil
 	}
@@ -135,12 +145,12 @@ func (c *Payment) CreatePayment(ctx context.Context, req *dto.CreatePaymentReque
 		SubscriptionData: subParams,
 	}
 
-	slog.Info("Creating a checkout session")
+	slog.InfoContext(ctx, "Creating a checkout session")
 	s, err := session.New(params)
 	if err != nil {
 		return "", fmt.Errorf("error creating session: %v", err)
 	}
-	slog.Info("After create session")
+	slog.InfoContext(ctx, "After create session")
 
 	return s.ClientSecret, nil
 }
@@ -171,37 +181,31 @@ func (c *Payment) GetPaymentInfo(ctx context.Context, sessionID string) (*dto.Pa
 
 func (c *Payment) ListPaymentPlans(ctx context.Context) (*dto.PaymentPlanList, error) {
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer uow.Finalize(&err)
 
-	paymentPlans := make([]dto.PaymentPlan, 0, 2)
-
-	rows, err := tx.Query(ctx, "SELECT id, description, features, price FROM builder.payment_plans")
+	plans, err := c.repos.Plans(tx).ListPlans(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("err getting plans %v", err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var plan dto.PaymentPlan
-		var featuresRaw []byte
+	paymentPlans := make([]dto.PaymentPlan, 0, len(plans))
+	for _, plan := range plans {
 		var features featuresJSON
-		if err = rows.Scan(&plan.Id, &plan.Description, &featuresRaw, &plan.Price); err != nil {
-			return nil, err
-		}
-		err = json.Unmarshal(featuresRaw, &features)
+		err = json.Unmarshal(plan.Features, &features)
 		if err != nil {
 			return nil, fmt.Errorf("err getting plan info %v", err)
 		}
-		plan.Included = features.Yes
-		plan.Excluded = features.No
-		paymentPlans = append(paymentPlans, plan)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		paymentPlans = append(paymentPlans, dto.PaymentPlan{
+			Id:          plan.ID,
+			Description: plan.Description,
+			Price:       plan.Price,
+			Included:    features.Yes,
+			Excluded:    features.No,
+		})
 	}
 
 	return &paymentPlans, nil
@@ -213,7 +217,7 @@ func (c *Payment) Webhook(ctx context.Context, req []byte, stripeHeader string)
 		return fmt.Errorf("error creating event, %v", err)
 	}
 
-	slog.Info("Handling event", "type", event.Type)
+	slog.InfoContext(ctx, "Handling event", "type", event.Type)
 
 	switch event.Type {
 
@@ -223,6 +227,9 @@ func (c *Payment) Webhook(ctx context.Context, req []byte, stripeHeader string)
 	case "invoice.payment_failed":
 		return c.handlePaymentFailed(ctx, event)
 
+	case "invoice.paid":
+		return c.handleInvoicePaid(ctx, event)
+
 	default:
 		return fmt.Errorf("Unhandled event type: %s\n", event.Type)
 	}
@@ -236,18 +243,16 @@ func (c *Payment) handleTrialEnds(ctx context.Context, event stripe.Event) error
 		return fmt.Errorf("error parsing subscription, %v", err)
 	}
 
-	slog.Info("Trial will end for subscription", "sub", subscription.ID, "customer", subscription.Customer.ID)
+	slog.InfoContext(ctx, "Trial will end for subscription", "sub", subscription.ID, "customer", subscription.Customer.ID)
 
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return err
 	}
-	var userID string
-	var firstName string
-	var secondName string
-	err = tx.QueryRow(ctx, "SELECT id, first_name, second_name FROM builder.users WHERE stripe_id = $1",
-		subscription.Customer.ID).Scan(&userID, &firstName, &secondName)
+	defer uow.Finalize(&err)
+
+	user, err := c.repos.Users(tx).GetUserByStripeID(ctx, subscription.Customer.ID)
 	if err != nil {
 		return fmt.Errorf("err finding user, %v", err)
 	}
@@ -267,27 +272,21 @@ func (c *Payment) handleTrialEnds(ctx context.Context, event stripe.Event) error
 		DaysUntilEnd:       daysUntilEnd,
 		PaymentURL:         session.URL,
 		Year:               strconv.Itoa(time.Now().Year()),
-		CustomerFirstName:  firstName,
-		CustomerSecondName: secondName,
+		CustomerFirstName:  user.FirstName,
+		CustomerSecondName: user.SecondName,
 	}
 
 	sendMailEvent := events.SendMail{
-		UserID:  userID,
+		UserID:  user.ID.String(),
 		Subject: mailData.GetSubject(),
 		Data:    mailData,
 	}
-	eventRepo := repo.NewEventRepo(tx)
-	err = eventRepo.InsertEvent(ctx, sendMailEvent)
+	err = c.repos.Events(tx).InsertEvent(ctx, sendMailEvent)
 	if err != nil {
 		return err
 	}
 
-	slog.Info("Event sendMail created", "subID", subscription.ID)
-
-	err = uow.Commit()
-	if err != nil {
-		return fmt.Errorf("error commiting, %v", err)
-	}
+	slog.InfoContext(ctx, "Event sendMail created", "subID", subscription.ID)
 
 	return nil
 }
@@ -296,9 +295,91 @@ func (c *Payment) handlePaymentFailed(ctx context.Context, event stripe.Event) e
 
 	// TODO: before deactivating site totally, send an email warning a user that site is about to be deactivated
 	// if user doesn't retry payment (if it was a failure in payment)
+	sub, err := getInvoiceSubscription(event)
+	if err != nil {
+		return err
+	}
+
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer uow.Finalize(&err)
+
+	site, err := c.repos.Sites(tx).GetSiteBySubscription(ctx, sub.ID)
+	if err != nil {
+		return fmt.Errorf("error getting site for subscription, %v", err)
+	}
+
+	if c.lifecycle.CanTransition(site.Status, consts.SiteStatusAwaitingDeactivation) {
+		err = c.lifecycle.Transition(ctx, tx, site, consts.SiteStatusAwaitingDeactivation, lifecycle.TransitionParams{
+			Actor:  consts.ActorWebhook,
+			Reason: paymentFailedReason,
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		slog.InfoContext(ctx, "Site can't be deactivated in its status", "siteID", site.ID, "status", site.Status)
+	}
+
+	return nil
+}
+
+// handleInvoicePaid reactivates a site, deactivated due to a failed payment. Paid invoices of active sites are skipped
+func (c *Payment) handleInvoicePaid(ctx context.Context, event stripe.Event) error {
+	sub, err := getInvoiceSubscription(event)
+	if err != nil {
+		return err
+	}
+
+	return c.reactivatePaidSite(ctx, sub.ID)
+}
+
+// reactivatePaidSite reverts only deactivations caused by a failed payment. Sites deactivated by their owners stay so,
+// and a site awaiting deletion is restored only by its owner
+func (c *Payment) reactivatePaidSite(ctx context.Context, subscriptionID string) (err error) {
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer uow.Finalize(&err)
+
+	site, err := c.repos.Sites(tx).GetSiteBySubscription(ctx, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("error getting site for subscription, %v", err)
+	}
+
+	if site.Status != consts.SiteStatusDeactivated {
+		slog.InfoContext(ctx, "Site doesn't need reactivation", "siteID", site.ID, "status", site.Status)
+		return nil
+	}
+	deactivation, err := c.lifecycle.LastStatusChange(ctx, tx, site.ID, consts.SiteStatusAwaitingDeactivation)
+	if err != nil {
+		return err
+	}
+	if deactivation == nil || deactivation.Actor != consts.ActorWebhook || deactivation.Reason != paymentFailedReason {
+		slog.InfoContext(ctx, "Site wasn't deactivated due to a failed payment, skipping reactivation", "siteID", site.ID)
+		return nil
+	}
+
+	err = c.lifecycle.Transition(ctx, tx, site, consts.SiteStatusAwaitingReactivation, lifecycle.TransitionParams{
+		Actor:  consts.ActorWebhook,
+		Reason: "Payment for subscription was received",
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func getInvoiceSubscription(event stripe.Event) (*stripe.Subscription, error) {
 	var invoice stripe.Invoice
 	if err := json.Unmarshal(event.Data.Raw, &invoice); err != nil {
-		return fmt.Errorf("error parsing event, %v", err)
+		return nil, fmt.Errorf("error parsing event, %v", err)
 	}
 	var subID string
 	if invoice.Parent != nil &&
@@ -314,51 +395,16 @@ func (c *Payment) handlePaymentFailed(ctx context.Context, event stripe.Event) e
 	//
 	//got, err := invoice.Get(invoice.ID, params)
 	//if err != nil {
-	//	return fmt.Errorf("error getting invoice, %v", err)
+	//	return nil, fmt.Errorf("error getting invoice, %v", err)
 	//}
 	//
 	//sub := got.Parent.SubscriptionDetails.Subscription
 	sub, err := subscription.Get(subID, &stripe.SubscriptionParams{})
 	if err != nil {
-		return fmt.Errorf("error getting subscription, %v", err)
+		return nil, fmt.Errorf("error getting subscription, %v", err)
 	}
 
-	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
-	if err != nil {
-		return err
-	}
-
-	var siteID uint64
-	var siteStatus consts.SiteStatus
-	err = tx.QueryRow(ctx, "SELECT id, status FROM builder.sites WHERE subscription_id = $1",
-		sub.ID).Scan(&siteID, &siteStatus)
-	if err != nil {
-		return fmt.Errorf("error getting site for subscription, %v", err)
-	}
-
-	if siteStatus == consts.SiteStatusCreated {
-		deactivateSite := events.DeactivateSite{
-			SiteID: siteID,
-			Reason: "Payment for subscription wasn't successful",
-		}
-
-		eventRepo := repo.NewEventRepo(tx)
-		err = eventRepo.InsertEvent(ctx, deactivateSite)
-		if err != nil {
-			return err
-		}
-
-	} else {
-		slog.Info("Site is not provisioned yet", "siteID", siteID)
-	}
-
-	err = uow.Commit()
-	if err != nil {
-		return fmt.Errorf("error commiting tx, %v", err)
-	}
-
-	return nil
+	return sub, nil
 }
 
 type featuresJSON struct {
@@ -0,0 +1,81 @@
+package payment_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/commands/payment"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo/memory"
+	"github.com/stretchr/testify/require"
+)
+
+// deactivatedSite - site with subscription "sub_1", that went through the deactivation recorded in its history
+func deactivatedSite(status consts.SiteStatus, history ...db.SiteEvent) *memory.Store {
+	store := memory.NewStore()
+	store.SitesByID[1] = db.Site{ID: 1, Status: status, SubscriptionID: "sub_1"}
+	store.ProvisionsBySite[1] = db.Provision{SiteID: 1, Status: consts.ProvisionStatusDeactivated}
+	for _, event := range history {
+		event.SiteID = 1
+		event.Kind = consts.SiteEventStatus
+		store.SiteHistory = append(store.SiteHistory, event)
+	}
+	return store
+}
+
+var (
+	deactivatedByPayment = db.SiteEvent{FromStatus: string(consts.SiteStatusCreated),
+		ToStatus: string(consts.SiteStatusAwaitingDeactivation), Actor: consts.ActorWebhook,
+		Reason: "Payment for subscription wasn't successful"}
+	deactivatedByOwner = db.SiteEvent{FromStatus: string(consts.SiteStatusCreated),
+		ToStatus: string(consts.SiteStatusAwaitingDeactivation), Actor: consts.ActorUser, Reason: "not needed"}
+)
+
+func Test_ReactivatePaidSite_When_Site_Was_Deactivated_By_Failed_Payment_Then_Request_Reactivation(t *testing.T) {
+	store := deactivatedSite(consts.SiteStatusDeactivated, deactivatedByPayment)
+	SUT := payment.NewPayment(memory.NewUoWFactory(), store, payment.PaymentConfig{})
+
+	err := SUT.ReactivatePaidSite(context.Background(), "sub_1")
+	require.NoError(t, err)
+
+	require.Equal(t, consts.SiteStatusAwaitingReactivation, store.SitesByID[1].Status)
+	require.Len(t, store.InsertedEvents, 1)
+	require.IsType(t, events.ReactivateSite{}, store.InsertedEvents[0])
+}
+
+func Test_ReactivatePaidSite_When_Site_Was_Deactivated_By_Owner_Then_Keep_It_Deactivated(t *testing.T) {
+	store := deactivatedSite(consts.SiteStatusDeactivated, deactivatedByOwner)
+	SUT := payment.NewPayment(memory.NewUoWFactory(), store, payment.PaymentConfig{})
+
+	err := SUT.ReactivatePaidSite(context.Background(), "sub_1")
+	require.NoError(t, err)
+
+	require.Equal(t, consts.SiteStatusDeactivated, store.SitesByID[1].Status)
+	require.Empty(t, store.InsertedEvents)
+}
+
+func Test_ReactivatePaidSite_When_Owner_Deactivated_Site_After_Failed_Payment_Then_Keep_It_Deactivated(t *testing.T) {
+	reactivated := db.SiteEvent{FromStatus: string(consts.SiteStatusDeactivated),
+		ToStatus: string(consts.SiteStatusAwaitingReactivation), Actor: consts.ActorWebhook}
+	store := deactivatedSite(consts.SiteStatusDeactivated, deactivatedByPayment, reactivated, deactivatedByOwner)
+	SUT := payment.NewPayment(memory.NewUoWFactory(), store, payment.PaymentConfig{})
+
+	err := SUT.ReactivatePaidSite(context.Background(), "sub_1")
+	require.NoError(t, err)
+
+	require.Equal(t, consts.SiteStatusDeactivated, store.SitesByID[1].Status)
+	require.Empty(t, store.InsertedEvents)
+}
+
+func Test_ReactivatePaidSite_When_Site_Awaits_Deletion_Then_Keep_It_For_Owner(t *testing.T) {
+	store := deactivatedSite(consts.SiteStatusAwaitingDeletion, deactivatedByPayment)
+	SUT := payment.NewPayment(memory.NewUoWFactory(), store, payment.PaymentConfig{})
+
+	err := SUT.ReactivatePaidSite(context.Background(), "sub_1")
+	require.NoError(t, err)
+
+	require.Equal(t, consts.SiteStatusAwaitingDeletion, store.SitesByID[1].Status)
+	require.Empty(t, store.InsertedEvents)
+}
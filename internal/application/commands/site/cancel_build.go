@@ -0,0 +1,69 @@
+package site
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type CancelBuild struct {
+	uowFactory interfaces.UoWFactory
+	repos      interfaces.Repositories
+}
+
+func NewCancelBuild(factory interfaces.UoWFactory, repos interfaces.Repositories) *CancelBuild {
+	return &CancelBuild{uowFactory: factory, repos: repos}
+}
+
+// Execute cancels a queued build at once, a running one is stopped by RebuildSite, when it sees the request
+func (c *CancelBuild) Execute(ctx context.Context, siteID uint64, jobID uuid.UUID, identity *auth.Identity) error {
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer uow.Finalize(&err)
+
+	_, err = getOwnedSite(ctx, c.repos.Sites(tx), siteID, identity)
+	if err != nil {
+		return err
+	}
+	buildRepo := c.repos.SiteBuilds(tx)
+	build, err := buildRepo.GetBuild(ctx, jobID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("err getting site build, %v", err)
+	}
+	if build == nil || build.SiteID != siteID {
+		err = errs.NotFoundError{Err: fmt.Errorf("site %v has no build %v", siteID, jobID)}
+		return err
+	}
+	if build.Status != consts.BuildStatusQueued && build.Status != consts.BuildStatusRunning {
+		err = errs.InvalidStateError{Err: fmt.Errorf("build %v is %v, it can't be cancelled", jobID, build.Status)}
+		return err
+	}
+
+	err = buildRepo.RequestCancel(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if build.Status == consts.BuildStatusQueued {
+		now := time.Now()
+		build.Status = consts.BuildStatusCancelled
+		build.UpdatedAt = now
+		build.FinishedAt = &now
+		err = buildRepo.UpdateBuild(ctx, *build)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
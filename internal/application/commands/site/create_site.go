@@ -2,37 +2,44 @@ package site
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
-	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
 	"github.com/jackc/pgx/v5"
 )
 
 type CreateSite struct {
-	uowFactory *dbs.UOWFactory
+	uowFactory interfaces.UoWFactory
+	repos      interfaces.Repositories
 }
 
-func NewCreateSite(factory *dbs.UOWFactory) *CreateSite {
-	return &CreateSite{uowFactory: factory}
+func NewCreateSite(factory interfaces.UoWFactory, repos interfaces.Repositories) *CreateSite {
+	return &CreateSite{uowFactory: factory, repos: repos}
 }
 
 func (c *CreateSite) Execute(ctx context.Context, req *dto.CreateSiteRequest, identity *auth.Identity) (uint64, error) {
 	uow := c.uowFactory.GetUoW()
 
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return 0, err
 	}
 	defer uow.Finalize(&err)
 
-	err = c.checkDuplicateSitesByUser(ctx, tx, identity)
+	siteRepo := c.repos.Sites(tx)
+	// concurrent requests of a user would pass the checks below before any of them inserts a site
+	err = siteRepo.LockCreator(ctx, identity.UserID)
+	if err != nil {
+		return 0, err
+	}
+	err = c.checkPlanLimit(ctx, tx, req.PlanID, identity)
 	if err != nil {
 		return 0, err
 	}
@@ -47,9 +54,7 @@ func (c *CreateSite) Execute(ctx context.Context, req *dto.CreateSiteRequest, id
 		UpdatedAt:  time.Now(),
 	}
 
-	insertQuery := "INSERT INTO builder.sites(template_id, creator_id, plan_id, status, fields, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id"
-	err = tx.QueryRow(ctx, insertQuery, newSite.TemplateID, newSite.CreatorID, newSite.PlanID, newSite.Status,
-		newSite.Fields, newSite.CreatedAt, newSite.UpdatedAt).Scan(&newSite.ID)
+	newSite.ID, err = siteRepo.InsertSite(ctx, newSite)
 	if err != nil {
 		return 0, fmt.Errorf("insert failed: %v", err)
 	}
@@ -57,18 +62,22 @@ func (c *CreateSite) Execute(ctx context.Context, req *dto.CreateSiteRequest, id
 	return newSite.ID, nil
 }
 
-func (c *CreateSite) checkDuplicateSitesByUser(ctx context.Context, tx pgx.Tx, identity *auth.Identity) error {
-	var duplicateID int64
-	err := tx.QueryRow(ctx, "SELECT id FROM builder.sites WHERE creator_id = $1 AND created_at > $2",
-		identity.UserID, time.Now().Add(-time.Minute*5),
-	).Scan(&duplicateID)
+// checkPlanLimit - user can't have more not deleted sites on a plan, than the plan allows
+func (c *CreateSite) checkPlanLimit(ctx context.Context, tx pgx.Tx, planID uint8, identity *auth.Identity) error {
+	plan, err := c.repos.Plans(tx).GetPlan(ctx, planID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			// no duplicates, all good
-			return nil
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errs.ValidationError{Err: fmt.Errorf("plan %v doesn't exist", planID)}
 		}
-		return fmt.Errorf("can't check for duplicate site, %v", err)
+		return fmt.Errorf("err getting plan, %v", err)
+	}
+	owned, err := c.repos.Sites(tx).CountSites(ctx, interfaces.SiteFilter{CreatorID: identity.UserID, PlanID: planID})
+	if err != nil {
+		return err
+	}
+	if owned >= plan.MaxSites {
+		return errs.InvalidStateError{Err: fmt.Errorf("plan %v allows only %v sites", planID, plan.MaxSites)}
 	}
 
-	return fmt.Errorf("site was already created by this user recently")
+	return nil
 }
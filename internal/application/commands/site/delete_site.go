@@ -2,52 +2,64 @@ package site
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"fmt"
 	"time"
 
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
-	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/lifecycle"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
-	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
-	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
 )
 
 type DeleteSite struct {
-	uowFactory *dbs.UOWFactory
+	uowFactory interfaces.UoWFactory
+	repos      interfaces.Repositories
+	lifecycle  *lifecycle.SiteLifecycle
+	// gracePeriod - how long resources of a deactivated site are kept
+	gracePeriod time.Duration
 }
 
-func NewDeleteSite(UOWFactory *dbs.UOWFactory) *DeleteSite {
-	return &DeleteSite{uowFactory: UOWFactory}
+func NewDeleteSite(UOWFactory interfaces.UoWFactory, repos interfaces.Repositories, gracePeriod time.Duration) *DeleteSite {
+	return &DeleteSite{uowFactory: UOWFactory, repos: repos, lifecycle: lifecycle.NewSiteLifecycle(repos), gracePeriod: gracePeriod}
 }
 
 func (c *DeleteSite) Execute(ctx context.Context, siteID uint64, identity *auth.Identity) error {
 
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer uow.Finalize(&err)
 
-	var createdAt time.Time
-	err = tx.QueryRow(ctx, "SELECT created_at FROM builder.sites WHERE id = $1 AND creator_id = $2",
-		siteID, identity.UserID).Scan(&createdAt)
+	site, err := getOwnedSite(ctx, c.repos.Sites(tx), siteID, identity)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return errs.PermissionsError{Err: err}
-		}
-		return fmt.Errorf("err checking if user owns site, %v", err)
+		return err
 	}
 
-	deactivateSiteEvent := events.DeactivateSite{
-		SiteID: siteID,
-		Reason: "Site deactivation was requested by it's owner",
+	params := lifecycle.TransitionParams{
+		Actor:   consts.ActorUser,
+		ActorID: &identity.UserID,
+		Reason:  "Site deletion was requested by it's owner",
+		Delete:  true,
 	}
-
-	eventRepo := repo.NewEventRepo(tx)
-	err = eventRepo.InsertEvent(ctx, deactivateSiteEvent)
+	var newStatus consts.SiteStatus
+	switch site.Status {
+	// site, that wasn't provisioned yet, has nothing to deactivate
+	case consts.SiteStatusInCreation:
+		newStatus = consts.SiteStatusDeleted
+	// grace period starts, when deactivation is finished
+	case consts.SiteStatusCreated:
+		newStatus = consts.SiteStatusAwaitingDeactivation
+	case consts.SiteStatusDeactivated:
+		newStatus = consts.SiteStatusAwaitingDeletion
+		params.DeleteAt = time.Now().Add(c.gracePeriod)
+	default:
+		err = errs.InvalidStateError{Err: fmt.Errorf("site in status %v can't be deleted", site.Status)}
+		return err
+	}
+	err = c.lifecycle.Transition(ctx, tx, site, newStatus, params)
 	if err != nil {
 		return err
 	}
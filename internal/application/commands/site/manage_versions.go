@@ -0,0 +1,116 @@
+package site
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/lifecycle"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/publishing"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// ManageVersions publishes site's draft as immutable versions and switches between them
+type ManageVersions struct {
+	uowFactory interfaces.UoWFactory
+	repos      interfaces.Repositories
+	publisher  *publishing.Publisher
+}
+
+func NewManageVersions(factory interfaces.UoWFactory, repos interfaces.Repositories, publisher *publishing.Publisher) *ManageVersions {
+	return &ManageVersions{uowFactory: factory, repos: repos, publisher: publisher}
+}
+
+// Publish queues a build of site's draft, RebuildSite publishes it as a new version, when the build succeeds
+func (c *ManageVersions) Publish(ctx context.Context, siteID uint64, identity *auth.Identity) (*dto.SiteBuild, error) {
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer uow.Finalize(&err)
+
+	_, _, err = c.getPublishedSite(ctx, tx, siteID, identity)
+	if err != nil {
+		return nil, err
+	}
+	// versions are numbered when they are built, so publishes of a site don't run concurrently
+	active, err := c.repos.SiteBuilds(tx).CountActiveBuilds(ctx, siteID, consts.BuildKindPublish)
+	if err != nil {
+		return nil, err
+	}
+	if active > 0 {
+		err = errs.InvalidStateError{Err: fmt.Errorf("site %v is already being published", siteID)}
+		return nil, err
+	}
+
+	build, err := queueBuild(ctx, tx, c.repos, siteID, consts.BuildKindPublish, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return build, nil
+}
+
+// Rollback points site's distribution to an earlier version, its build is reused, draft isn't changed
+func (c *ManageVersions) Rollback(ctx context.Context, siteID uint64, number int, identity *auth.Identity) error {
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer uow.Finalize(&err)
+
+	site, provision, err := c.getPublishedSite(ctx, tx, siteID, identity)
+	if err != nil {
+		return err
+	}
+	if site.PublishedVersion == number {
+		slog.InfoContext(ctx, "version is already published", "siteID", siteID, "version", number)
+		return nil
+	}
+	version, err := c.repos.SiteVersions(tx).GetSiteVersion(ctx, siteID, number)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = errs.NotFoundError{Err: fmt.Errorf("site %v has no version %v", siteID, number)}
+			return err
+		}
+		return fmt.Errorf("err getting site version, %v", err)
+	}
+
+	err = c.publisher.SwitchVersion(ctx, tx, site, provision, version, lifecycle.TransitionParams{
+		Actor:   consts.ActorUser,
+		ActorID: &identity.UserID,
+		Reason:  fmt.Sprintf("Site was rolled back to version %v", number),
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// getPublishedSite - versions can be switched only for a provisioned site, that is served by a distribution
+func (c *ManageVersions) getPublishedSite(ctx context.Context, tx pgx.Tx, siteID uint64, identity *auth.Identity,
+) (*db.Site, *db.Provision, error) {
+	site, err := getOwnedSite(ctx, c.repos.Sites(tx), siteID, identity)
+	if err != nil {
+		return nil, nil, err
+	}
+	if site.Status != consts.SiteStatusCreated {
+		return nil, nil, errs.InvalidStateError{Err: fmt.Errorf("site %v is %v, only a created site can be published", siteID, site.Status)}
+	}
+	provision, err := c.repos.Provisions(tx).GetProvisionByID(ctx, siteID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("err getting provision, %v", err)
+	}
+
+	return site, provision, nil
+}
@@ -0,0 +1,29 @@
+package site
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/jackc/pgx/v5"
+)
+
+// getOwnedSite returns a site, only when the user is its creator
+func getOwnedSite(ctx context.Context, siteRepo interfaces.SiteRepo, siteID uint64, identity *auth.Identity) (*db.Site, error) {
+	site, err := siteRepo.GetSite(ctx, siteID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.NotFoundError{Err: fmt.Errorf("site %v doesn't exist", siteID)}
+		}
+		return nil, fmt.Errorf("err getting site, %v", err)
+	}
+	if site.CreatorID != identity.UserID {
+		return nil, errs.PermissionsError{Err: fmt.Errorf("user %v is not a creator of site %v", identity.UserID, siteID)}
+	}
+
+	return site, nil
+}
@@ -0,0 +1,70 @@
+package site
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+)
+
+// maxActivePreviews - every preview has its own distribution, so their number is limited, queued previews are counted too
+const maxActivePreviews = 3
+
+type PreviewSite struct {
+	uowFactory interfaces.UoWFactory
+	repos      interfaces.Repositories
+}
+
+func NewPreviewSite(factory interfaces.UoWFactory, repos interfaces.Repositories) *PreviewSite {
+	return &PreviewSite{uowFactory: factory, repos: repos}
+}
+
+// Execute queues a build of site's draft, RebuildSite serves it on a temporary subdomain of the base domain.
+// Preview becomes available, when FinalizePreview is processed, and is removed by ExpirePreview after PreviewTTL
+func (c *PreviewSite) Execute(ctx context.Context, siteID uint64, identity *auth.Identity) (*dto.SiteBuild, error) {
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer uow.Finalize(&err)
+
+	site, err := getOwnedSite(ctx, c.repos.Sites(tx), siteID, identity)
+	if err != nil {
+		return nil, err
+	}
+	if site.Status == consts.SiteStatusDeleted || site.Status == consts.SiteStatusAwaitingDeletion {
+		err = errs.InvalidStateError{Err: fmt.Errorf("site %v is %v, it can't be previewed", siteID, site.Status)}
+		return nil, err
+	}
+	previews, err := c.repos.SitePreviews(tx).ListPreviews(ctx, siteID)
+	if err != nil {
+		return nil, err
+	}
+	// preview without a distribution belongs to a queued build or to a failed one, which has nothing to limit
+	active := 0
+	for _, preview := range previews {
+		if preview.CloudfrontID != "" {
+			active++
+		}
+	}
+	queued, err := c.repos.SiteBuilds(tx).CountActiveBuilds(ctx, siteID, consts.BuildKindPreview)
+	if err != nil {
+		return nil, err
+	}
+	if active+queued >= maxActivePreviews {
+		err = errs.InvalidStateError{Err: fmt.Errorf("site %v already has %v active previews", siteID, active+queued)}
+		return nil, err
+	}
+
+	build, err := queueBuild(ctx, tx, c.repos, siteID, consts.BuildKindPreview, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return build, nil
+}
@@ -0,0 +1,67 @@
+package site
+
+import (
+	"context"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// queueBuild saves a build job with the event, that runs it, so the draft is built outside of the request
+func queueBuild(ctx context.Context, tx pgx.Tx, repos interfaces.Repositories, siteID uint64, kind consts.BuildKind,
+	identity *auth.Identity,
+) (*dto.SiteBuild, error) {
+	now := time.Now()
+	build := db.SiteBuild{
+		ID:        uuid.New(),
+		SiteID:    siteID,
+		Kind:      kind,
+		Status:    consts.BuildStatusQueued,
+		CreatedBy: identity.UserID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err := repos.SiteBuilds(tx).InsertBuild(ctx, build)
+	if err != nil {
+		return nil, err
+	}
+	err = repos.Events(tx).InsertEvent(ctx, events.RebuildSite{JobID: build.ID, SiteID: siteID, Kind: kind})
+	if err != nil {
+		return nil, err
+	}
+
+	return mapBuildToDto(build), nil
+}
+
+func mapBuildToDto(build db.SiteBuild) *dto.SiteBuild {
+	result := &dto.SiteBuild{
+		JobId:      build.ID,
+		Kind:       dto.SiteBuildKind(build.Kind),
+		Status:     dto.SiteBuildStatus(build.Status),
+		Attempts:   build.Attempts,
+		CreatedAt:  build.CreatedAt,
+		UpdatedAt:  build.UpdatedAt,
+		FinishedAt: build.FinishedAt,
+	}
+	if build.Step != "" {
+		result.Step = &build.Step
+	}
+	if build.Error != "" {
+		result.Error = &build.Error
+	}
+	if build.Version != 0 {
+		result.Version = &build.Version
+	}
+	if build.PreviewToken != "" {
+		result.PreviewToken = &build.PreviewToken
+	}
+
+	return result
+}
@@ -0,0 +1,46 @@
+package site
+
+import (
+	"context"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/lifecycle"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+)
+
+type ReactivateSite struct {
+	uowFactory interfaces.UoWFactory
+	repos      interfaces.Repositories
+	lifecycle  *lifecycle.SiteLifecycle
+}
+
+func NewReactivateSite(uowFactory interfaces.UoWFactory, repos interfaces.Repositories) *ReactivateSite {
+	return &ReactivateSite{uowFactory: uowFactory, repos: repos, lifecycle: lifecycle.NewSiteLifecycle(repos)}
+}
+
+// Execute requests reactivation of a deactivated site, it's done asynchronously by ReactivateSite processor
+func (c *ReactivateSite) Execute(ctx context.Context, siteID uint64, identity *auth.Identity) error {
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer uow.Finalize(&err)
+
+	site, err := getOwnedSite(ctx, c.repos.Sites(tx), siteID, identity)
+	if err != nil {
+		return err
+	}
+
+	err = c.lifecycle.Transition(ctx, tx, site, consts.SiteStatusAwaitingReactivation, lifecycle.TransitionParams{
+		Actor:   consts.ActorUser,
+		ActorID: &identity.UserID,
+		Reason:  "Site reactivation was requested by it's owner",
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,306 @@
+package site_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/commands/site"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreateSite_When_Called_Then_Insert_Site_In_Creation(t *testing.T) {
+	store := memory.NewStore()
+	uowFactory := memory.NewUoWFactory()
+	identity := &auth.Identity{UserID: uuid.New()}
+	store.PlansByID[2] = db.PaymentPlan{ID: 2, MaxSites: 1}
+	fields := []map[string]interface{}{{"title": "Law firm"}}
+	SUT := site.NewCreateSite(uowFactory, store)
+
+	siteID, err := SUT.Execute(context.Background(), &dto.CreateSiteRequest{TemplateID: 1, PlanID: 2, Fields: &fields}, identity)
+	require.NoError(t, err)
+
+	created := store.SitesByID[siteID]
+	require.Equal(t, identity.UserID, created.CreatorID)
+	require.Equal(t, consts.SiteStatusInCreation, created.Status)
+	require.JSONEq(t, `[{"title":"Law firm"}]`, string(created.Fields))
+	require.Equal(t, 1, uowFactory.Commits)
+}
+
+func Test_CreateSite_When_Site_Was_Created_Recently_And_Plan_Allows_More_Then_Create_Site(t *testing.T) {
+	store := memory.NewStore()
+	uowFactory := memory.NewUoWFactory()
+	identity := &auth.Identity{UserID: uuid.New()}
+	store.PlansByID[2] = db.PaymentPlan{ID: 2, MaxSites: 2}
+	store.SitesByID[7] = db.Site{ID: 7, CreatorID: identity.UserID, PlanID: 2, Status: consts.SiteStatusInCreation,
+		CreatedAt: time.Now().Add(-time.Minute)}
+	fields := []map[string]interface{}{}
+	SUT := site.NewCreateSite(uowFactory, store)
+
+	id, err := SUT.Execute(context.Background(), &dto.CreateSiteRequest{TemplateID: 1, PlanID: 2, Fields: &fields}, identity)
+
+	require.NoError(t, err)
+	require.Len(t, store.SitesByID, 2)
+	require.Equal(t, identity.UserID, store.SitesByID[id].CreatorID)
+}
+
+func Test_CreateSite_When_Plan_Limit_Is_Reached_Then_Return_Invalid_State(t *testing.T) {
+	store := memory.NewStore()
+	uowFactory := memory.NewUoWFactory()
+	identity := &auth.Identity{UserID: uuid.New()}
+	store.PlansByID[2] = db.PaymentPlan{ID: 2, MaxSites: 1}
+	store.SitesByID[1] = db.Site{ID: 1, CreatorID: identity.UserID, PlanID: 2, Status: consts.SiteStatusCreated,
+		CreatedAt: time.Now().Add(-time.Hour)}
+	fields := []map[string]interface{}{}
+	SUT := site.NewCreateSite(uowFactory, store)
+
+	_, err := SUT.Execute(context.Background(), &dto.CreateSiteRequest{TemplateID: 1, PlanID: 2, Fields: &fields}, identity)
+
+	var stateErr errs.InvalidStateError
+	require.ErrorAs(t, err, &stateErr)
+	require.Len(t, store.SitesByID, 1)
+}
+
+func Test_UpdateSite_When_User_Is_Not_Creator_Then_Return_Permissions_Error(t *testing.T) {
+	store := memory.NewStore()
+	store.SitesByID[1] = db.Site{ID: 1, CreatorID: uuid.New(), Status: consts.SiteStatusInCreation}
+	SUT := site.NewUpdateSite(memory.NewUoWFactory(), store)
+
+	_, err := SUT.Execute(context.Background(), 1, &dto.UpdateSiteRequest{}, &auth.Identity{UserID: uuid.New()})
+
+	var permissionsErr errs.PermissionsError
+	require.ErrorAs(t, err, &permissionsErr)
+	require.Equal(t, consts.SiteStatusInCreation, store.SitesByID[1].Status)
+}
+
+func Test_DeleteSite_When_User_Is_Not_Creator_Then_Return_Permissions_Error(t *testing.T) {
+	store := memory.NewStore()
+	store.SitesByID[1] = db.Site{ID: 1, CreatorID: uuid.New(), Status: consts.SiteStatusCreated}
+	SUT := site.NewDeleteSite(memory.NewUoWFactory(), store, time.Hour)
+
+	err := SUT.Execute(context.Background(), 1, &auth.Identity{UserID: uuid.New()})
+
+	var permissionsErr errs.PermissionsError
+	require.ErrorAs(t, err, &permissionsErr)
+	require.Empty(t, store.InsertedEvents)
+}
+
+func Test_UpdateSite_When_Status_Is_Awaiting_Provision_Then_Request_Provision(t *testing.T) {
+	store := memory.NewStore()
+	creatorID := uuid.New()
+	store.SitesByID[7] = db.Site{ID: 7, CreatorID: creatorID, TemplateID: 3, Status: consts.SiteStatusInCreation, SubscriptionID: "sub_1",
+		Fields: []byte(`[{"title":"Law firm"}]`)}
+	store.TemplatesByID[3] = db.Template{ID: 3, Name: "lawyer"}
+	newStatus := dto.UpdateSiteRequestNewStatus(consts.SiteStatusAwaitingProvision)
+	domain := "law-firm"
+	SUT := site.NewUpdateSite(memory.NewUoWFactory(), store)
+
+	siteID, err := SUT.Execute(context.Background(), 7, &dto.UpdateSiteRequest{NewStatus: &newStatus, Domain: &domain},
+		&auth.Identity{UserID: creatorID})
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), siteID)
+
+	require.Equal(t, consts.SiteStatusAwaitingProvision, store.SitesByID[7].Status)
+	require.Len(t, store.InsertedEvents, 1)
+	provision, ok := store.InsertedEvents[0].(events.SiteAwaitingProvision)
+	require.True(t, ok)
+	require.Equal(t, "lawyer", provision.TemplateName)
+	require.Equal(t, consts.DefaultDomain, provision.DomainType)
+	require.JSONEq(t, `[{"title":"Law firm"}]`, string(provision.Fields))
+}
+
+func Test_ReactivateSite_When_Site_Is_Deactivated_Then_Request_Reactivation(t *testing.T) {
+	store := memory.NewStore()
+	creatorID := uuid.New()
+	store.SitesByID[5] = db.Site{ID: 5, CreatorID: creatorID, SubscriptionID: "sub_1", Status: consts.SiteStatusDeactivated}
+	store.ProvisionsBySite[5] = db.Provision{SiteID: 5, Status: consts.ProvisionStatusDeactivated}
+	SUT := site.NewReactivateSite(memory.NewUoWFactory(), store)
+
+	err := SUT.Execute(context.Background(), 5, &auth.Identity{UserID: creatorID})
+	require.NoError(t, err)
+
+	require.Equal(t, consts.SiteStatusAwaitingReactivation, store.SitesByID[5].Status)
+	require.Len(t, store.InsertedEvents, 1)
+	reactivation, ok := store.InsertedEvents[0].(events.ReactivateSite)
+	require.True(t, ok)
+	require.Equal(t, uint64(5), reactivation.SiteID)
+}
+
+func Test_DeleteSite_When_Site_Is_Created_Then_Deactivate_Before_Deletion(t *testing.T) {
+	store := memory.NewStore()
+	creatorID := uuid.New()
+	store.SitesByID[2] = db.Site{ID: 2, CreatorID: creatorID, Status: consts.SiteStatusCreated}
+	SUT := site.NewDeleteSite(memory.NewUoWFactory(), store, time.Hour)
+
+	err := SUT.Execute(context.Background(), 2, &auth.Identity{UserID: creatorID})
+	require.NoError(t, err)
+
+	require.Equal(t, consts.SiteStatusAwaitingDeactivation, store.SitesByID[2].Status)
+	require.Len(t, store.InsertedEvents, 1)
+	deactivation, ok := store.InsertedEvents[0].(events.DeactivateSite)
+	require.True(t, ok)
+	require.True(t, deactivation.Delete)
+}
+
+func Test_DeleteSite_When_Draft_Has_Subscription_Then_Delete_It_And_Cancel_Subscription(t *testing.T) {
+	store := memory.NewStore()
+	creatorID := uuid.New()
+	store.SitesByID[2] = db.Site{ID: 2, CreatorID: creatorID, Status: consts.SiteStatusInCreation, SubscriptionID: "sub_1"}
+	SUT := site.NewDeleteSite(memory.NewUoWFactory(), store, time.Hour)
+
+	err := SUT.Execute(context.Background(), 2, &auth.Identity{UserID: creatorID})
+	require.NoError(t, err)
+
+	require.Equal(t, consts.SiteStatusDeleted, store.SitesByID[2].Status)
+	require.Len(t, store.InsertedEvents, 1)
+	cancel, ok := store.InsertedEvents[0].(events.CancelSubscription)
+	require.True(t, ok)
+	require.Equal(t, uint64(2), cancel.SiteID)
+	require.Equal(t, "sub_1", cancel.SubscriptionID)
+}
+
+func Test_DeleteSite_When_Draft_Has_No_Subscription_Then_Delete_It_At_Once(t *testing.T) {
+	store := memory.NewStore()
+	creatorID := uuid.New()
+	store.SitesByID[2] = db.Site{ID: 2, CreatorID: creatorID, Status: consts.SiteStatusInCreation}
+	SUT := site.NewDeleteSite(memory.NewUoWFactory(), store, time.Hour)
+
+	err := SUT.Execute(context.Background(), 2, &auth.Identity{UserID: creatorID})
+	require.NoError(t, err)
+
+	require.Equal(t, consts.SiteStatusDeleted, store.SitesByID[2].Status)
+	require.Empty(t, store.InsertedEvents)
+}
+
+func Test_DeleteSite_When_Site_Is_Deactivated_Then_Schedule_Deletion_After_Grace_Period(t *testing.T) {
+	store := memory.NewStore()
+	creatorID := uuid.New()
+	store.SitesByID[2] = db.Site{ID: 2, CreatorID: creatorID, Status: consts.SiteStatusDeactivated}
+	SUT := site.NewDeleteSite(memory.NewUoWFactory(), store, time.Hour)
+
+	err := SUT.Execute(context.Background(), 2, &auth.Identity{UserID: creatorID})
+	require.NoError(t, err)
+
+	require.Equal(t, consts.SiteStatusAwaitingDeletion, store.SitesByID[2].Status)
+	require.Len(t, store.InsertedEvents, 1)
+	deletion, ok := store.InsertedEvents[0].(events.DeleteSite)
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now().Add(time.Hour), deletion.DeleteAt, time.Minute)
+}
+
+func Test_UpdateSite_When_Fields_Are_Changed_Then_Save_Draft_Without_Publishing(t *testing.T) {
+	store := memory.NewStore()
+	creatorID := uuid.New()
+	store.SitesByID[3] = db.Site{ID: 3, CreatorID: creatorID, Status: consts.SiteStatusCreated, PublishedVersion: 2,
+		Fields: []byte(`[{"title":"Law firm"}]`)}
+	fields := []map[string]interface{}{{"title": "New law firm"}}
+	SUT := site.NewUpdateSite(memory.NewUoWFactory(), store)
+
+	_, err := SUT.Execute(context.Background(), 3, &dto.UpdateSiteRequest{Fields: &fields}, &auth.Identity{UserID: creatorID})
+	require.NoError(t, err)
+
+	require.JSONEq(t, `[{"title":"New law firm"}]`, string(store.SitesByID[3].Fields))
+	require.Equal(t, 2, store.SitesByID[3].PublishedVersion)
+	require.Empty(t, store.PublishedVersions)
+}
+
+func Test_Publish_When_Site_Is_Created_Then_Queue_Build_Without_Building(t *testing.T) {
+	store := memory.NewStore()
+	creatorID := uuid.New()
+	store.SitesByID[5] = db.Site{ID: 5, CreatorID: creatorID, Status: consts.SiteStatusCreated, PublishedVersion: 1}
+	store.ProvisionsBySite[5] = db.Provision{SiteID: 5, Status: consts.ProvisionStatusProvisioned, CloudfrontID: "cf_5"}
+	SUT := site.NewManageVersions(memory.NewUoWFactory(), store, nil)
+
+	build, err := SUT.Publish(context.Background(), 5, &auth.Identity{UserID: creatorID})
+
+	require.NoError(t, err)
+	require.Equal(t, dto.SiteBuildStatus(consts.BuildStatusQueued), build.Status)
+	require.Equal(t, consts.BuildStatusQueued, store.BuildsByID[build.JobId].Status)
+	require.Len(t, store.InsertedEvents, 1)
+	require.Equal(t, events.RebuildSite{JobID: build.JobId, SiteID: 5, Kind: consts.BuildKindPublish}, store.InsertedEvents[0])
+	require.Equal(t, 1, store.SitesByID[5].PublishedVersion)
+
+	_, err = SUT.Publish(context.Background(), 5, &auth.Identity{UserID: creatorID})
+
+	var stateErr errs.InvalidStateError
+	require.ErrorAs(t, err, &stateErr)
+}
+
+func Test_CancelBuild_When_Build_Is_Queued_Then_Mark_It_Cancelled(t *testing.T) {
+	store := memory.NewStore()
+	creatorID := uuid.New()
+	jobID := uuid.New()
+	store.SitesByID[6] = db.Site{ID: 6, CreatorID: creatorID, Status: consts.SiteStatusCreated}
+	store.BuildsByID[jobID] = db.SiteBuild{ID: jobID, SiteID: 6, Kind: consts.BuildKindPreview, Status: consts.BuildStatusQueued}
+	SUT := site.NewCancelBuild(memory.NewUoWFactory(), store)
+
+	err := SUT.Execute(context.Background(), 6, jobID, &auth.Identity{UserID: creatorID})
+
+	require.NoError(t, err)
+	require.Equal(t, consts.BuildStatusCancelled, store.BuildsByID[jobID].Status)
+	require.True(t, store.BuildsByID[jobID].CancelRequested)
+
+	err = SUT.Execute(context.Background(), 6, jobID, &auth.Identity{UserID: creatorID})
+
+	var stateErr errs.InvalidStateError
+	require.ErrorAs(t, err, &stateErr)
+}
+
+func Test_Rollback_When_Version_Does_Not_Exist_Then_Return_Not_Found(t *testing.T) {
+	store := memory.NewStore()
+	creatorID := uuid.New()
+	store.SitesByID[3] = db.Site{ID: 3, CreatorID: creatorID, Status: consts.SiteStatusCreated, PublishedVersion: 1}
+	store.ProvisionsBySite[3] = db.Provision{SiteID: 3, Status: consts.ProvisionStatusProvisioned, CloudfrontID: "cf_1"}
+	store.PublishedVersions = []db.SiteVersion{{SiteID: 3, Version: 1, Prefix: "sites/3/versions/1"}}
+	uowFactory := memory.NewUoWFactory()
+	SUT := site.NewManageVersions(uowFactory, store, nil)
+
+	err := SUT.Rollback(context.Background(), 3, 5, &auth.Identity{UserID: creatorID})
+
+	var notFoundErr errs.NotFoundError
+	require.ErrorAs(t, err, &notFoundErr)
+	require.Equal(t, 1, store.SitesByID[3].PublishedVersion)
+	require.Equal(t, 1, uowFactory.Rollbacks)
+}
+
+func Test_PreviewSite_When_Site_Has_Too_Many_Active_Previews_Then_Return_Invalid_State(t *testing.T) {
+	store := memory.NewStore()
+	creatorID := uuid.New()
+	store.SitesByID[4] = db.Site{ID: 4, CreatorID: creatorID, Status: consts.SiteStatusCreated}
+	for _, token := range []string{"a1", "b2", "c3"} {
+		store.PreviewsByToken[token] = db.SitePreview{Token: token, SiteID: 4, Status: consts.PreviewStatusReady,
+			CloudfrontID: "E" + token}
+	}
+	store.PreviewsByToken["d4"] = db.SitePreview{Token: "d4", SiteID: 4, Status: consts.PreviewStatusExpired}
+	SUT := site.NewPreviewSite(memory.NewUoWFactory(), store)
+
+	_, err := SUT.Execute(context.Background(), 4, &auth.Identity{UserID: creatorID})
+
+	var stateErr errs.InvalidStateError
+	require.ErrorAs(t, err, &stateErr)
+	require.Len(t, store.PreviewsByToken, 4)
+	require.Empty(t, store.InsertedEvents)
+}
+
+func Test_PreviewSite_When_Preview_Of_Running_Build_Has_No_Distribution_Then_Count_It_Once(t *testing.T) {
+	store := memory.NewStore()
+	creatorID := uuid.New()
+	store.SitesByID[4] = db.Site{ID: 4, CreatorID: creatorID, Status: consts.SiteStatusCreated}
+	store.PreviewsByToken["a1"] = db.SitePreview{Token: "a1", SiteID: 4, Status: consts.PreviewStatusReady, CloudfrontID: "Ea1"}
+	store.PreviewsByToken["b2"] = db.SitePreview{Token: "b2", SiteID: 4, Status: consts.PreviewStatusPending}
+	store.BuildsByID[uuid.New()] = db.SiteBuild{SiteID: 4, Kind: consts.BuildKindPreview, Status: consts.BuildStatusRunning}
+	SUT := site.NewPreviewSite(memory.NewUoWFactory(), store)
+
+	_, err := SUT.Execute(context.Background(), 4, &auth.Identity{UserID: creatorID})
+	require.NoError(t, err)
+
+	require.Len(t, store.InsertedEvents, 1)
+}
@@ -2,30 +2,37 @@ package template
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
-	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
 )
 
 type CreateTemplate struct {
-	uowFactory *dbs.UOWFactory
+	uowFactory interfaces.UoWFactory
+	repos      interfaces.Repositories
 }
 
-func NewCreateTemplate(factory *dbs.UOWFactory) *CreateTemplate {
-	return &CreateTemplate{uowFactory: factory}
+func NewCreateTemplate(factory interfaces.UoWFactory, repos interfaces.Repositories) *CreateTemplate {
+	return &CreateTemplate{uowFactory: factory, repos: repos}
 }
 
 func (c *CreateTemplate) Execute(ctx context.Context, req *dto.CreateTemplateRequest) (uint8, error) {
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return 0, err
 	}
 	defer uow.Finalize(&err)
 
-	var templateID uint8
-	err = tx.QueryRow(ctx, "INSERT INTO builder.templates(name, fields) VALUES($1, $2) RETURNING id", req.Name, req.Fields).Scan(&templateID)
+	fields, err := json.Marshal(req.Fields)
+	if err != nil {
+		return 0, fmt.Errorf("err marshalling template fields, %v", err)
+	}
+
+	templateID, err := c.repos.Templates(tx).InsertTemplate(ctx, db.Template{Name: req.Name, Fields: fields})
 	if err != nil {
 		return 0, fmt.Errorf("err inserting template")
 	}
@@ -2,34 +2,33 @@ package template
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"path/filepath"
 
 	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/build"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
-	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/jackc/pgx/v5"
 )
 
 type RebuildTemplate struct {
-	uowFactory     *dbs.UOWFactory
-	storage        *storage.Storage
-	templateBuild  *build.TemplateBuild
-	dnsProvisioner *dns.DNSProvisioner
-	cfg            config.ProvisionConfig
+	uowFactory    interfaces.UoWFactory
+	repos         interfaces.Repositories
+	storage       *storage.Storage
+	templateBuild *build.TemplateBuild
+	cdn           dns.CDNProvider
+	cfg           config.ProvisionConfig
 }
 
-func NewRebuildTemplate(uowFactory *dbs.UOWFactory, storage *storage.Storage,
-	templateBuild *build.TemplateBuild, dnsProvisioner *dns.DNSProvisioner, cfg config.ProvisionConfig,
+func NewRebuildTemplate(uowFactory interfaces.UoWFactory, repos interfaces.Repositories, storage *storage.Storage,
+	templateBuild *build.TemplateBuild, cdn dns.CDNProvider, cfg config.ProvisionConfig,
 ) *RebuildTemplate {
-	return &RebuildTemplate{uowFactory: uowFactory, storage: storage, templateBuild: templateBuild, dnsProvisioner: dnsProvisioner, cfg: cfg}
+	return &RebuildTemplate{uowFactory: uowFactory, repos: repos, storage: storage, templateBuild: templateBuild, cdn: cdn, cfg: cfg}
 }
 
 // Refreshes all local template files, rebuilds a template and uploads built statics to s3
@@ -68,19 +67,21 @@ func (c *RebuildTemplate) Execute(ctx context.Context, req *dto.RebuildTemplates
 		}
 
 		templateBuildS3Path := fmt.Sprintf("%s%s", c.cfg.TemplateBuildBucketPath, template)
-		if err = c.templateBuild.UploadFiles(ctx, templateBuildS3Path, template, buildOutputDir); err != nil {
+		if _, err = c.templateBuild.Sync(ctx, templateBuildS3Path, buildOutputDir, nil); err != nil {
 			return fmt.Errorf("err saving build output to s3, %v", err)
 		}
 
 		domain := fmt.Sprintf("%v.%v", template, c.cfg.BaseDomain)
-		previewURL, err := c.dnsProvisioner.MapCfDistributionToS3GetURL(ctx, "/"+templateBuildS3Path, c.cfg.Defaults.S3Domain, domain, c.cfg.Defaults.CertARN)
+		distribution, err := c.cdn.CreateDistribution(ctx, "/"+templateBuildS3Path, c.cfg.Defaults.S3Domain, domain, c.cfg.Defaults.CertARN)
 		if err != nil {
 			return err
 		}
-		styles := c.storage.ListFiles(ctx, 1, &s3.ListObjectsV2Input{
-			Prefix: aws.String(templateBuildS3Path),
-		})
-		if styles == nil || len(styles) == 0 {
+		previewURL := distribution.Domain
+		styles, err := c.storage.ListKeys(ctx, templateBuildS3Path, 1)
+		if err != nil {
+			return err
+		}
+		if len(styles) == 0 {
 			return fmt.Errorf("err getting styles file from template, %v", err)
 		}
 		stylesPath := fmt.Sprintf("%s/%s", c.cfg.S3ObjectURL, styles[0])
@@ -92,14 +93,14 @@ func (c *RebuildTemplate) Execute(ctx context.Context, req *dto.RebuildTemplates
 	}
 
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer uow.Finalize(&err)
+	templateRepo := c.repos.Templates(tx)
 	for name, template := range templateStylesURLs {
-		_, err = tx.Exec(ctx, "UPDATE builder.templates SET styles = $1, preview = $2 WHERE name = $3",
-			template.Styles, template.Preview, name)
+		err = templateRepo.UpdateTemplateBuild(ctx, name, template.Styles, template.Preview)
 		if err != nil {
 			return fmt.Errorf("err inserting styles url to template, %v", err)
 		}
@@ -110,47 +111,29 @@ func (c *RebuildTemplate) Execute(ctx context.Context, req *dto.RebuildTemplates
 
 func (c *RebuildTemplate) isTemplateValid(ctx context.Context, templateName string) (bool, error) {
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return false, err
 	}
 	defer uow.Finalize(&err)
-	var templateID sql.NullInt64
-	err = tx.QueryRow(ctx, "SELECT id FROM builder.templates WHERE name = $1", templateName).Scan(&templateID)
+	_, err = c.repos.Templates(tx).GetTemplateByName(ctx, templateName)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return false, nil
 		}
 		return false, fmt.Errorf("err getting template name %v", err)
 	}
 
-	return templateID.Valid, nil
+	return true, nil
 }
 
 func (c *RebuildTemplate) getAllTemplates(ctx context.Context) ([]string, error) {
-	templatesToUpdate := make([]string, 0, 1)
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer uow.Finalize(&err)
-	rows, err := tx.Query(ctx, "SELECT name FROM builder.templates")
-	if err != nil {
-		return nil, fmt.Errorf("err getting templates %v", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var templateName string
-		if err = rows.Scan(&templateName); err != nil {
-			return nil, err
-		}
-		templatesToUpdate = append(templatesToUpdate, templateName)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
 
-	return templatesToUpdate, nil
+	return c.repos.Templates(tx).ListTemplateNames(ctx)
 }
@@ -5,27 +5,28 @@ import (
 	"fmt"
 
 	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
-	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
 )
 
 type UpdateTemplate struct {
-	uowFactory *dbs.UOWFactory
+	uowFactory interfaces.UoWFactory
+	repos      interfaces.Repositories
 }
 
-func NewUpdateTemplate(uowFactory *dbs.UOWFactory,
+func NewUpdateTemplate(uowFactory interfaces.UoWFactory, repos interfaces.Repositories,
 ) *UpdateTemplate {
-	return &UpdateTemplate{uowFactory: uowFactory}
+	return &UpdateTemplate{uowFactory: uowFactory, repos: repos}
 }
 
 // Refreshes all local template files, rebuilds a template and uploads built statics to s3
 func (c *UpdateTemplate) Execute(ctx context.Context, id int, req *dto.UpdateTemplateRequest) error {
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return err
 	}
 	defer uow.Finalize(&err)
-	_, err = tx.Exec(ctx, "UPDATE builder.templates SET file_id = COALESCE($1, file_id), name= COALESCE($2, name) WHERE id = $3 ", req.FileID, req.Name, id)
+	err = c.repos.Templates(tx).UpdateTemplate(ctx, uint8(id), req.Name, req.FileID)
 	if err != nil {
 		return fmt.Errorf("err executing a partial update, %w", err)
 	}
@@ -8,9 +8,58 @@ const (
 	SiteStatusCreated              SiteStatus = "Created"
 	SiteStatusDeactivated          SiteStatus = "Deactivated"
 	SiteStatusAwaitingDeactivation SiteStatus = "AwaitingDeactivation"
+	SiteStatusAwaitingReactivation SiteStatus = "AwaitingReactivation"
+	SiteStatusAwaitingDeletion     SiteStatus = "AwaitingDeletion"
 	SiteStatusDeleted              SiteStatus = "Deleted"
 )
 
+// PreviewStatus - preview is Pending until its distribution is deployed and is Expiring until the distribution is deleted
+type PreviewStatus string
+
+const (
+	PreviewStatusPending  PreviewStatus = "Pending"
+	PreviewStatusReady    PreviewStatus = "Ready"
+	PreviewStatusExpiring PreviewStatus = "Expiring"
+	PreviewStatusExpired  PreviewStatus = "Expired"
+)
+
+// BuildKind - what is done with a build of site's draft, when it succeeds
+type BuildKind string
+
+const (
+	BuildKindPublish BuildKind = "Publish"
+	BuildKindPreview BuildKind = "Preview"
+)
+
+// BuildStatus - failed attempt of a build is Queued again, until its retries are exhausted
+type BuildStatus string
+
+const (
+	BuildStatusQueued    BuildStatus = "Queued"
+	BuildStatusRunning   BuildStatus = "Running"
+	BuildStatusSucceeded BuildStatus = "Succeeded"
+	BuildStatusFailed    BuildStatus = "Failed"
+	BuildStatusCancelled BuildStatus = "Cancelled"
+)
+
+// SiteEventKind - what changed in a site's history entry
+type SiteEventKind string
+
+const (
+	SiteEventStatus    SiteEventKind = "Status"
+	SiteEventProvision SiteEventKind = "Provision"
+	SiteEventVersion   SiteEventKind = "Version"
+)
+
+// Actor - who made a change to a site
+type Actor string
+
+const (
+	ActorUser      Actor = "User"
+	ActorWebhook   Actor = "Webhook"
+	ActorProcessor Actor = "Processor"
+)
+
 type UserStatus string
 
 const (
@@ -25,8 +74,31 @@ const (
 	Processing
 	Processed
 	InError
+	DeadLettered
+	// Unhandled - no handler is registered for event's type
+	Unhandled
+	// Cancelled - pending event was cancelled by an admin
+	Cancelled
 )
 
+var outboxStatusNames = [...]string{"NotProcessed", "Processing", "Processed", "InError", "DeadLettered", "Unhandled", "Cancelled"}
+
+func (s OutboxStatus) String() string {
+	if s < 0 || int(s) >= len(outboxStatusNames) {
+		return "Unknown"
+	}
+	return outboxStatusNames[s]
+}
+
+func ParseOutboxStatus(name string) (OutboxStatus, bool) {
+	for i, statusName := range outboxStatusNames {
+		if statusName == name {
+			return OutboxStatus(i), true
+		}
+	}
+	return 0, false
+}
+
 type ProvisionType string
 
 const (
@@ -42,4 +114,5 @@ const (
 	ProvisionStatusProvisioned ProvisionStatus = "PROVISIONED"
 	ProvisionStatusInError     ProvisionStatus = "IN_ERROR"
 	ProvisionStatusDeactivated ProvisionStatus = "DEACTIVATED"
+	ProvisionStatusDeleted     ProvisionStatus = "DELETED"
 )
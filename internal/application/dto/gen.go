@@ -4,6 +4,8 @@
 package dto
 
 import (
+	"time"
+
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
@@ -14,6 +16,66 @@ const (
 	Unhealthy      GetSiteResponseHealthCheckStatus = "Unhealthy"
 )
 
+// Defines values for OutboxEventStatus.
+const (
+	OutboxEventStatusCancelled    OutboxEventStatus = "Cancelled"
+	OutboxEventStatusDeadLettered OutboxEventStatus = "DeadLettered"
+	OutboxEventStatusInError      OutboxEventStatus = "InError"
+	OutboxEventStatusNotProcessed OutboxEventStatus = "NotProcessed"
+	OutboxEventStatusProcessed    OutboxEventStatus = "Processed"
+	OutboxEventStatusProcessing   OutboxEventStatus = "Processing"
+	OutboxEventStatusUnhandled    OutboxEventStatus = "Unhandled"
+)
+
+// Defines values for SiteBuildKind.
+const (
+	Preview SiteBuildKind = "Preview"
+	Publish SiteBuildKind = "Publish"
+)
+
+// Defines values for SiteBuildStatus.
+const (
+	SiteBuildStatusCancelled SiteBuildStatus = "Cancelled"
+	SiteBuildStatusFailed    SiteBuildStatus = "Failed"
+	SiteBuildStatusQueued    SiteBuildStatus = "Queued"
+	SiteBuildStatusRunning   SiteBuildStatus = "Running"
+	SiteBuildStatusSucceeded SiteBuildStatus = "Succeeded"
+)
+
+// Defines values for SiteHistoryEntryActor.
+const (
+	Processor SiteHistoryEntryActor = "Processor"
+	User      SiteHistoryEntryActor = "User"
+	Webhook   SiteHistoryEntryActor = "Webhook"
+)
+
+// Defines values for SiteHistoryEntryKind.
+const (
+	Provision SiteHistoryEntryKind = "Provision"
+	Status    SiteHistoryEntryKind = "Status"
+	Version   SiteHistoryEntryKind = "Version"
+)
+
+// Defines values for SitePreviewStatus.
+const (
+	Expired  SitePreviewStatus = "Expired"
+	Expiring SitePreviewStatus = "Expiring"
+	Pending  SitePreviewStatus = "Pending"
+	Ready    SitePreviewStatus = "Ready"
+)
+
+// Defines values for SiteStatus.
+const (
+	SiteStatusAwaitingDeactivation SiteStatus = "AwaitingDeactivation"
+	SiteStatusAwaitingDeletion     SiteStatus = "AwaitingDeletion"
+	SiteStatusAwaitingProvision    SiteStatus = "AwaitingProvision"
+	SiteStatusAwaitingReactivation SiteStatus = "AwaitingReactivation"
+	SiteStatusCreated              SiteStatus = "Created"
+	SiteStatusDeactivated          SiteStatus = "Deactivated"
+	SiteStatusDeleted              SiteStatus = "Deleted"
+	SiteStatusInCreation           SiteStatus = "InCreation"
+)
+
 // Defines values for UpdateSiteRequestDomainType.
 const (
 	BringYourDomain UpdateSiteRequestDomainType = "BringYourDomain"
@@ -23,9 +85,9 @@ const (
 
 // Defines values for UpdateSiteRequestNewStatus.
 const (
-	AwaitingProvision UpdateSiteRequestNewStatus = "AwaitingProvision"
-	Created           UpdateSiteRequestNewStatus = "Created"
-	InCreation        UpdateSiteRequestNewStatus = "InCreation"
+	UpdateSiteRequestNewStatusAwaitingProvision UpdateSiteRequestNewStatus = "AwaitingProvision"
+	UpdateSiteRequestNewStatusCreated           UpdateSiteRequestNewStatus = "Created"
+	UpdateSiteRequestNewStatusInCreation        UpdateSiteRequestNewStatus = "InCreation"
 )
 
 // Defines values for VerifyOauthTokenProvider.
@@ -94,6 +156,20 @@ type DomainAvailability struct {
 	Available bool `json:"available"`
 }
 
+// EnqueueOutboxEventRequest defines model for EnqueueOutboxEventRequest.
+type EnqueueOutboxEventRequest struct {
+	Event   string                 `json:"event"`
+	Payload map[string]interface{} `json:"payload"`
+
+	// Reason why the event is enqueued, stored in audit
+	Reason *string `json:"reason,omitempty"`
+}
+
+// EnqueueOutboxEventResponse defines model for EnqueueOutboxEventResponse.
+type EnqueueOutboxEventResponse struct {
+	Id uint64 `json:"id"`
+}
+
 // EnrichContentRequest defines model for EnrichContentRequest.
 type EnrichContentRequest struct {
 	Content string `json:"content"`
@@ -117,9 +193,15 @@ type FileUploadedResponse struct {
 
 // GetSiteResponse defines model for GetSiteResponse.
 type GetSiteResponse struct {
-	CreatedAt         string                           `json:"createdAt"`
+	CreatedAt string `json:"createdAt"`
+
+	// Draft fields saved by the user, that may be not published yet
+	Draft             *[]map[string]interface{}        `json:"draft,omitempty"`
 	HealthCheckStatus GetSiteResponseHealthCheckStatus `json:"healthCheckStatus"`
 
+	// PublishedVersion version served by the site, absent when nothing was published yet
+	PublishedVersion *int `json:"publishedVersion,omitempty"`
+
 	// Structure url to pages.json file
 	Structure string `json:"structure"`
 }
@@ -141,6 +223,41 @@ type ListTemplatePaginator struct {
 	Size *int `json:"size,omitempty"`
 }
 
+// OutboxActionRequest defines model for OutboxActionRequest.
+type OutboxActionRequest struct {
+	// Reason why the action is taken, stored in audit
+	Reason *string `json:"reason,omitempty"`
+}
+
+// OutboxEvent defines model for OutboxEvent.
+type OutboxEvent struct {
+	Attempts int `json:"attempts"`
+
+	// CorrelationID ID of a request, that started the chain of events
+	CorrelationID *string   `json:"correlationID,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+	Event         string    `json:"event"`
+	Id            uint64    `json:"id"`
+	LastError     *string   `json:"lastError,omitempty"`
+
+	// LockedBy worker processing the event
+	LockedBy      *string                `json:"lockedBy,omitempty"`
+	NextAttemptAt time.Time              `json:"nextAttemptAt"`
+	Payload       map[string]interface{} `json:"payload"`
+	Status        OutboxEventStatus      `json:"status"`
+}
+
+// OutboxEventList defines model for OutboxEventList.
+type OutboxEventList struct {
+	Elements []OutboxEvent `json:"elements"`
+	HasNext  bool          `json:"hasNext"`
+	Page     int           `json:"page"`
+	Total    int           `json:"total"`
+}
+
+// OutboxEventStatus defines model for OutboxEventStatus.
+type OutboxEventStatus string
+
 // PaymentPlan defines model for PaymentPlan.
 type PaymentPlan struct {
 	Description string   `json:"description"`
@@ -176,6 +293,120 @@ type SessionInfo struct {
 	UserSite *UserSite          `json:"userSite,omitempty"`
 }
 
+// SiteBuild defines model for SiteBuild.
+type SiteBuild struct {
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Error error of the last attempt, its output is returned by the log endpoint
+	Error      *string            `json:"error,omitempty"`
+	FinishedAt *time.Time         `json:"finishedAt,omitempty"`
+	JobId      openapi_types.UUID `json:"jobId"`
+	Kind       SiteBuildKind      `json:"kind"`
+
+	// PreviewToken token of the preview, set when a preview succeeds
+	PreviewToken *string         `json:"previewToken,omitempty"`
+	Status       SiteBuildStatus `json:"status"`
+
+	// Step step of the build, that is running or has failed
+	Step      *string   `json:"step,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Version published version, set when a publish succeeds
+	Version *int `json:"version,omitempty"`
+}
+
+// SiteBuildKind defines model for SiteBuild.Kind.
+type SiteBuildKind string
+
+// SiteBuildStatus defines model for SiteBuild.Status.
+type SiteBuildStatus string
+
+// SiteHistory defines model for SiteHistory.
+type SiteHistory struct {
+	Elements []SiteHistoryEntry `json:"elements"`
+}
+
+// SiteHistoryEntry defines model for SiteHistoryEntry.
+type SiteHistoryEntry struct {
+	Actor SiteHistoryEntryActor `json:"actor"`
+
+	// ActorID set when the change was made by a user
+	ActorID *openapi_types.UUID `json:"actorID,omitempty"`
+
+	// CorrelationID ID of a request, that started the change
+	CorrelationID *string   `json:"correlationID,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+
+	// FromStatus status or published version, empty for the first provision or version entry
+	FromStatus *string              `json:"fromStatus,omitempty"`
+	Id         uint64               `json:"id"`
+	Kind       SiteHistoryEntryKind `json:"kind"`
+	Reason     *string              `json:"reason,omitempty"`
+	ToStatus   string               `json:"toStatus"`
+}
+
+// SiteHistoryEntryActor defines model for SiteHistoryEntry.Actor.
+type SiteHistoryEntryActor string
+
+// SiteHistoryEntryKind defines model for SiteHistoryEntry.Kind.
+type SiteHistoryEntryKind string
+
+// SiteList defines model for SiteList.
+type SiteList struct {
+	Elements []SiteSummary `json:"elements"`
+	HasNext  bool          `json:"hasNext"`
+	Page     int           `json:"page"`
+	Total    int           `json:"total"`
+}
+
+// SitePreview defines model for SitePreview.
+type SitePreview struct {
+	CreatedAt time.Time         `json:"createdAt"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+	Status    SitePreviewStatus `json:"status"`
+	Token     string            `json:"token"`
+	Url       string            `json:"url"`
+}
+
+// SitePreviewStatus defines model for SitePreview.Status.
+type SitePreviewStatus string
+
+// SitePreviewList defines model for SitePreviewList.
+type SitePreviewList struct {
+	Elements []SitePreview `json:"elements"`
+}
+
+// SiteStatus defines model for SiteStatus.
+type SiteStatus string
+
+// SiteSummary defines model for SiteSummary.
+type SiteSummary struct {
+	CreatedAt  time.Time  `json:"createdAt"`
+	Id         uint64     `json:"id"`
+	PlanID     uint8      `json:"planID"`
+	Status     SiteStatus `json:"status"`
+	TemplateID uint8      `json:"templateID"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// SiteVersion defines model for SiteVersion.
+type SiteVersion struct {
+	CreatedAt time.Time `json:"createdAt"`
+
+	// CreatedBy absent for the version, published by site's provision
+	CreatedBy *openapi_types.UUID `json:"createdBy,omitempty"`
+
+	// Published site currently serves this version
+	Published bool `json:"published"`
+	Version   int  `json:"version"`
+}
+
+// SiteVersionList defines model for SiteVersionList.
+type SiteVersionList struct {
+	Elements []SiteVersion `json:"elements"`
+}
+
 // StripeWebhookRequest defines model for StripeWebhookRequest.
 type StripeWebhookRequest map[string]interface{}
 
@@ -198,7 +429,9 @@ type TemplateInfo struct {
 type UpdateSiteRequest struct {
 	Domain     *string                      `json:"domain,omitempty"`
 	DomainType *UpdateSiteRequestDomainType `json:"domainType,omitempty"`
-	Fields     *[]map[string]interface{}    `json:"fields,omitempty"`
+
+	// Fields saved as site's draft, it's built only when published
+	Fields *[]map[string]interface{} `json:"fields,omitempty"`
 
 	// FileID photo of a template
 	FileID     *openapi_types.UUID         `json:"fileID,omitempty"`
@@ -249,6 +482,12 @@ type VerifyOauthTokenProvider string
 // BadRequestError defines model for BadRequestError.
 type BadRequestError = ErrorResponse
 
+// ConflictError defines model for ConflictError.
+type ConflictError = ErrorResponse
+
+// ForbiddenError defines model for ForbiddenError.
+type ForbiddenError = ErrorResponse
+
 // InternalServerError defines model for InternalServerError.
 type InternalServerError = ErrorResponse
 
@@ -258,6 +497,16 @@ type NotFoundError = ErrorResponse
 // UnauthorizedError defines model for UnauthorizedError.
 type UnauthorizedError = ErrorResponse
 
+// ListOutboxEventsParams defines parameters for ListOutboxEvents.
+type ListOutboxEventsParams struct {
+	Event         *string            `form:"event,omitempty" json:"event,omitempty"`
+	Status        *OutboxEventStatus `form:"status,omitempty" json:"status,omitempty"`
+	SiteID        *uint64            `form:"siteID,omitempty" json:"siteID,omitempty"`
+	CorrelationID *string            `form:"correlationID,omitempty" json:"correlationID,omitempty"`
+	Page          *int               `form:"page,omitempty" json:"page,omitempty"`
+	Size          *int               `form:"size,omitempty" json:"size,omitempty"`
+}
+
 // FileUploadMultipartBody defines parameters for FileUpload.
 type FileUploadMultipartBody struct {
 	File *openapi_types.File `json:"file,omitempty"`
@@ -266,6 +515,22 @@ type FileUploadMultipartBody struct {
 	Metadata *map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// ListSitesParams defines parameters for ListSites.
+type ListSitesParams struct {
+	Status *SiteStatus `form:"status,omitempty" json:"status,omitempty"`
+	Page   *int        `form:"page,omitempty" json:"page,omitempty"`
+	Size   *int        `form:"size,omitempty" json:"size,omitempty"`
+}
+
+// EnqueueOutboxEventJSONRequestBody defines body for EnqueueOutboxEvent for application/json ContentType.
+type EnqueueOutboxEventJSONRequestBody = EnqueueOutboxEventRequest
+
+// CancelOutboxEventJSONRequestBody defines body for CancelOutboxEvent for application/json ContentType.
+type CancelOutboxEventJSONRequestBody = OutboxActionRequest
+
+// ReplayOutboxEventJSONRequestBody defines body for ReplayOutboxEvent for application/json ContentType.
+type ReplayOutboxEventJSONRequestBody = OutboxActionRequest
+
 // EnrichContentJSONRequestBody defines body for EnrichContent for application/json ContentType.
 type EnrichContentJSONRequestBody = EnrichContentRequest
 
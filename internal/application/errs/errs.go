@@ -1,6 +1,9 @@
 package errs
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type PermissionsError struct {
 	Err error
@@ -17,3 +20,48 @@ type RetryableError struct {
 func (t RetryableError) Error() string {
 	return fmt.Sprintf("retryable error: %v", t.Err)
 }
+
+// DeferredError - event can't be handled yet because of missing capacity, f.e. a full build queue.
+// It's retried after Delay, the attempt isn't counted against event's retry policy
+type DeferredError struct {
+	Err   error
+	Delay time.Duration
+}
+
+func (t DeferredError) Error() string {
+	return fmt.Sprintf("deferred: %v", t.Err)
+}
+
+// PermanentError marks failures that can't be fixed by retrying, f.e. malformed event payload
+type PermanentError struct {
+	Err error
+}
+
+func (t PermanentError) Error() string {
+	return fmt.Sprintf("permanent error: %v", t.Err)
+}
+
+type NotFoundError struct {
+	Err error
+}
+
+func (t NotFoundError) Error() string {
+	return fmt.Sprintf("not found: %v", t.Err)
+}
+
+// InvalidStateError - resource's current state doesn't allow the operation
+type InvalidStateError struct {
+	Err error
+}
+
+func (t InvalidStateError) Error() string {
+	return fmt.Sprintf("invalid state: %v", t.Err)
+}
+
+type ValidationError struct {
+	Err error
+}
+
+func (t ValidationError) Error() string {
+	return fmt.Sprintf("validation error: %v", t.Err)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/google/uuid"
 )
 
 type SiteAwaitingProvision struct {
@@ -57,8 +58,82 @@ func (e SendMail) GetType() string {
 type DeactivateSite struct {
 	SiteID uint64
 	Reason string
+	// Delete - site's resources are removed after the grace period, once it's deactivated
+	Delete bool
 }
 
 func (e DeactivateSite) GetType() string {
 	return "DeactivateSite"
 }
+
+type ReactivateSite struct {
+	SiteID uint64
+	Reason string
+}
+
+func (e ReactivateSite) GetType() string {
+	return "ReactivateSite"
+}
+
+// DeleteSite removes all resources of a deactivated site, it isn't processed before DeleteAt
+type DeleteSite struct {
+	SiteID   uint64
+	Reason   string
+	DeleteAt time.Time
+}
+
+func (e DeleteSite) GetType() string {
+	return "DeleteSite"
+}
+
+func (e DeleteSite) GetNotBefore() time.Time {
+	return e.DeleteAt
+}
+
+// CancelSubscription cancels subscription of a site, that is deleted without any provisioned resources
+type CancelSubscription struct {
+	SiteID         uint64
+	SubscriptionID string
+	Reason         string
+}
+
+func (e CancelSubscription) GetType() string {
+	return "CancelSubscription"
+}
+
+// FinalizePreview makes a preview available on its domain, after its distribution is deployed
+type FinalizePreview struct {
+	Token          string
+	DistributionID string
+	Domain         string
+}
+
+func (e FinalizePreview) GetType() string {
+	return "FinalizePreview"
+}
+
+// ExpirePreview removes all resources of a preview, it isn't processed before ExpireAt
+type ExpirePreview struct {
+	Token    string
+	ExpireAt time.Time
+}
+
+func (e ExpirePreview) GetType() string {
+	return "ExpirePreview"
+}
+
+func (e ExpirePreview) GetNotBefore() time.Time {
+	return e.ExpireAt
+}
+
+// RebuildSite builds site's draft for a job, Attempt is set from outbox record, so the last failure can be recorded
+type RebuildSite struct {
+	JobID   uuid.UUID
+	SiteID  uint64
+	Kind    consts.BuildKind
+	Attempt int `json:"-"`
+}
+
+func (e RebuildSite) GetType() string {
+	return "RebuildSite"
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+	"github.com/jackc/pgx/v5"
+)
+
+// Envelope is a stored outbox record, passed to event decoders
+type Envelope struct {
+	ID        uint64
+	Event     string
+	Payload   json.RawMessage
+	Attempts  int
+	CreatedAt time.Time
+}
+
+// Decoder maps a stored payload to a typed event
+type Decoder[E shared.Event] func(envelope Envelope) (E, error)
+
+// Handler processes a typed event. Returned UoW is committed by the caller together with event's status
+type Handler[E shared.Event] func(ctx context.Context, event E) (shared.UoW, error)
+
+// Compensation undoes effects of an event, that won't be processed anymore. It runs in tx, that dead-letters the event
+type Compensation[E shared.Event] func(ctx context.Context, tx pgx.Tx, event E, cause error) error
+
+type Registration struct {
+	Event      string
+	Retry      RetryPolicy
+	decode     func(envelope Envelope) (any, error)
+	dispatch   func(ctx context.Context, envelope Envelope) (shared.UoW, error)
+	compensate func(ctx context.Context, tx pgx.Tx, envelope Envelope, cause error) error
+}
+
+// Validate checks that payload can be decoded into the registered event
+func (r Registration) Validate(payload json.RawMessage) error {
+	_, err := r.decode(Envelope{Event: r.Event, Payload: payload})
+	return err
+}
+
+// Dispatch decodes envelope's payload and passes it to the registered handler
+func (r Registration) Dispatch(ctx context.Context, envelope Envelope) (shared.UoW, error) {
+	return r.dispatch(ctx, envelope)
+}
+
+// Compensate runs compensation of a dead-lettered event, does nothing when none is registered
+func (r Registration) Compensate(ctx context.Context, tx pgx.Tx, envelope Envelope, cause error) error {
+	if r.compensate == nil {
+		return nil
+	}
+	return r.compensate(ctx, tx, envelope, cause)
+}
+
+type Registry struct {
+	registrations map[string]Registration
+}
+
+func NewRegistry() *Registry {
+	return &Registry{registrations: make(map[string]Registration)}
+}
+
+// Register binds a handler to the type of event E. If decoder is nil, payload is decoded as json
+func Register[E shared.Event](registry *Registry, handler Handler[E], decoder Decoder[E], retry RetryPolicy) {
+	var zero E
+	eventType := zero.GetType()
+	if _, ok := registry.registrations[eventType]; ok {
+		panic(fmt.Sprintf("handler for event %v is already registered", eventType))
+	}
+	if decoder == nil {
+		decoder = DecodeJSON[E]
+	}
+
+	registry.registrations[eventType] = Registration{
+		Event: eventType,
+		Retry: retry,
+		decode: func(envelope Envelope) (any, error) {
+			return decoder(envelope)
+		},
+		dispatch: func(ctx context.Context, envelope Envelope) (shared.UoW, error) {
+			event, err := decoder(envelope)
+			if err != nil {
+				return nil, errs.PermanentError{Err: fmt.Errorf("err decoding %v payload, %w", eventType, err)}
+			}
+			return handler(ctx, event)
+		},
+	}
+}
+
+// OnDeadLetter attaches a compensation to the registered event E, it's called when the event is dead-lettered
+func OnDeadLetter[E shared.Event](registry *Registry, compensate Compensation[E]) {
+	var zero E
+	eventType := zero.GetType()
+	registration, ok := registry.registrations[eventType]
+	if !ok {
+		panic(fmt.Sprintf("handler for event %v isn't registered", eventType))
+	}
+
+	decode := registration.decode
+	registration.compensate = func(ctx context.Context, tx pgx.Tx, envelope Envelope, cause error) error {
+		event, err := decode(envelope)
+		if err != nil {
+			return fmt.Errorf("err decoding %v payload, %w", eventType, err)
+		}
+		return compensate(ctx, tx, event.(E), cause)
+	}
+	registry.registrations[eventType] = registration
+}
+
+func (r *Registry) Lookup(event string) (Registration, bool) {
+	registration, ok := r.registrations[event]
+	return registration, ok
+}
+
+// RetryPolicy returns policy of a registered event, or a default one
+func (r *Registry) RetryPolicy(event string) RetryPolicy {
+	if registration, ok := r.registrations[event]; ok {
+		return registration.Retry
+	}
+	return DefaultRetryPolicy
+}
+
+func DecodeJSON[E shared.Event](envelope Envelope) (E, error) {
+	var event E
+	err := json.Unmarshal(envelope.Payload, &event)
+	return event, err
+}
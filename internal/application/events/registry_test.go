@@ -0,0 +1,119 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Registry_Dispatch_Decodes_Payload_And_Calls_Registered_Handler(t *testing.T) {
+	registry := events.NewRegistry()
+	var handled events.DeactivateSite
+	events.Register(registry, func(ctx context.Context, event events.DeactivateSite) (shared.UoW, error) {
+		handled = event
+		return nil, nil
+	}, nil, events.DefaultRetryPolicy)
+
+	payload, err := json.Marshal(events.DeactivateSite{SiteID: 42, Reason: "test"})
+	require.NoError(t, err)
+
+	registration, ok := registry.Lookup(events.DeactivateSite{}.GetType())
+	require.True(t, ok)
+	_, err = registration.Dispatch(context.Background(), events.Envelope{Payload: payload})
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), handled.SiteID)
+	require.Equal(t, "test", handled.Reason)
+}
+
+func Test_Registry_Dispatch_Returns_Permanent_Error_When_Payload_Is_Malformed(t *testing.T) {
+	registry := events.NewRegistry()
+	events.Register(registry, func(ctx context.Context, event events.DeactivateSite) (shared.UoW, error) {
+		t.Fatal("handler must not be called")
+		return nil, nil
+	}, nil, events.DefaultRetryPolicy)
+
+	registration, _ := registry.Lookup(events.DeactivateSite{}.GetType())
+	_, err := registration.Dispatch(context.Background(), events.Envelope{Payload: json.RawMessage(`{"SiteID":"abc"}`)})
+
+	var permanent errs.PermanentError
+	require.ErrorAs(t, err, &permanent)
+}
+
+func Test_Registry_Lookup_Of_Unknown_Event_Falls_Back_To_Default_Retry_Policy(t *testing.T) {
+	registry := events.NewRegistry()
+
+	_, ok := registry.Lookup("Unknown")
+	require.False(t, ok)
+	require.Equal(t, events.DefaultRetryPolicy, registry.RetryPolicy("Unknown"))
+}
+
+func Test_Registry_Register_Panics_On_Duplicate_Event_Type(t *testing.T) {
+	registry := events.NewRegistry()
+	handler := func(ctx context.Context, event events.SendMail) (shared.UoW, error) { return nil, nil }
+	events.Register(registry, handler, nil, events.DefaultRetryPolicy)
+
+	require.Panics(t, func() {
+		events.Register(registry, handler, nil, events.DefaultRetryPolicy)
+	})
+}
+
+func Test_Registration_Validate_Rejects_Payload_Not_Matching_Event(t *testing.T) {
+	registry := events.NewRegistry()
+	events.Register(registry, func(ctx context.Context, event events.DeactivateSite) (shared.UoW, error) {
+		return nil, nil
+	}, nil, events.DefaultRetryPolicy)
+
+	registration, _ := registry.Lookup(events.DeactivateSite{}.GetType())
+
+	require.NoError(t, registration.Validate(json.RawMessage(`{"SiteID":12,"Reason":"test"}`)))
+	require.Error(t, registration.Validate(json.RawMessage(`{"SiteID":"12"}`)))
+}
+
+func Test_Registry_Compensate_Decodes_Payload_And_Passes_Cause(t *testing.T) {
+	registry := events.NewRegistry()
+	events.Register(registry, func(ctx context.Context, event events.DeactivateSite) (shared.UoW, error) {
+		return nil, nil
+	}, nil, events.DefaultRetryPolicy)
+	var compensated events.DeactivateSite
+	var cause error
+	events.OnDeadLetter(registry, func(ctx context.Context, tx pgx.Tx, event events.DeactivateSite, err error) error {
+		compensated, cause = event, err
+		return nil
+	})
+
+	payload, err := json.Marshal(events.DeactivateSite{SiteID: 42})
+	require.NoError(t, err)
+	failure := errors.New("distribution is missing")
+	registration, _ := registry.Lookup(events.DeactivateSite{}.GetType())
+	require.NoError(t, registration.Compensate(context.Background(), nil, events.Envelope{Payload: payload}, failure))
+
+	require.Equal(t, uint64(42), compensated.SiteID)
+	require.Equal(t, failure, cause)
+}
+
+func Test_Registry_Compensate_Without_Compensation_Does_Nothing(t *testing.T) {
+	registry := events.NewRegistry()
+	events.Register(registry, func(ctx context.Context, event events.DeactivateSite) (shared.UoW, error) {
+		return nil, nil
+	}, nil, events.DefaultRetryPolicy)
+
+	registration, _ := registry.Lookup(events.DeactivateSite{}.GetType())
+	require.NoError(t, registration.Compensate(context.Background(), nil, events.Envelope{Payload: json.RawMessage(`{}`)}, nil))
+}
+
+func Test_Registry_OnDeadLetter_Of_Unregistered_Event_Panics(t *testing.T) {
+	registry := events.NewRegistry()
+
+	require.Panics(t, func() {
+		events.OnDeadLetter(registry, func(ctx context.Context, tx pgx.Tx, event events.DeactivateSite, err error) error {
+			return nil
+		})
+	})
+}
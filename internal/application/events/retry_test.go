@@ -0,0 +1,32 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RetryPolicy_NextDelay_Grows_Exponentially_Up_To_Max_Delay(t *testing.T) {
+	policy := events.RetryPolicy{
+		MaxAttempts:  5,
+		InitialDelay: time.Second,
+		MaxDelay:     10 * time.Second,
+		Multiplier:   2,
+	}
+
+	require.Equal(t, time.Second, policy.NextDelay(1))
+	require.Equal(t, 2*time.Second, policy.NextDelay(2))
+	require.Equal(t, 8*time.Second, policy.NextDelay(4))
+	require.Equal(t, 10*time.Second, policy.NextDelay(5))
+	require.Equal(t, 10*time.Second, policy.NextDelay(50))
+}
+
+func Test_RetryPolicy_Exhausted_When_Attempts_Reach_Max(t *testing.T) {
+	policy := events.RetryPolicy{MaxAttempts: 3}
+
+	require.False(t, policy.Exhausted(2))
+	require.True(t, policy.Exhausted(3))
+	require.True(t, policy.Exhausted(4))
+}
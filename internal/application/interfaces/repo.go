@@ -2,16 +2,152 @@ package interfaces
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
 	"github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
+// Get methods of repositories return pgx.ErrNoRows, when nothing is found
+
+type UoWFactory interface {
+	GetUoW() interfaces.UoW
+}
+
+// Repositories builds repositories, bound to a transaction of a UoW
+type Repositories interface {
+	Sites(tx pgx.Tx) SiteRepo
+	SiteEvents(tx pgx.Tx) SiteEventRepo
+	SiteVersions(tx pgx.Tx) SiteVersionRepo
+	SitePreviews(tx pgx.Tx) SitePreviewRepo
+	SiteBuilds(tx pgx.Tx) SiteBuildRepo
+	Users(tx pgx.Tx) UserRepo
+	Templates(tx pgx.Tx) TemplateRepo
+	Sessions(tx pgx.Tx) SessionRepo
+	Mails(tx pgx.Tx) MailRepo
+	Plans(tx pgx.Tx) PlanRepo
+	Provisions(tx pgx.Tx) ProvisionRepo
+	Events(tx pgx.Tx) EventRepo
+}
+
 type ProvisionRepo interface {
 	GetProvisionByID(ctx context.Context, siteID uint64) (*db.Provision, error)
 	InsertProvision(ctx context.Context, provision db.Provision) error
+	UpdateProvisionStructure(ctx context.Context, siteID uint64, structurePath string) error
+	DeleteProvision(ctx context.Context, siteID uint64) error
 }
 
 type EventRepo interface {
 	InsertEvent(ctx context.Context, event interfaces.Event) error
 }
+
+// SiteFilter - zero fields aren't filtered by, deleted sites are excluded unless Status is Deleted
+type SiteFilter struct {
+	CreatorID uuid.UUID
+	PlanID    uint8
+	Status    consts.SiteStatus
+}
+
+type SiteRepo interface {
+	GetSite(ctx context.Context, id uint64) (*db.Site, error)
+	GetSiteBySubscription(ctx context.Context, subscriptionID string) (*db.Site, error)
+	// GetFirstSiteOfCreator returns the oldest site of a user
+	GetFirstSiteOfCreator(ctx context.Context, creatorID uuid.UUID) (*db.Site, error)
+	// LockCreator serializes site creation of a user until the end of the transaction
+	LockCreator(ctx context.Context, creatorID uuid.UUID) error
+	// ListSites returns matching sites, newest first
+	ListSites(ctx context.Context, filter SiteFilter, limit, offset int) ([]db.Site, error)
+	CountSites(ctx context.Context, filter SiteFilter) (int, error)
+	InsertSite(ctx context.Context, site db.Site) (uint64, error)
+	UpdateSiteStatus(ctx context.Context, id uint64, status consts.SiteStatus) error
+	// UpdateSiteContent keeps current fields or file, when nil is passed for them
+	UpdateSiteContent(ctx context.Context, id uint64, fields json.RawMessage, fileID *uuid.UUID) error
+	UpdateSiteSubscription(ctx context.Context, id uint64, subscriptionID string) error
+	UpdateSitePublishedVersion(ctx context.Context, id uint64, version int) error
+}
+
+type SiteVersionRepo interface {
+	GetSiteVersion(ctx context.Context, siteID uint64, version int) (*db.SiteVersion, error)
+	// ListSiteVersions returns site's versions, newest first
+	ListSiteVersions(ctx context.Context, siteID uint64) ([]db.SiteVersion, error)
+	// NextSiteVersion returns the number, which the next published version of a site gets
+	NextSiteVersion(ctx context.Context, siteID uint64) (int, error)
+	InsertSiteVersion(ctx context.Context, version db.SiteVersion) error
+}
+
+type SitePreviewRepo interface {
+	GetPreview(ctx context.Context, token string) (*db.SitePreview, error)
+	// ListPreviews returns not expired previews of a site, newest first
+	ListPreviews(ctx context.Context, siteID uint64) ([]db.SitePreview, error)
+	InsertPreview(ctx context.Context, preview db.SitePreview) error
+	UpdatePreviewDistribution(ctx context.Context, token, cloudfrontID string) error
+	// UpdatePreviewStatus keeps current distribution's domain, when empty one is passed
+	UpdatePreviewStatus(ctx context.Context, token string, status consts.PreviewStatus, cloudfrontDomain string) error
+}
+
+type SiteBuildRepo interface {
+	GetBuild(ctx context.Context, id uuid.UUID) (*db.SiteBuild, error)
+	// CountActiveBuilds counts queued and running builds of a kind
+	CountActiveBuilds(ctx context.Context, siteID uint64, kind consts.BuildKind) (int, error)
+	InsertBuild(ctx context.Context, build db.SiteBuild) error
+	// UpdateBuild saves status, progress and result of a build
+	UpdateBuild(ctx context.Context, build db.SiteBuild) error
+	// RequestCancel marks a build, so its processor stops it
+	RequestCancel(ctx context.Context, id uuid.UUID) error
+	GetBuildLog(ctx context.Context, id uuid.UUID) (string, error)
+	SaveBuildLog(ctx context.Context, id uuid.UUID, log string) error
+}
+
+type SiteEventRepo interface {
+	InsertSiteEvent(ctx context.Context, event db.SiteEvent) error
+	// ListSiteEvents returns site's history, oldest first
+	ListSiteEvents(ctx context.Context, siteID uint64) ([]db.SiteEvent, error)
+}
+
+type UserRepo interface {
+	GetUser(ctx context.Context, id uuid.UUID) (*db.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*db.User, error)
+	GetUserByStripeID(ctx context.Context, stripeID string) (*db.User, error)
+	InsertUser(ctx context.Context, user db.User) error
+	UpdateUserStatus(ctx context.Context, id uuid.UUID, status consts.UserStatus) error
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+
+	GetUserIDByIdentity(ctx context.Context, provider, sub string) (uuid.UUID, error)
+	GetIdentities(ctx context.Context, userID uuid.UUID) ([]db.UserIdentity, error)
+	InsertIdentity(ctx context.Context, identity db.UserIdentity) error
+	DeleteIdentities(ctx context.Context, userID uuid.UUID) error
+
+	GetConfirmationCode(ctx context.Context, code uuid.UUID) (*db.ConfirmationCode, error)
+	InsertConfirmationCode(ctx context.Context, code db.ConfirmationCode) error
+	DeleteConfirmationCode(ctx context.Context, code uuid.UUID) error
+}
+
+type TemplateRepo interface {
+	GetTemplate(ctx context.Context, id uint8) (*db.Template, error)
+	GetTemplateByName(ctx context.Context, name string) (*db.Template, error)
+	ListTemplateNames(ctx context.Context) ([]string, error)
+	InsertTemplate(ctx context.Context, template db.Template) (uint8, error)
+	// UpdateTemplate keeps current name or file, when nil is passed for them
+	UpdateTemplate(ctx context.Context, id uint8, name *string, fileID *uuid.UUID) error
+	UpdateTemplateBuild(ctx context.Context, name, styles, preview string) error
+}
+
+type SessionRepo interface {
+	GetSession(ctx context.Context, id uuid.UUID) (*db.Session, error)
+	GetActiveSessionOfUser(ctx context.Context, userID uuid.UUID, now time.Time) (*db.Session, error)
+	InsertSession(ctx context.Context, session db.Session) error
+}
+
+type MailRepo interface {
+	GetMailTemplate(ctx context.Context, mailType string) (*db.MailTemplates, error)
+	InsertMail(ctx context.Context, mail db.Mail) (uint64, error)
+}
+
+type PlanRepo interface {
+	GetPlan(ctx context.Context, id uint8) (*db.PaymentPlan, error)
+	ListPlans(ctx context.Context) ([]db.PaymentPlan, error)
+}
@@ -0,0 +1,314 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/correlation"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// TransitionParams - data, needed by guards and side effects of some transitions
+type TransitionParams struct {
+	// Actor and ActorID are saved to site's history, ActorID is set only for users
+	Actor   consts.Actor
+	ActorID *uuid.UUID
+	// Domain, DomainType and Fields are used to request a provision
+	Domain     string
+	DomainType consts.ProvisionType
+	Fields     json.RawMessage
+	// Reason is saved to site's history and sent to the user, when site is deactivated
+	Reason string
+	// Delete requests removal of site's resources after deactivation, not earlier than DeleteAt
+	Delete   bool
+	DeleteAt time.Time
+}
+
+type guard func(ctx context.Context, tx pgx.Tx, site *db.Site, params TransitionParams) error
+
+type effect func(ctx context.Context, tx pgx.Tx, site *db.Site, params TransitionParams) error
+
+type transition struct {
+	guards  []guard
+	effects []effect
+}
+
+// SiteLifecycle is the only place, where site's status should be changed
+type SiteLifecycle struct {
+	repos       interfaces.Repositories
+	transitions map[consts.SiteStatus]map[consts.SiteStatus]transition
+}
+
+func NewSiteLifecycle(repos interfaces.Repositories) *SiteLifecycle {
+	l := &SiteLifecycle{repos: repos}
+	l.transitions = map[consts.SiteStatus]map[consts.SiteStatus]transition{
+		consts.SiteStatusInCreation: {
+			consts.SiteStatusAwaitingProvision: {
+				guards:  []guard{l.hasSubscription, l.hasDomain, l.notProvisioned},
+				effects: []effect{l.requestProvision},
+			},
+			// site was never provisioned, so there is nothing to deactivate, only a paid subscription is cancelled
+			consts.SiteStatusDeleted: {
+				effects: []effect{l.requestSubscriptionCancel},
+			},
+		},
+		consts.SiteStatusAwaitingProvision: {
+			consts.SiteStatusCreated: {
+				guards: []guard{l.provisionIn(consts.ProvisionStatusProvisioned)},
+			},
+			// provision failed before it was finalized, site goes back to the draft and can be requested again
+			consts.SiteStatusInCreation: {
+				guards: []guard{l.notProvisioned},
+			},
+		},
+		consts.SiteStatusCreated: {
+			consts.SiteStatusAwaitingDeactivation: {
+				effects: []effect{l.requestDeactivation},
+			},
+		},
+		consts.SiteStatusAwaitingDeactivation: {
+			consts.SiteStatusDeactivated: {
+				guards: []guard{l.provisionIn(consts.ProvisionStatusDeactivated)},
+			},
+		},
+		consts.SiteStatusDeactivated: {
+			consts.SiteStatusAwaitingReactivation: {
+				guards:  []guard{l.hasSubscription, l.provisionIn(consts.ProvisionStatusDeactivated)},
+				effects: []effect{l.requestReactivation},
+			},
+			consts.SiteStatusAwaitingDeletion: {
+				effects: []effect{l.requestDeletion},
+			},
+		},
+		// site can still be restored during the grace period, DeleteSite processor skips it then
+		consts.SiteStatusAwaitingDeletion: {
+			consts.SiteStatusAwaitingReactivation: {
+				guards:  []guard{l.hasSubscription, l.provisionIn(consts.ProvisionStatusDeactivated)},
+				effects: []effect{l.requestReactivation},
+			},
+			consts.SiteStatusDeleted: {
+				guards: []guard{l.provisionIn(consts.ProvisionStatusDeleted)},
+			},
+		},
+		consts.SiteStatusAwaitingReactivation: {
+			consts.SiteStatusCreated: {
+				guards: []guard{l.provisionIn(consts.ProvisionStatusProvisioned)},
+			},
+			// reactivation failed, site stays deactivated and can be reactivated or deleted again
+			consts.SiteStatusDeactivated: {
+				guards: []guard{l.provisionIn(consts.ProvisionStatusDeactivated)},
+			},
+		},
+	}
+	return l
+}
+
+// CanTransition only checks that transition exists, guards aren't run
+func (l *SiteLifecycle) CanTransition(from, to consts.SiteStatus) bool {
+	_, ok := l.transitions[from][to]
+	return ok
+}
+
+// Transition checks guards, updates site's status and runs side effects in the passed transaction.
+// Transition to the current status is a no-op, so retried processors don't fail
+func (l *SiteLifecycle) Transition(ctx context.Context, tx pgx.Tx, site *db.Site, to consts.SiteStatus, params TransitionParams) error {
+	if site.Status == to {
+		slog.InfoContext(ctx, "site is already in status", "siteID", site.ID, "status", to)
+		return nil
+	}
+	t, ok := l.transitions[site.Status][to]
+	if !ok {
+		return errs.InvalidStateError{Err: fmt.Errorf("site %v can't go from %v to %v", site.ID, site.Status, to)}
+	}
+
+	for _, check := range t.guards {
+		if err := check(ctx, tx, site, params); err != nil {
+			return err
+		}
+	}
+
+	err := l.repos.Sites(tx).UpdateSiteStatus(ctx, site.ID, to)
+	if err != nil {
+		return fmt.Errorf("err updating site status, %v", err)
+	}
+	from := site.Status
+	site.Status = to
+
+	err = l.record(ctx, tx, site.ID, consts.SiteEventStatus, string(from), string(to), params)
+	if err != nil {
+		return err
+	}
+
+	for _, run := range t.effects {
+		if err = run(ctx, tx, site, params); err != nil {
+			return err
+		}
+	}
+	slog.InfoContext(ctx, "site status changed", "siteID", site.ID, "from", from, "to", to)
+
+	return nil
+}
+
+// LastStatusChange returns the latest entry of site's history, that moved it to status, nil if it was never in it
+func (l *SiteLifecycle) LastStatusChange(ctx context.Context, tx pgx.Tx, siteID uint64, to consts.SiteStatus) (*db.SiteEvent, error) {
+	history, err := l.repos.SiteEvents(tx).ListSiteEvents(ctx, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("err getting site history, %v", err)
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Kind == consts.SiteEventStatus && history[i].ToStatus == string(to) {
+			return &history[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// RecordProvisionChange saves a change of site's provision to site's history
+func (l *SiteLifecycle) RecordProvisionChange(ctx context.Context, tx pgx.Tx, siteID uint64, from, to consts.ProvisionStatus,
+	params TransitionParams,
+) error {
+	return l.record(ctx, tx, siteID, consts.SiteEventProvision, string(from), string(to), params)
+}
+
+// RecordVersionChange saves a change of site's published version to site's history
+func (l *SiteLifecycle) RecordVersionChange(ctx context.Context, tx pgx.Tx, siteID uint64, from, to int, params TransitionParams) error {
+	var fromVersion string
+	if from != 0 {
+		fromVersion = strconv.Itoa(from)
+	}
+	return l.record(ctx, tx, siteID, consts.SiteEventVersion, fromVersion, strconv.Itoa(to), params)
+}
+
+func (l *SiteLifecycle) record(ctx context.Context, tx pgx.Tx, siteID uint64, kind consts.SiteEventKind, from, to string,
+	params TransitionParams,
+) error {
+	err := l.repos.SiteEvents(tx).InsertSiteEvent(ctx, db.SiteEvent{
+		SiteID:        siteID,
+		Kind:          kind,
+		FromStatus:    from,
+		ToStatus:      to,
+		Actor:         params.Actor,
+		ActorID:       params.ActorID,
+		Reason:        params.Reason,
+		CorrelationID: correlation.FromContext(ctx),
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("err saving site history, %v", err)
+	}
+	return nil
+}
+
+func (l *SiteLifecycle) hasSubscription(ctx context.Context, tx pgx.Tx, site *db.Site, params TransitionParams) error {
+	if site.SubscriptionID == "" {
+		return errs.InvalidStateError{Err: fmt.Errorf("site %v has no subscription", site.ID)}
+	}
+	return nil
+}
+
+func (l *SiteLifecycle) hasDomain(ctx context.Context, tx pgx.Tx, site *db.Site, params TransitionParams) error {
+	if params.Domain == "" {
+		return errs.ValidationError{Err: fmt.Errorf("domain is required to provision site %v", site.ID)}
+	}
+	return nil
+}
+
+// notProvisioned - provision, that failed before it was finalized, stays InProcess and is reused by the next request
+func (l *SiteLifecycle) notProvisioned(ctx context.Context, tx pgx.Tx, site *db.Site, params TransitionParams) error {
+	provision, err := l.repos.Provisions(tx).GetProvisionByID(ctx, site.ID)
+	if err == nil {
+		if provision.Status == consts.ProvisionStatusInProcess {
+			return nil
+		}
+		return errs.InvalidStateError{Err: fmt.Errorf("site %v is already provisioned", site.ID)}
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("err checking if site already provisioned, %v", err)
+	}
+	return nil
+}
+
+func (l *SiteLifecycle) provisionIn(status consts.ProvisionStatus) guard {
+	return func(ctx context.Context, tx pgx.Tx, site *db.Site, params TransitionParams) error {
+		provision, err := l.repos.Provisions(tx).GetProvisionByID(ctx, site.ID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errs.InvalidStateError{Err: fmt.Errorf("site %v has no provision", site.ID)}
+			}
+			return fmt.Errorf("err getting provision, %v", err)
+		}
+		if provision.Status != status {
+			return errs.InvalidStateError{Err: fmt.Errorf("provision of site %v is %v, not %v", site.ID, provision.Status, status)}
+		}
+		return nil
+	}
+}
+
+func (l *SiteLifecycle) requestProvision(ctx context.Context, tx pgx.Tx, site *db.Site, params TransitionParams) error {
+	template, err := l.repos.Templates(tx).GetTemplate(ctx, site.TemplateID)
+	if err != nil {
+		return fmt.Errorf("err getting site's template, %v", err)
+	}
+	domainType := params.DomainType
+	if domainType == "" {
+		domainType = consts.DefaultDomain
+	}
+	fields := params.Fields
+	if fields == nil {
+		fields = site.Fields
+	}
+
+	return l.repos.Events(tx).InsertEvent(ctx, events.SiteAwaitingProvision{
+		SiteID:       site.ID,
+		DomainType:   domainType,
+		TemplateName: template.Name,
+		Domain:       params.Domain,
+		Fields:       fields,
+		CreatedAt:    time.Now(),
+	})
+}
+
+func (l *SiteLifecycle) requestDeactivation(ctx context.Context, tx pgx.Tx, site *db.Site, params TransitionParams) error {
+	return l.repos.Events(tx).InsertEvent(ctx, events.DeactivateSite{
+		SiteID: site.ID,
+		Reason: params.Reason,
+		Delete: params.Delete,
+	})
+}
+
+func (l *SiteLifecycle) requestReactivation(ctx context.Context, tx pgx.Tx, site *db.Site, params TransitionParams) error {
+	return l.repos.Events(tx).InsertEvent(ctx, events.ReactivateSite{
+		SiteID: site.ID,
+		Reason: params.Reason,
+	})
+}
+
+func (l *SiteLifecycle) requestSubscriptionCancel(ctx context.Context, tx pgx.Tx, site *db.Site, params TransitionParams) error {
+	if site.SubscriptionID == "" {
+		return nil
+	}
+	return l.repos.Events(tx).InsertEvent(ctx, events.CancelSubscription{
+		SiteID:         site.ID,
+		SubscriptionID: site.SubscriptionID,
+		Reason:         params.Reason,
+	})
+}
+
+func (l *SiteLifecycle) requestDeletion(ctx context.Context, tx pgx.Tx, site *db.Site, params TransitionParams) error {
+	return l.repos.Events(tx).InsertEvent(ctx, events.DeleteSite{
+		SiteID:   site.ID,
+		Reason:   params.Reason,
+		DeleteAt: params.DeleteAt,
+	})
+}
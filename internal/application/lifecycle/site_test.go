@@ -0,0 +1,156 @@
+package lifecycle_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/lifecycle"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/correlation"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Transition_When_Skipping_Provision_Then_Return_Invalid_State(t *testing.T) {
+	store := memory.NewStore()
+	site := db.Site{ID: 1, Status: consts.SiteStatusInCreation}
+	store.SitesByID[1] = site
+	SUT := lifecycle.NewSiteLifecycle(store)
+
+	err := SUT.Transition(context.Background(), nil, &site, consts.SiteStatusCreated, lifecycle.TransitionParams{})
+
+	var stateErr errs.InvalidStateError
+	require.ErrorAs(t, err, &stateErr)
+	require.Equal(t, consts.SiteStatusInCreation, store.SitesByID[1].Status)
+}
+
+func Test_Transition_When_Site_Has_No_Subscription_Then_Do_Not_Request_Provision(t *testing.T) {
+	store := memory.NewStore()
+	site := db.Site{ID: 1, TemplateID: 3, Status: consts.SiteStatusInCreation}
+	store.SitesByID[1] = site
+	store.TemplatesByID[3] = db.Template{ID: 3, Name: "lawyer"}
+	SUT := lifecycle.NewSiteLifecycle(store)
+
+	err := SUT.Transition(context.Background(), nil, &site, consts.SiteStatusAwaitingProvision,
+		lifecycle.TransitionParams{Domain: "law-firm"})
+
+	var stateErr errs.InvalidStateError
+	require.ErrorAs(t, err, &stateErr)
+	require.Equal(t, consts.SiteStatusInCreation, store.SitesByID[1].Status)
+	require.Empty(t, store.InsertedEvents)
+}
+
+func Test_Transition_When_Provision_Is_Not_Finished_Then_Site_Is_Not_Created(t *testing.T) {
+	store := memory.NewStore()
+	site := db.Site{ID: 1, Status: consts.SiteStatusAwaitingProvision}
+	store.SitesByID[1] = site
+	store.ProvisionsBySite[1] = db.Provision{SiteID: 1, Status: consts.ProvisionStatusInProcess}
+	SUT := lifecycle.NewSiteLifecycle(store)
+
+	err := SUT.Transition(context.Background(), nil, &site, consts.SiteStatusCreated, lifecycle.TransitionParams{})
+
+	var stateErr errs.InvalidStateError
+	require.ErrorAs(t, err, &stateErr)
+	require.Equal(t, consts.SiteStatusAwaitingProvision, store.SitesByID[1].Status)
+}
+
+func Test_Transition_When_Created_Site_Is_Deactivated_Then_Request_Deactivation(t *testing.T) {
+	store := memory.NewStore()
+	site := db.Site{ID: 1, Status: consts.SiteStatusCreated}
+	store.SitesByID[1] = site
+	SUT := lifecycle.NewSiteLifecycle(store)
+
+	err := SUT.Transition(context.Background(), nil, &site, consts.SiteStatusAwaitingDeactivation,
+		lifecycle.TransitionParams{Reason: "missing payment"})
+	require.NoError(t, err)
+
+	require.Equal(t, consts.SiteStatusAwaitingDeactivation, site.Status)
+	require.Equal(t, consts.SiteStatusAwaitingDeactivation, store.SitesByID[1].Status)
+	require.Len(t, store.InsertedEvents, 1)
+	require.Equal(t, events.DeactivateSite{SiteID: 1, Reason: "missing payment"}, store.InsertedEvents[0])
+}
+
+func Test_Transition_When_Status_Changes_Then_Record_History(t *testing.T) {
+	store := memory.NewStore()
+	site := db.Site{ID: 1, Status: consts.SiteStatusCreated}
+	store.SitesByID[1] = site
+	userID := uuid.New()
+	ctx := correlation.WithID(context.Background(), "req-1")
+	SUT := lifecycle.NewSiteLifecycle(store)
+
+	err := SUT.Transition(ctx, nil, &site, consts.SiteStatusAwaitingDeactivation,
+		lifecycle.TransitionParams{Actor: consts.ActorUser, ActorID: &userID, Reason: "not needed"})
+	require.NoError(t, err)
+
+	require.Len(t, store.SiteHistory, 1)
+	entry := store.SiteHistory[0]
+	require.Equal(t, consts.SiteEventStatus, entry.Kind)
+	require.Equal(t, string(consts.SiteStatusCreated), entry.FromStatus)
+	require.Equal(t, string(consts.SiteStatusAwaitingDeactivation), entry.ToStatus)
+	require.Equal(t, consts.ActorUser, entry.Actor)
+	require.Equal(t, &userID, entry.ActorID)
+	require.Equal(t, "not needed", entry.Reason)
+	require.Equal(t, "req-1", entry.CorrelationID)
+}
+
+func Test_Transition_When_Provision_Failed_Before_It_Was_Saved_Then_Site_Returns_To_Draft(t *testing.T) {
+	store := memory.NewStore()
+	site := db.Site{ID: 1, Status: consts.SiteStatusAwaitingProvision}
+	store.SitesByID[1] = site
+	SUT := lifecycle.NewSiteLifecycle(store)
+
+	err := SUT.Transition(context.Background(), nil, &site, consts.SiteStatusInCreation,
+		lifecycle.TransitionParams{Actor: consts.ActorProcessor, Reason: "Provision failed"})
+	require.NoError(t, err)
+
+	require.Equal(t, consts.SiteStatusInCreation, store.SitesByID[1].Status)
+	require.Empty(t, store.InsertedEvents)
+}
+
+func Test_Transition_When_Provision_Failed_Before_It_Was_Finalized_Then_Site_Returns_To_Draft_And_Keeps_It(t *testing.T) {
+	store := memory.NewStore()
+	site := db.Site{ID: 1, Status: consts.SiteStatusAwaitingProvision}
+	store.SitesByID[1] = site
+	store.ProvisionsBySite[1] = db.Provision{SiteID: 1, Status: consts.ProvisionStatusInProcess, CloudfrontID: "E1"}
+	SUT := lifecycle.NewSiteLifecycle(store)
+
+	err := SUT.Transition(context.Background(), nil, &site, consts.SiteStatusInCreation,
+		lifecycle.TransitionParams{Actor: consts.ActorProcessor, Reason: "Provision failed"})
+	require.NoError(t, err)
+
+	require.Equal(t, consts.SiteStatusInCreation, store.SitesByID[1].Status)
+	require.Equal(t, "E1", store.ProvisionsBySite[1].CloudfrontID)
+}
+
+func Test_Transition_When_Provision_Is_Finalized_Then_Site_Doesnt_Return_To_Draft(t *testing.T) {
+	store := memory.NewStore()
+	site := db.Site{ID: 1, Status: consts.SiteStatusAwaitingProvision}
+	store.SitesByID[1] = site
+	store.ProvisionsBySite[1] = db.Provision{SiteID: 1, Status: consts.ProvisionStatusProvisioned}
+	SUT := lifecycle.NewSiteLifecycle(store)
+
+	err := SUT.Transition(context.Background(), nil, &site, consts.SiteStatusInCreation, lifecycle.TransitionParams{})
+
+	var stateErr errs.InvalidStateError
+	require.ErrorAs(t, err, &stateErr)
+	require.Equal(t, consts.SiteStatusAwaitingProvision, store.SitesByID[1].Status)
+}
+
+func Test_Transition_When_Reactivation_Failed_Then_Site_Stays_Deactivated(t *testing.T) {
+	store := memory.NewStore()
+	site := db.Site{ID: 1, Status: consts.SiteStatusAwaitingReactivation}
+	store.SitesByID[1] = site
+	store.ProvisionsBySite[1] = db.Provision{SiteID: 1, Status: consts.ProvisionStatusDeactivated}
+	SUT := lifecycle.NewSiteLifecycle(store)
+
+	err := SUT.Transition(context.Background(), nil, &site, consts.SiteStatusDeactivated,
+		lifecycle.TransitionParams{Actor: consts.ActorProcessor, Reason: "Reactivation failed"})
+	require.NoError(t, err)
+
+	require.Equal(t, consts.SiteStatusDeactivated, store.SitesByID[1].Status)
+	require.True(t, SUT.CanTransition(consts.SiteStatusDeactivated, consts.SiteStatusAwaitingDeletion))
+}
@@ -0,0 +1,30 @@
+package processors
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+)
+
+// CancelSubscription cancels subscription of a site, deleted before it was provisioned.
+// Sites with resources cancel it in DeleteSite, after the grace period
+type CancelSubscription struct{}
+
+func NewCancelSubscription() *CancelSubscription {
+	return &CancelSubscription{}
+}
+
+func (c *CancelSubscription) Register(registry *events.Registry) {
+	events.Register(registry, c.Handle, nil, events.DefaultRetryPolicy)
+}
+
+func (c *CancelSubscription) Handle(ctx context.Context, event events.CancelSubscription) (shared.UoW, error) {
+	if err := cancelSubscription(event.SubscriptionID); err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "subscription of deleted site was cancelled", "siteID", event.SiteID, "subID", event.SubscriptionID)
+
+	return nil, nil
+}
@@ -2,36 +2,47 @@ package processors
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/lifecycle"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
+	dbm "github.com/Builder-Lawyers/builder-backend/internal/infra/db"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/mail"
 	"github.com/Builder-Lawyers/builder-backend/pkg/db"
 	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+	"github.com/jackc/pgx/v5"
 )
 
 type DeactivateSite struct {
 	uowFactory      *db.UOWFactory
-	dnsProvisioner  *dns.DNSProvisioner
+	cdn             dns.CDNProvider
+	zones           dns.DNSZoneProvider
 	provisionConfig config.ProvisionConfig
+	lifecycle       *lifecycle.SiteLifecycle
 }
 
-func NewDeactivateSite(uowFactory *db.UOWFactory, dnsProvisioner *dns.DNSProvisioner, provisionConfig config.ProvisionConfig,
+func NewDeactivateSite(uowFactory *db.UOWFactory, cdn dns.CDNProvider, zones dns.DNSZoneProvider, provisionConfig config.ProvisionConfig,
 ) *DeactivateSite {
-	return &DeactivateSite{uowFactory: uowFactory, dnsProvisioner: dnsProvisioner, provisionConfig: provisionConfig}
+	return &DeactivateSite{uowFactory: uowFactory, cdn: cdn, zones: zones, provisionConfig: provisionConfig,
+		lifecycle: lifecycle.NewSiteLifecycle(repo.NewRepositories())}
+}
+
+func (c *DeactivateSite) Register(registry *events.Registry) {
+	events.Register(registry, c.Handle, nil, events.DefaultRetryPolicy)
 }
 
 func (c *DeactivateSite) Handle(ctx context.Context, event events.DeactivateSite) (shared.UoW, error) {
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -50,19 +61,18 @@ func (c *DeactivateSite) Handle(ctx context.Context, event events.DeactivateSite
 	timeout := 5 * time.Second
 	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 
-	cloudfrontDomain, err := c.dnsProvisioner.WaitAndGetDistribution(timeoutCtx, provision.CloudfrontID)
+	cloudfrontDomain, err := c.cdn.WaitAndGetDistribution(timeoutCtx, provision.CloudfrontID)
 	cancel()
 	if err != nil {
 		return uow, err
 	}
 
-	err = c.dnsProvisioner.DeleteSubdomain(ctx, baseDomain, subdomain, cloudfrontDomain)
+	err = c.zones.DeleteSubdomain(ctx, baseDomain, subdomain, cloudfrontDomain)
 	if err != nil {
 		return uow, err
 	}
 
-	err = c.dnsProvisioner.DisableDistribution(ctx, provision.CloudfrontID,
-		strconv.FormatUint(event.SiteID, 10), c.provisionConfig.Defaults.S3Domain)
+	err = c.cdn.DisableDistribution(ctx, provision.CloudfrontID)
 	if err != nil {
 		return uow, err
 	}
@@ -72,27 +82,39 @@ func (c *DeactivateSite) Handle(ctx context.Context, event events.DeactivateSite
 		return nil, fmt.Errorf("err setting provision status to deactivated, %v", err)
 	}
 
+	params := lifecycle.TransitionParams{Actor: consts.ActorProcessor, Reason: event.Reason}
+	err = c.lifecycle.RecordProvisionChange(ctx, tx, event.SiteID, provision.Status, consts.ProvisionStatusDeactivated, params)
+	if err != nil {
+		return uow, err
+	}
+	err = transitionSite(ctx, c.lifecycle, tx, event.SiteID, consts.SiteStatusDeactivated, params)
+	if err != nil {
+		return uow, err
+	}
+	if event.Delete {
+		params.DeleteAt = time.Now().Add(c.provisionConfig.DeletionGracePeriod)
+		err = transitionSite(ctx, c.lifecycle, tx, event.SiteID, consts.SiteStatusAwaitingDeletion, params)
+		if err != nil {
+			return uow, err
+		}
+	}
+
 	// TODO: based on plan, do different actions. F.e. if plan is with separate domain - deactivate domain
-	var userID string
-	var firstName sql.NullString
-	var secondName sql.NullString
-	err = tx.QueryRow(ctx, "SELECT s.creator_id, u.first_name, u.second_name FROM builder.sites s "+
-		"LEFT JOIN builder.users u ON s.creator_id = u.id "+
-		"WHERE s.id = $1", event.SiteID).Scan(&userID, &firstName, &secondName)
+	creator, err := getSiteCreator(ctx, tx, event.SiteID)
 	if err != nil {
 		return uow, fmt.Errorf("error getting site creator, %v", err)
 	}
 
 	siteDeactivatedData := mail.SiteDeactivatedData{
-		CustomerFirstName:  firstName.String,
-		CustomerSecondName: secondName.String,
+		CustomerFirstName:  creator.FirstName,
+		CustomerSecondName: creator.SecondName,
 		Year:               strconv.Itoa(time.Now().Year()),
 		SiteURL:            provision.Domain,
 		Reason:             event.Reason,
 	}
 
 	sendMail := events.SendMail{
-		UserID:  userID,
+		UserID:  creator.ID.String(),
 		Subject: siteDeactivatedData.GetSubject(),
 		Data:    siteDeactivatedData,
 	}
@@ -123,3 +145,36 @@ func getBaseAndSubdomainFromFull(domain string) (string, string, error) {
 	fmt.Println("after:", baseDomain)
 	return baseDomain, subdomain, nil
 }
+
+// getSiteCreator returns a user, who created the site, only with id when user is already deleted
+func getSiteCreator(ctx context.Context, tx pgx.Tx, siteID uint64) (*dbm.User, error) {
+	site, err := repo.NewSiteRepo(tx).GetSite(ctx, siteID)
+	if err != nil {
+		return nil, err
+	}
+	creator, err := repo.NewUserRepo(tx).GetUser(ctx, site.CreatorID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return &dbm.User{ID: site.CreatorID}, nil
+		}
+		return nil, err
+	}
+
+	return creator, nil
+}
+
+// transitionSite moves site through the lifecycle, a forbidden transition won't be fixed by a retry
+func transitionSite(ctx context.Context, l *lifecycle.SiteLifecycle, tx pgx.Tx, siteID uint64, to consts.SiteStatus,
+	params lifecycle.TransitionParams,
+) error {
+	site, err := repo.NewSiteRepo(tx).GetSite(ctx, siteID)
+	if err != nil {
+		return fmt.Errorf("error getting site, %v", err)
+	}
+	err = l.Transition(ctx, tx, site, to, params)
+	var stateErr errs.InvalidStateError
+	if errors.As(err, &stateErr) {
+		return errs.PermanentError{Err: err}
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+package processors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/lifecycle"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/certs"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
+	"github.com/Builder-Lawyers/builder-backend/pkg/db"
+	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+	"github.com/stripe/stripe-go/v82"
+	"github.com/stripe/stripe-go/v82/subscription"
+)
+
+// DeleteSite removes resources of a deactivated site after the grace period.
+// Every step is a no-op for already removed resources, so a failed deletion is resumed by a retry
+type DeleteSite struct {
+	uowFactory      *db.UOWFactory
+	storage         *storage.Storage
+	cdn             dns.CDNProvider
+	certs           certs.Manager
+	provisionConfig config.ProvisionConfig
+	lifecycle       *lifecycle.SiteLifecycle
+}
+
+func NewDeleteSite(uowFactory *db.UOWFactory, storage *storage.Storage, cdn dns.CDNProvider,
+	certs certs.Manager, provisionConfig config.ProvisionConfig,
+) *DeleteSite {
+	return &DeleteSite{
+		uowFactory:      uowFactory,
+		storage:         storage,
+		cdn:             cdn,
+		certs:           certs,
+		provisionConfig: provisionConfig,
+		lifecycle:       lifecycle.NewSiteLifecycle(repo.NewRepositories()),
+	}
+}
+
+// Register - disabled distribution can be deleted only after it's redeployed, which takes several minutes
+func (c *DeleteSite) Register(registry *events.Registry) {
+	events.Register(registry, c.Handle, nil, events.RetryPolicy{
+		MaxAttempts:  40,
+		InitialDelay: 15 * time.Second,
+		MaxDelay:     2 * time.Minute,
+		Multiplier:   1.5,
+	})
+}
+
+func (c *DeleteSite) Handle(ctx context.Context, event events.DeleteSite) (shared.UoW, error) {
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	site, err := repo.NewSiteRepo(tx).GetSite(ctx, event.SiteID)
+	if err != nil {
+		return uow, fmt.Errorf("error getting site, %v", err)
+	}
+	if site.Status != consts.SiteStatusAwaitingDeletion {
+		slog.InfoContext(ctx, "site deletion was cancelled", "siteID", site.ID, "status", site.Status)
+		return uow, nil
+	}
+	provision, err := repo.NewProvisionRepo(tx).GetProvisionByID(ctx, event.SiteID)
+	if err != nil {
+		return uow, fmt.Errorf("error retrieving site's provision, %v", err)
+	}
+
+	deleted, err := c.storage.DeletePrefix(ctx, storage.SitePrefix(event.SiteID)+"/")
+	if err != nil {
+		return uow, err
+	}
+	slog.InfoContext(ctx, "site files deleted", "siteID", site.ID, "count", deleted)
+
+	if provision.CloudfrontID != "" {
+		if err = c.cdn.DeleteDistribution(ctx, provision.CloudfrontID); err != nil {
+			return uow, err
+		}
+	}
+	// default certificate is shared by all sites on our base domain
+	if provision.CertificateARN != "" && provision.CertificateARN != c.provisionConfig.Defaults.CertARN {
+		if err = c.certs.DeleteCertificate(ctx, provision.CertificateARN); err != nil {
+			return uow, err
+		}
+	}
+	if site.SubscriptionID != "" {
+		if err = cancelSubscription(site.SubscriptionID); err != nil {
+			return uow, err
+		}
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE builder.provisions SET status = $1, updated_at = $2 WHERE site_id = $3",
+		consts.ProvisionStatusDeleted, time.Now(), event.SiteID)
+	if err != nil {
+		return uow, fmt.Errorf("error updating provision's status, %v", err)
+	}
+
+	params := lifecycle.TransitionParams{Actor: consts.ActorProcessor, Reason: event.Reason}
+	err = c.lifecycle.RecordProvisionChange(ctx, tx, event.SiteID, provision.Status, consts.ProvisionStatusDeleted, params)
+	if err != nil {
+		return uow, err
+	}
+	err = transitionSite(ctx, c.lifecycle, tx, event.SiteID, consts.SiteStatusDeleted, params)
+	if err != nil {
+		return uow, err
+	}
+
+	return uow, nil
+}
+
+// cancelSubscription is a no-op for a missing or already cancelled subscription
+func cancelSubscription(subID string) error {
+	sub, err := subscription.Get(subID, &stripe.SubscriptionParams{})
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+			return nil
+		}
+		return fmt.Errorf("error getting subscription, %v", err)
+	}
+	if sub.Status == stripe.SubscriptionStatusCanceled {
+		return nil
+	}
+
+	_, err = subscription.Cancel(subID, &stripe.SubscriptionCancelParams{})
+	if err != nil {
+		return fmt.Errorf("error cancelling subscription, %v", err)
+	}
+
+	return nil
+}
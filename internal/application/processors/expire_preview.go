@@ -0,0 +1,128 @@
+package processors
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
+	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+)
+
+// distributionDisableDelay - disabled distribution is redeployed for several minutes, before it can be deleted
+const distributionDisableDelay = 5 * time.Minute
+
+// ExpirePreview removes a preview in two passes. The first one removes its dns record and files and disables its
+// distribution, the second one deletes the distribution, when it's disabled
+type ExpirePreview struct {
+	cfg        config.ProvisionConfig
+	uowFactory *dbs.UOWFactory
+	storage    *storage.Storage
+	cdn        dns.CDNProvider
+	zones      dns.DNSZoneProvider
+}
+
+func NewExpirePreview(cfg config.ProvisionConfig, factory *dbs.UOWFactory, storage *storage.Storage, cdn dns.CDNProvider,
+	zones dns.DNSZoneProvider,
+) *ExpirePreview {
+	return &ExpirePreview{
+		cfg:        cfg,
+		uowFactory: factory,
+		storage:    storage,
+		cdn:        cdn,
+		zones:      zones,
+	}
+}
+
+func (c *ExpirePreview) Register(registry *events.Registry) {
+	events.Register(registry, c.Handle, nil, events.RetryPolicy{
+		MaxAttempts:  40,
+		InitialDelay: 15 * time.Second,
+		MaxDelay:     2 * time.Minute,
+		Multiplier:   1.5,
+	})
+}
+
+func (c *ExpirePreview) Handle(ctx context.Context, event events.ExpirePreview) (shared.UoW, error) {
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	previewRepo := repo.NewSitePreviewRepo(tx)
+	preview, err := previewRepo.GetPreview(ctx, event.Token)
+	if err != nil {
+		return uow, fmt.Errorf("error getting preview, %v", err)
+	}
+
+	switch preview.Status {
+	case consts.PreviewStatusPending, consts.PreviewStatusReady:
+		// build of the preview failed before its distribution was created, only files can be left
+		if preview.CloudfrontID == "" {
+			var deleted int
+			deleted, err = c.storage.DeletePrefix(ctx, preview.Prefix+"/")
+			if err != nil {
+				return uow, err
+			}
+			slog.InfoContext(ctx, "preview without distribution expired", "token", event.Token, "files", deleted)
+			err = previewRepo.UpdatePreviewStatus(ctx, event.Token, consts.PreviewStatusExpired, "")
+			if err != nil {
+				return uow, err
+			}
+			break
+		}
+		// dns record is created only for a ready preview
+		if preview.CloudfrontDomain != "" {
+			subdomain := strings.TrimSuffix(preview.Domain, "."+c.cfg.BaseDomain)
+			err = c.zones.DeleteSubdomain(ctx, c.cfg.BaseDomain, subdomain, preview.CloudfrontDomain)
+			if err != nil {
+				return uow, err
+			}
+		}
+		err = c.cdn.DisableDistribution(ctx, preview.CloudfrontID)
+		if err != nil {
+			return uow, err
+		}
+		var deleted int
+		deleted, err = c.storage.DeletePrefix(ctx, preview.Prefix+"/")
+		if err != nil {
+			return uow, err
+		}
+		slog.InfoContext(ctx, "preview files deleted", "token", event.Token, "count", deleted)
+
+		err = previewRepo.UpdatePreviewStatus(ctx, event.Token, consts.PreviewStatusExpiring, "")
+		if err != nil {
+			return uow, err
+		}
+		err = repo.NewEventRepo(tx).InsertEvent(ctx, events.ExpirePreview{
+			Token:    event.Token,
+			ExpireAt: time.Now().Add(distributionDisableDelay),
+		})
+		if err != nil {
+			return uow, err
+		}
+	case consts.PreviewStatusExpiring:
+		err = c.cdn.DeleteDistribution(ctx, preview.CloudfrontID)
+		if err != nil {
+			return uow, err
+		}
+		err = previewRepo.UpdatePreviewStatus(ctx, event.Token, consts.PreviewStatusExpired, "")
+		if err != nil {
+			return uow, err
+		}
+		slog.InfoContext(ctx, "preview expired", "siteID", preview.SiteID, "token", event.Token)
+	default:
+		slog.InfoContext(ctx, "preview already expired", "token", event.Token)
+	}
+
+	return uow, nil
+}
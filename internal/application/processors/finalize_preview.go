@@ -0,0 +1,84 @@
+package processors
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
+	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+)
+
+type FinalizePreview struct {
+	cfg        config.ProvisionConfig
+	uowFactory *dbs.UOWFactory
+	cdn        dns.CDNProvider
+	zones      dns.DNSZoneProvider
+}
+
+func NewFinalizePreview(cfg config.ProvisionConfig, factory *dbs.UOWFactory, cdn dns.CDNProvider, zones dns.DNSZoneProvider,
+) *FinalizePreview {
+	return &FinalizePreview{
+		cfg:        cfg,
+		uowFactory: factory,
+		cdn:        cdn,
+		zones:      zones,
+	}
+}
+
+// Register - preview's distribution is deployed like a site's one, so it's polled the same way as in FinalizeProvision
+func (c *FinalizePreview) Register(registry *events.Registry) {
+	events.Register(registry, c.Handle, nil, events.RetryPolicy{
+		MaxAttempts:  40,
+		InitialDelay: 15 * time.Second,
+		MaxDelay:     2 * time.Minute,
+		Multiplier:   1.5,
+	})
+}
+
+func (c *FinalizePreview) Handle(ctx context.Context, event events.FinalizePreview) (shared.UoW, error) {
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	previewRepo := repo.NewSitePreviewRepo(tx)
+	preview, err := previewRepo.GetPreview(ctx, event.Token)
+	if err != nil {
+		return uow, fmt.Errorf("error getting preview, %v", err)
+	}
+	// preview could have expired, while its distribution was deploying
+	if preview.Status != consts.PreviewStatusPending {
+		slog.InfoContext(ctx, "preview is not pending", "token", event.Token, "status", preview.Status)
+		return uow, nil
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	cfDomain, err := c.cdn.WaitAndGetDistribution(timeoutCtx, event.DistributionID)
+	cancel()
+	if err != nil {
+		return uow, fmt.Errorf("err waiting for deployment of distribution, %w", err)
+	}
+
+	timeoutCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
+	err = c.zones.CreateSubdomain(timeoutCtx, c.cfg.BaseDomain, event.Domain, cfDomain)
+	cancel()
+	if err != nil {
+		return uow, fmt.Errorf("err creating route53 subdomain, %v", err)
+	}
+
+	err = previewRepo.UpdatePreviewStatus(ctx, event.Token, consts.PreviewStatusReady, cfDomain)
+	if err != nil {
+		return uow, err
+	}
+	slog.InfoContext(ctx, "preview is ready", "siteID", preview.SiteID, "domain", event.Domain)
+
+	return uow, nil
+}
@@ -2,7 +2,6 @@ package processors
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"strconv"
 	"strings"
@@ -10,6 +9,7 @@ import (
 
 	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/lifecycle"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
@@ -19,83 +19,90 @@ import (
 )
 
 type FinalizeProvision struct {
-	cfg         config.ProvisionConfig
-	uowFactory  *dbs.UOWFactory
-	dnsProvider *dns.DNSProvisioner
+	cfg        config.ProvisionConfig
+	uowFactory *dbs.UOWFactory
+	cdn        dns.CDNProvider
+	zones      dns.DNSZoneProvider
+	lifecycle  *lifecycle.SiteLifecycle
 }
 
 func NewFinalizeProvision(
-	cfg config.ProvisionConfig, factory *dbs.UOWFactory, dns *dns.DNSProvisioner,
+	cfg config.ProvisionConfig, factory *dbs.UOWFactory, cdn dns.CDNProvider, zones dns.DNSZoneProvider,
 ) *FinalizeProvision {
 	return &FinalizeProvision{
 		cfg,
 		factory,
-		dns,
+		cdn,
+		zones,
+		lifecycle.NewSiteLifecycle(repo.NewRepositories()),
 	}
 }
 
+// Register - distribution deployment usually takes several minutes, so it's polled for up to ~an hour
+func (c *FinalizeProvision) Register(registry *events.Registry) {
+	events.Register(registry, c.Handle, func(envelope events.Envelope) (events.FinalizeProvision, error) {
+		event, err := events.DecodeJSON[events.FinalizeProvision](envelope)
+		event.CreatedAt = envelope.CreatedAt
+		return event, err
+	}, events.RetryPolicy{
+		MaxAttempts:  40,
+		InitialDelay: 15 * time.Second,
+		MaxDelay:     2 * time.Minute,
+		Multiplier:   1.5,
+	})
+}
+
 func (c *FinalizeProvision) Handle(ctx context.Context, event events.FinalizeProvision) (interfaces.UoW, error) {
 	timeout := 10 * time.Second
 	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
-	cfDomain, err := c.dnsProvider.WaitAndGetDistribution(timeoutCtx, event.DistributionID)
+	cfDomain, err := c.cdn.WaitAndGetDistribution(timeoutCtx, event.DistributionID)
 	cancel()
 	if err != nil {
 		return nil, fmt.Errorf("err waiting for deployment of distribution, %w", err)
 	}
-	var baseDomain string
-	firstPart := strings.Index(event.Domain, ".")
-	if event.DomainType == consts.DefaultDomain {
-		baseDomain = event.Domain[firstPart+1:]
-	} else {
-		baseDomain = event.Domain
-	}
-
 	timeout = 5 * time.Second
 	timeoutCtx, cancel = context.WithTimeout(ctx, timeout)
-	err = c.dnsProvider.CreateSubdomain(timeoutCtx, baseDomain, event.Domain, cfDomain)
+	err = c.zones.CreateSubdomain(timeoutCtx, getBaseDomain(event.DomainType, event.Domain), event.Domain, cfDomain)
 	cancel()
 	if err != nil {
 		return nil, fmt.Errorf("err creating route53 subdomain, %v", err)
 	}
 
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
-	newStatus := consts.SiteStatusCreated
-	_, err = tx.Exec(ctx, "UPDATE builder.sites SET status = $1, updated_at = $2 WHERE id = $3", newStatus, time.Now(), event.SiteID)
-	if err != nil {
-		return uow, fmt.Errorf("error updating site status, %v", err)
-	}
-
 	_, err = tx.Exec(ctx, "UPDATE builder.provisions SET status = $1, updated_at = $2 WHERE site_id = $3",
 		consts.ProvisionStatusProvisioned, time.Now(), event.SiteID)
 	if err != nil {
 		return uow, fmt.Errorf("error updating provision's status, %v", err)
 	}
 
-	var userID string
-	var firstName sql.NullString
-	var secondName sql.NullString
-	err = tx.QueryRow(ctx, `SELECT s.creator_id, u.first_name, u.second_name 
-			FROM builder.sites s
-    		LEFT JOIN builder.users u ON s.creator_id = u.id
-			WHERE s.id = $1`, event.SiteID,
-	).Scan(&userID, &firstName, &secondName)
+	params := lifecycle.TransitionParams{Actor: consts.ActorProcessor, Reason: "Site was provisioned on " + event.Domain}
+	err = c.lifecycle.RecordProvisionChange(ctx, tx, event.SiteID, consts.ProvisionStatusInProcess, consts.ProvisionStatusProvisioned, params)
+	if err != nil {
+		return uow, err
+	}
+	err = transitionSite(ctx, c.lifecycle, tx, event.SiteID, consts.SiteStatusCreated, params)
+	if err != nil {
+		return uow, err
+	}
+
+	creator, err := getSiteCreator(ctx, tx, event.SiteID)
 	if err != nil {
 		return uow, fmt.Errorf("error getting mail data, %v", err)
 	}
 
 	mailData := mail.SiteCreatedData{
-		CustomerFirstName:  firstName.String,
-		CustomerSecondName: secondName.String,
+		CustomerFirstName:  creator.FirstName,
+		CustomerSecondName: creator.SecondName,
 		SiteURL:            event.Domain,
 		Year:               strconv.Itoa(time.Now().Year()),
 	}
 
 	sendMailEvent := events.SendMail{
-		UserID:  userID,
+		UserID:  creator.ID.String(),
 		Subject: mailData.GetSubject(),
 		Data:    mailData,
 	}
@@ -107,3 +114,11 @@ func (c *FinalizeProvision) Handle(ctx context.Context, event events.FinalizePro
 
 	return uow, nil
 }
+
+// getBaseDomain returns a hosted zone's domain, default domains are subdomains of our base domain
+func getBaseDomain(domainType consts.ProvisionType, domain string) string {
+	if domainType == consts.DefaultDomain {
+		return domain[strings.Index(domain, ".")+1:]
+	}
+	return domain
+}
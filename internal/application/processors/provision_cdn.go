@@ -2,58 +2,78 @@ package processors
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
-	"strconv"
 	"time"
 
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
 	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
 	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
-	"github.com/aws/aws-sdk-go-v2/service/route53domains/types"
 )
 
 type ProvisionCDN struct {
-	cfg            config.ProvisionConfig
-	uowFactory     *dbs.UOWFactory
-	dnsProvisioner *dns.DNSProvisioner
+	cfg        config.ProvisionConfig
+	uowFactory *dbs.UOWFactory
+	registrar  dns.DomainRegistrar
+	cdn        dns.CDNProvider
 }
 
 func NewProvisionCDN(
-	cfg config.ProvisionConfig, factory *dbs.UOWFactory, dns *dns.DNSProvisioner,
+	cfg config.ProvisionConfig, factory *dbs.UOWFactory, registrar dns.DomainRegistrar, cdn dns.CDNProvider,
 ) *ProvisionCDN {
 	return &ProvisionCDN{
 		cfg,
 		factory,
-		dns,
+		registrar,
+		cdn,
 	}
 }
 
-func (c *ProvisionCDN) Handle(ctx context.Context, event events.ProvisionCDN) (shared.UoW, error) {
-	siteID := strconv.FormatUint(event.SiteID, 10)
+// Register - domain registration can take up to a few days, so its status is polled for a long time
+func (c *ProvisionCDN) Register(registry *events.Registry) {
+	events.Register(registry, c.Handle, func(envelope events.Envelope) (events.ProvisionCDN, error) {
+		event, err := events.DecodeJSON[events.ProvisionCDN](envelope)
+		event.CreatedAt = envelope.CreatedAt
+		return event, err
+	}, events.RetryPolicy{
+		MaxAttempts:  150,
+		InitialDelay: time.Minute,
+		MaxDelay:     30 * time.Minute,
+		Multiplier:   2,
+	})
+}
 
-	status, err := c.dnsProvisioner.GetDomainStatus(ctx, event.OperationID)
+func (c *ProvisionCDN) Handle(ctx context.Context, event events.ProvisionCDN) (shared.UoW, error) {
+	status, err := c.registrar.GetDomainStatus(ctx, event.OperationID)
+	if err != nil {
+		return nil, fmt.Errorf("err getting domain registration status, %w", err)
+	}
 	switch status {
-	case types.OperationStatusSuccessful:
-		slog.Info("Requested domain was provisioned for site", "siteID", event.SiteID)
+	case dns.RegistrationSucceeded:
+		slog.InfoContext(ctx, "Requested domain was provisioned for site", "siteID", event.SiteID)
+	case dns.RegistrationFailed:
+		return nil, fmt.Errorf("domain registration failed with status %v", status)
 	default:
-		slog.Info("Domain is not provisioned yet for site", "siteID", event.SiteID)
-		return nil, nil
+		slog.InfoContext(ctx, "Domain is not provisioned yet for site", "siteID", event.SiteID)
+		return nil, errs.RetryableError{Err: fmt.Errorf("domain registration is %v", status)}
 	}
 
 	timeout := 3 * time.Second
 	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
 	// TODO: verify here domain passed, if it is ok
-	distributionID, err := c.dnsProvisioner.MapCfDistributionToS3GetURL(timeoutCtx, "/sites/"+siteID, event.Domain, event.Domain, event.CertificateARN)
+	distribution, err := c.cdn.CreateDistribution(timeoutCtx, "/"+storage.SiteVersionPrefix(event.SiteID, 1), event.Domain, event.Domain, event.CertificateARN)
 	cancel()
 	if err != nil {
 		return nil, err
 	}
 
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +83,7 @@ func (c *ProvisionCDN) Handle(ctx context.Context, event events.ProvisionCDN) (s
 	if err != nil {
 		return uow, err
 	}
-	provision.CloudfrontID = distributionID
+	provision.CloudfrontID = distribution.ID
 
 	_, err = tx.Exec(ctx, "UPDATE builder.provisions SET cloudfront_id = $1, updated_at = $2 WHERE site_id = $3",
 		provision.CloudfrontID, time.Now(), event.SiteID)
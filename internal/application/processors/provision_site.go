@@ -2,17 +2,15 @@ package processors
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"os"
-	"path/filepath"
-	"strconv"
 	"time"
 
 	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
-	"github.com/Builder-Lawyers/builder-backend/internal/infra/build"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/lifecycle"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/publishing"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/certs"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
@@ -21,156 +19,162 @@ import (
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
 	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
 	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/jackc/pgx/v5"
 )
 
 type ProvisionSite struct {
-	cfg            config.ProvisionConfig
-	uowFactory     *dbs.UOWFactory
-	storage        *storage.Storage
-	templateBuild  *build.TemplateBuild
-	dnsProvisioner *dns.DNSProvisioner
-	certs          *certs.ACMCertificates
+	cfg        config.ProvisionConfig
+	uowFactory *dbs.UOWFactory
+	storage    *storage.Storage
+	publisher  *publishing.Publisher
+	cdn        dns.CDNProvider
+	registrar  dns.DomainRegistrar
+	certs      certs.Manager
+	lifecycle  *lifecycle.SiteLifecycle
 }
 
 func NewProvisionSite(
 	cfg config.ProvisionConfig, factory *dbs.UOWFactory, storage *storage.Storage,
-	build *build.TemplateBuild, dns *dns.DNSProvisioner, certs *certs.ACMCertificates,
+	publisher *publishing.Publisher, cdn dns.CDNProvider, registrar dns.DomainRegistrar, certs certs.Manager,
 ) *ProvisionSite {
 	return &ProvisionSite{
 		cfg,
 		factory,
 		storage,
-		build,
-		dns,
+		publisher,
+		cdn,
+		registrar,
 		certs,
+		lifecycle.NewSiteLifecycle(repo.NewRepositories()),
 	}
 }
 
-// download all source code of template, save it to fs
-// place page.json to a certain dir
-// check if node_modules are installed, install if not
-// build to dist folder
-// upload dist folder to s3
-func (c *ProvisionSite) Handle(ctx context.Context, event events.SiteAwaitingProvision) (shared.UoW, error) {
-	siteID := strconv.FormatUint(event.SiteID, 10)
-	sitePath := "sites/" + siteID
-	// idempotent execution - check if provisioned site static content already exists
-	existingFiles := c.storage.ListFiles(ctx, 1, &s3.ListObjectsV2Input{
-		Prefix: aws.String(siteID),
+func (c *ProvisionSite) Register(registry *events.Registry) {
+	events.Register(registry, c.Handle, nil, events.DefaultRetryPolicy)
+	events.OnDeadLetter(registry, c.Compensate)
+}
+
+// Compensate returns site to the draft, when its provision can't be started, so the owner can fix and request it again.
+// Saved provision is kept InProcess, its distribution or certificate is reused by the next request of the same domain
+func (c *ProvisionSite) Compensate(ctx context.Context, tx pgx.Tx, event events.SiteAwaitingProvision, cause error) error {
+	_, err := c.storage.DeletePrefix(ctx, storage.SiteVersionPrefix(event.SiteID, 1))
+	if err != nil {
+		return fmt.Errorf("err deleting build of failed provision, %v", err)
+	}
+
+	return transitionSite(ctx, c.lifecycle, tx, event.SiteID, consts.SiteStatusInCreation, lifecycle.TransitionParams{
+		Actor:  consts.ActorProcessor,
+		Reason: fmt.Sprintf("Provision failed, %v", cause),
 	})
-	if len(existingFiles) > 0 {
-		slog.Warn("site already provisioned", "id", siteID)
-		return nil, nil
+}
+
+// build the first version in its own workspace and upload it to s3
+// create a distribution or request a separate domain for it
+func (c *ProvisionSite) Handle(ctx context.Context, event events.SiteAwaitingProvision) (shared.UoW, error) {
+	// the first version of a site is published by its provision
+	sitePath := storage.SiteVersionPrefix(event.SiteID, 1)
+	structureURL := c.storage.GetFileURL(sitePath + "/" + c.cfg.Filename)
+	var domain string
+	switch event.DomainType {
+	case consts.DefaultDomain:
+		domain = fmt.Sprintf("%v.%v", event.Domain, c.cfg.BaseDomain)
+	case consts.SeparateDomain:
+		domain = event.Domain
+	default:
+		return nil, fmt.Errorf("unknown domain type")
 	}
 
-	err := c.templateBuild.DownloadTemplate(ctx, event.TemplateName)
+	saved, err := c.savedProvision(ctx, event, domain)
 	if err != nil {
 		return nil, err
 	}
-	templatePath := filepath.Join(c.cfg.TemplatesFolder, event.TemplateName)
-	customizeJsonPath := filepath.Join(templatePath+c.cfg.PathToFile, c.cfg.Filename)
-	err = saveFieldsToFile(event.Fields, customizeJsonPath)
-	if err != nil {
-		slog.Error("error saving fields json to template", "build", err)
-		return nil, err
+	if saved != nil && saved.Status != consts.ProvisionStatusInProcess {
+		slog.WarnContext(ctx, "site already provisioned", "id", event.SiteID, "status", saved.Status)
+		return nil, nil
 	}
-	defer cleanBuild(customizeJsonPath)
 
-	slog.Info("Building")
-	buildPath, err := c.templateBuild.RunSiteBuild(ctx, templatePath)
+	err = c.publisher.Build(ctx, sitePath, event.TemplateName, event.Fields)
 	if err != nil {
-		return nil, fmt.Errorf("err building site, %v", err)
-	}
-	if err = c.templateBuild.UploadFiles(ctx, sitePath, event.TemplateName, buildPath); err != nil {
 		return nil, err
 	}
 
-	structureFile, err := os.Open(customizeJsonPath)
-	if err != nil {
-		return nil, fmt.Errorf("err reading site structure file, %v", err)
-	}
-	defer structureFile.Close()
-	structureURL, err := c.storage.UploadFile(ctx, sitePath+"/"+c.cfg.Filename, aws.String("application/json"), structureFile)
-	if err != nil {
-		return nil, fmt.Errorf("err uploading structure file, %v", err)
-	}
-	var domain string
 	var newEvent shared.Event
-	var newProvision db.Provision
-
 	switch event.DomainType {
 	case consts.DefaultDomain:
-
-		domain = fmt.Sprintf("%v.%v", event.Domain, c.cfg.BaseDomain)
-		timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		distributionID, err := c.dnsProvisioner.MapCfDistributionToS3GetID(timeoutCtx, "/sites/"+siteID, c.cfg.Defaults.S3Domain, domain, c.cfg.Defaults.CertARN)
-		cancel()
-		if err != nil {
-			return nil, err
+		provision := saved
+		if provision == nil {
+			timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			distribution, err := c.cdn.CreateDistribution(timeoutCtx, "/"+sitePath, c.cfg.Defaults.S3Domain, domain, c.cfg.Defaults.CertARN)
+			cancel()
+			if err != nil {
+				return nil, err
+			}
+			provision = &db.Provision{
+				SiteID:         event.SiteID,
+				Type:           event.DomainType,
+				Status:         consts.ProvisionStatusInProcess,
+				Domain:         domain,
+				CertificateARN: c.cfg.Defaults.CertARN,
+				CloudfrontID:   distribution.ID,
+				StructurePath:  structureURL,
+				CreatedAt:      time.Now(),
+				UpdatedAt:      time.Now(),
+			}
+			err = c.saveProvision(ctx, provision)
+			if err != nil {
+				return nil, err
+			}
 		}
 		newEvent = events.FinalizeProvision{
 			SiteID:         event.SiteID,
-			DistributionID: distributionID,
+			DistributionID: provision.CloudfrontID,
 			Domain:         domain,
 			DomainType:     event.DomainType,
 			CreatedAt:      time.Now(),
 		}
-		newProvision = db.Provision{
-			SiteID:         event.SiteID,
-			Type:           event.DomainType,
-			Status:         consts.ProvisionStatusInProcess,
-			Domain:         domain,
-			CertificateARN: c.cfg.Defaults.CertARN,
-			CloudfrontID:   distributionID,
-			StructurePath:  structureURL,
-			CreatedAt:      time.Now(),
-			UpdatedAt:      time.Now(),
-		}
-		break
 
 	case consts.SeparateDomain:
-
-		domain = event.Domain
+		// certificate is created first, registration of the domain is started only by the last step before the commit
+		provision := saved
+		if provision == nil {
+			// for now is a FQDN, maybe do with asterisk like a *.baseDomain?
+			certificateARN, err := c.certs.CreateCertificate(ctx, domain)
+			if err != nil {
+				return nil, err
+			}
+			provision = &db.Provision{
+				SiteID:         event.SiteID,
+				Type:           event.DomainType,
+				Status:         consts.ProvisionStatusInProcess,
+				Domain:         domain,
+				CertificateARN: certificateARN,
+				StructurePath:  structureURL,
+				CreatedAt:      time.Now(),
+				UpdatedAt:      time.Now(),
+			}
+			err = c.saveProvision(ctx, provision)
+			if err != nil {
+				return nil, err
+			}
+		}
 		timeoutCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
-		operationID, err := c.dnsProvisioner.RequestDomain(timeoutCtx, domain)
+		operationID, err := c.registrar.RequestDomain(timeoutCtx, domain)
 		cancel()
 		if err != nil {
 			return nil, err
 		}
-		// for now is a FQDN, maybe do with asterisk like a *.baseDomain?
-		certificateARN, err := c.certs.CreateCertificate(ctx, domain)
-		if err != nil {
-			return nil, err
-		}
 
 		newEvent = events.ProvisionCDN{
 			SiteID:         event.SiteID,
 			OperationID:    operationID,
-			CertificateARN: certificateARN,
-			Domain:         domain,
-			CreatedAt:      time.Now(),
-		}
-
-		newProvision = db.Provision{
-			SiteID:         event.SiteID,
-			Type:           event.DomainType,
-			Status:         consts.ProvisionStatusInProcess,
+			CertificateARN: provision.CertificateARN,
 			Domain:         domain,
-			CertificateARN: certificateARN,
-			StructurePath:  structureURL,
 			CreatedAt:      time.Now(),
-			UpdatedAt:      time.Now(),
 		}
-
-		break
-	default:
-		return nil, fmt.Errorf("unknown domain type")
 	}
 
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -180,53 +184,76 @@ func (c *ProvisionSite) Handle(ctx context.Context, event events.SiteAwaitingPro
 	if err != nil {
 		return uow, err
 	}
-	provisionRepo := repo.NewProvisionRepo(tx)
-	err = provisionRepo.InsertProvision(ctx, newProvision)
+
+	err = repo.NewSiteVersionRepo(tx).InsertSiteVersion(ctx, db.SiteVersion{
+		SiteID:    event.SiteID,
+		Version:   1,
+		Fields:    event.Fields,
+		Prefix:    sitePath,
+		CreatedAt: time.Now(),
+	})
 	if err != nil {
 		return uow, err
 	}
-
-	return uow, nil
-}
-
-func (c *ProvisionSite) buildSite(ctx context.Context, siteID, templatePath, templateName string) error {
-	err := c.templateBuild.DownloadTemplate(ctx, templateName)
+	err = repo.NewSiteRepo(tx).UpdateSitePublishedVersion(ctx, event.SiteID, 1)
 	if err != nil {
-		return err
+		return uow, err
 	}
-
-	slog.Info("Building")
-	buildPath, err := c.templateBuild.RunSiteBuild(ctx, templatePath)
+	err = c.lifecycle.RecordVersionChange(ctx, tx, event.SiteID, 0, 1, lifecycle.TransitionParams{
+		Actor:  consts.ActorProcessor,
+		Reason: "Version 1 was published by site's provision",
+	})
 	if err != nil {
-		return err
+		return uow, err
 	}
 
-	return c.templateBuild.UploadFiles(ctx, "sites/"+siteID, templateName, buildPath)
+	return uow, nil
 }
 
-func saveFieldsToFile(fields json.RawMessage, fullPath string) error {
-	jsonBytes, err := json.MarshalIndent(fields, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal fields: %w", err)
-	}
-	file, err := os.Create(fullPath)
-	if err != nil {
-		return fmt.Errorf("failed to create file %s: %w", fullPath, err)
-	}
-	defer file.Close()
+// savedProvision returns provision saved by a failed attempt or a compensated request, so its resources are reused.
+// Provision of another domain is removed, as its resources can't serve this one
+func (c *ProvisionSite) savedProvision(ctx context.Context, event events.SiteAwaitingProvision, domain string) (*db.Provision, error) {
+	var provision *db.Provision
+	err := c.uowFactory.RunInTx(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		provisionRepo := repo.NewProvisionRepo(tx)
+		var err error
+		provision, err = provisionRepo.GetProvisionByID(ctx, event.SiteID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				provision = nil
+				return nil
+			}
+			return fmt.Errorf("err getting provision, %v", err)
+		}
+		if provision.Status != consts.ProvisionStatusInProcess ||
+			(provision.Type == event.DomainType && provision.Domain == domain) {
+			return nil
+		}
 
-	if _, err := file.Write(jsonBytes); err != nil {
-		return fmt.Errorf("failed to write JSON to file %s: %w", fullPath, err)
-	}
+		slog.WarnContext(ctx, "provision of another domain is replaced, its resources have to be removed manually",
+			"siteID", event.SiteID, "domain", provision.Domain, "distributionID", provision.CloudfrontID,
+			"certificateARN", provision.CertificateARN)
+		err = provisionRepo.DeleteProvision(ctx, event.SiteID)
+		if err != nil {
+			return err
+		}
+		provision = nil
+		return nil
+	})
 
-	return nil
+	return provision, err
 }
 
-func cleanBuild(filename string) {
-	err := os.Remove(filename)
-	if err != nil {
-		slog.Error("error cleaning up", "cleanup", err)
-		return
-	}
-	slog.Info("Success build cleanup", "cleanup", "")
+// saveProvision is committed right after its resources are created, so a retry doesn't create them again
+func (c *ProvisionSite) saveProvision(ctx context.Context, provision *db.Provision) error {
+	return c.uowFactory.RunInTx(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		err := repo.NewProvisionRepo(tx).InsertProvision(ctx, *provision)
+		if err != nil {
+			return fmt.Errorf("err saving provision, %v", err)
+		}
+		return c.lifecycle.RecordProvisionChange(ctx, tx, provision.SiteID, "", provision.Status, lifecycle.TransitionParams{
+			Actor:  consts.ActorProcessor,
+			Reason: fmt.Sprintf("Provision of %v domain %v was started", provision.Type, provision.Domain),
+		})
+	})
 }
@@ -0,0 +1,135 @@
+package processors
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/lifecycle"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/mail"
+	"github.com/Builder-Lawyers/builder-backend/pkg/db"
+	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+	"github.com/jackc/pgx/v5"
+)
+
+// ReactivateSite reverts DeactivateSite - enables site's distribution and recreates its dns record
+type ReactivateSite struct {
+	uowFactory *db.UOWFactory
+	cdn        dns.CDNProvider
+	zones      dns.DNSZoneProvider
+	lifecycle  *lifecycle.SiteLifecycle
+}
+
+func NewReactivateSite(uowFactory *db.UOWFactory, cdn dns.CDNProvider, zones dns.DNSZoneProvider) *ReactivateSite {
+	return &ReactivateSite{
+		uowFactory: uowFactory,
+		cdn:        cdn,
+		zones:      zones,
+		lifecycle:  lifecycle.NewSiteLifecycle(repo.NewRepositories()),
+	}
+}
+
+// Register - enabled distribution is redeployed like a new one, so it's polled the same way as in FinalizeProvision
+func (c *ReactivateSite) Register(registry *events.Registry) {
+	events.Register(registry, c.Handle, nil, events.RetryPolicy{
+		MaxAttempts:  40,
+		InitialDelay: 15 * time.Second,
+		MaxDelay:     2 * time.Minute,
+		Multiplier:   1.5,
+	})
+	events.OnDeadLetter(registry, c.Compensate)
+}
+
+// Compensate keeps site deactivated, when it can't be reactivated. Provision is updated only by a successful attempt,
+// so just the distribution, that could have been enabled by a failed one, is disabled again
+func (c *ReactivateSite) Compensate(ctx context.Context, tx pgx.Tx, event events.ReactivateSite, cause error) error {
+	provision, err := repo.NewProvisionRepo(tx).GetProvisionByID(ctx, event.SiteID)
+	if err != nil {
+		return fmt.Errorf("error retrieving site's provision, %v", err)
+	}
+	err = c.cdn.DisableDistribution(ctx, provision.CloudfrontID)
+	if err != nil {
+		return fmt.Errorf("err disabling distribution of failed reactivation, %v", err)
+	}
+
+	return transitionSite(ctx, c.lifecycle, tx, event.SiteID, consts.SiteStatusDeactivated, lifecycle.TransitionParams{
+		Actor:  consts.ActorProcessor,
+		Reason: fmt.Sprintf("Reactivation failed, %v", cause),
+	})
+}
+
+func (c *ReactivateSite) Handle(ctx context.Context, event events.ReactivateSite) (shared.UoW, error) {
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	provision, err := repo.NewProvisionRepo(tx).GetProvisionByID(ctx, event.SiteID)
+	if err != nil {
+		return uow, fmt.Errorf("error retrieving site's provision, %v", err)
+	}
+
+	err = c.cdn.EnableDistribution(ctx, provision.CloudfrontID)
+	if err != nil {
+		return uow, err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	cfDomain, err := c.cdn.WaitAndGetDistribution(timeoutCtx, provision.CloudfrontID)
+	cancel()
+	if err != nil {
+		return uow, fmt.Errorf("err waiting for deployment of distribution, %w", err)
+	}
+
+	timeoutCtx, cancel = context.WithTimeout(ctx, 5*time.Second)
+	err = c.zones.CreateSubdomain(timeoutCtx, getBaseDomain(provision.Type, provision.Domain), provision.Domain, cfDomain)
+	cancel()
+	if err != nil {
+		return uow, fmt.Errorf("err creating route53 subdomain, %v", err)
+	}
+
+	_, err = tx.Exec(ctx, "UPDATE builder.provisions SET status = $1, updated_at = $2 WHERE site_id = $3",
+		consts.ProvisionStatusProvisioned, time.Now(), event.SiteID)
+	if err != nil {
+		return uow, fmt.Errorf("error updating provision's status, %v", err)
+	}
+
+	params := lifecycle.TransitionParams{Actor: consts.ActorProcessor, Reason: event.Reason}
+	err = c.lifecycle.RecordProvisionChange(ctx, tx, event.SiteID, provision.Status, consts.ProvisionStatusProvisioned, params)
+	if err != nil {
+		return uow, err
+	}
+	err = transitionSite(ctx, c.lifecycle, tx, event.SiteID, consts.SiteStatusCreated, params)
+	if err != nil {
+		return uow, err
+	}
+
+	creator, err := getSiteCreator(ctx, tx, event.SiteID)
+	if err != nil {
+		return uow, fmt.Errorf("error getting site creator, %v", err)
+	}
+
+	mailData := mail.SiteReactivatedData{
+		CustomerFirstName:  creator.FirstName,
+		CustomerSecondName: creator.SecondName,
+		SiteURL:            provision.Domain,
+		Year:               strconv.Itoa(time.Now().Year()),
+	}
+
+	err = repo.NewEventRepo(tx).InsertEvent(ctx, events.SendMail{
+		UserID:  creator.ID.String(),
+		Subject: mailData.GetSubject(),
+		Data:    mailData,
+	})
+	if err != nil {
+		return uow, err
+	}
+
+	return uow, nil
+}
@@ -0,0 +1,454 @@
+package processors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/lifecycle"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/publishing"
+	infrabuild "github.com/Builder-Lawyers/builder-backend/internal/infra/build"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
+	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// build steps, that are shown in build's status
+const (
+	stepBuilding  = "Building"
+	stepDeploying = "Deploying"
+)
+
+// queueFullDelay - when a build is retried, if all build workers were busy
+const queueFullDelay = 30 * time.Second
+
+// cancelCheckInterval - how often a running build checks, if its cancel was requested
+const cancelCheckInterval = 5 * time.Second
+
+var errBuildCancelled = errors.New("build was cancelled")
+
+// rebuildRetryPolicy - a build is retried a few times, its status is Failed, when the retries are exhausted
+var rebuildRetryPolicy = events.RetryPolicy{
+	MaxAttempts:  3,
+	InitialDelay: 30 * time.Second,
+	MaxDelay:     5 * time.Minute,
+	Multiplier:   2,
+}
+
+// RebuildSite builds site's draft for a queued job and publishes it as a new version or serves it as a preview
+type RebuildSite struct {
+	cfg        config.ProvisionConfig
+	uowFactory *dbs.UOWFactory
+	publisher  *publishing.Publisher
+	cdn        dns.CDNProvider
+}
+
+func NewRebuildSite(cfg config.ProvisionConfig, factory *dbs.UOWFactory, publisher *publishing.Publisher, cdn dns.CDNProvider,
+) *RebuildSite {
+	return &RebuildSite{
+		cfg:        cfg,
+		uowFactory: factory,
+		publisher:  publisher,
+		cdn:        cdn,
+	}
+}
+
+func (c *RebuildSite) Register(registry *events.Registry) {
+	events.Register(registry, c.Handle, func(envelope events.Envelope) (events.RebuildSite, error) {
+		event, err := events.DecodeJSON[events.RebuildSite](envelope)
+		event.Attempt = envelope.Attempts + 1
+		return event, err
+	}, rebuildRetryPolicy)
+}
+
+// Handle builds without holding a transaction, the result is saved in the returned uow together with the build's status
+func (c *RebuildSite) Handle(ctx context.Context, event events.RebuildSite) (shared.UoW, error) {
+	var build *db.SiteBuild
+	var site *db.Site
+	var templateName string
+	err := c.uowFactory.RunInReadOnlyTx(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		build, err = repo.NewSiteBuildRepo(tx).GetBuild(ctx, event.JobID)
+		if err != nil {
+			return fmt.Errorf("err getting site build, %v", err)
+		}
+		site, err = repo.NewSiteRepo(tx).GetSite(ctx, event.SiteID)
+		if err != nil {
+			return fmt.Errorf("err getting site, %v", err)
+		}
+		template, err := repo.NewTemplateRepo(tx).GetTemplate(ctx, site.TemplateID)
+		if err != nil {
+			return fmt.Errorf("err getting template's name, %v", err)
+		}
+		templateName = template.Name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if build.Status == consts.BuildStatusSucceeded || build.Status == consts.BuildStatusFailed ||
+		build.Status == consts.BuildStatusCancelled {
+		slog.InfoContext(ctx, "site build is already finished", "jobID", build.ID, "status", build.Status)
+		return nil, nil
+	}
+	build.Attempts = event.Attempt
+	build.Error = ""
+
+	// build is stopped, when its cancel is requested through any replica
+	buildCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go c.watchCancel(buildCtx, build.ID, cancel)
+
+	if build.CancelRequested {
+		err = errBuildCancelled
+	} else {
+		var uow shared.UoW
+		uow, err = c.run(ctx, buildCtx, event, build, site, templateName)
+		if err == nil {
+			return uow, nil
+		}
+		if uow != nil {
+			// result of a failed build is rolled back before its failure is saved
+			if rollbackErr := uow.Rollback(); rollbackErr != nil {
+				slog.ErrorContext(ctx, "err rolling back site build", "jobID", build.ID, "err", rollbackErr)
+			}
+		}
+	}
+
+	cancelled := build.CancelRequested || errors.Is(context.Cause(buildCtx), errBuildCancelled)
+	if cancelled {
+		err = errs.PermanentError{Err: errBuildCancelled}
+	} else if errors.Is(err, infrabuild.ErrQueueFull) {
+		return nil, c.requeue(ctx, build, event, err)
+	}
+	if failErr := c.fail(ctx, build, err, cancelled); failErr != nil {
+		slog.ErrorContext(ctx, "err saving failure of site build", "jobID", build.ID, "err", failErr)
+	}
+
+	return nil, err
+}
+
+// run builds with buildCtx, that is cancelled with the build. Result is saved in a uow bound to ctx,
+// as it's committed by the poller after Handle returns and buildCtx is cancelled
+func (c *RebuildSite) run(ctx, buildCtx context.Context, event events.RebuildSite, build *db.SiteBuild, site *db.Site,
+	templateName string,
+) (shared.UoW, error) {
+	if event.Kind == consts.BuildKindPublish && site.Status != consts.SiteStatusCreated {
+		return nil, errs.PermanentError{Err: fmt.Errorf("site %v is %v, only a created site can be published", site.ID, site.Status)}
+	}
+	deleting := site.Status == consts.SiteStatusDeleted || site.Status == consts.SiteStatusAwaitingDeletion
+	if event.Kind == consts.BuildKindPreview && deleting {
+		return nil, errs.PermanentError{Err: fmt.Errorf("site %v is %v, it can't be previewed", site.ID, site.Status)}
+	}
+
+	var prefix string
+	var number int
+	var token string
+	switch event.Kind {
+	case consts.BuildKindPublish:
+		err := c.uowFactory.RunInReadOnlyTx(ctx, func(ctx context.Context, tx pgx.Tx) error {
+			var err error
+			number, err = repo.NewSiteVersionRepo(tx).NextSiteVersion(ctx, site.ID)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		// a failed attempt leaves files under the prefix, they are overwritten by the next one, as it gets the same number
+		prefix = storage.SiteVersionPrefix(site.ID, number)
+	case consts.BuildKindPreview:
+		// token is derived from the job, so every attempt uploads to the same prefix
+		token = strings.ReplaceAll(build.ID.String(), "-", "")[:12]
+		prefix = storage.SitePreviewPrefix(site.ID, token)
+	default:
+		return nil, errs.PermanentError{Err: fmt.Errorf("unknown build kind %v", event.Kind)}
+	}
+
+	err := c.progress(ctx, build, stepBuilding)
+	if err != nil {
+		return nil, err
+	}
+	err = c.publisher.Build(buildCtx, prefix, templateName, site.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.Kind == consts.BuildKindPublish {
+		return c.publish(ctx, build, site, number, prefix)
+	}
+	return c.preview(ctx, build, token, prefix)
+}
+
+// publish saves the built version and points site's distribution to it
+func (c *RebuildSite) publish(ctx context.Context, build *db.SiteBuild, site *db.Site, number int, prefix string,
+) (shared.UoW, error) {
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// site could have been changed, while it was building, version keeps the fields, that were built
+	built := site.Fields
+	site, err = repo.NewSiteRepo(tx).GetSite(ctx, site.ID)
+	if err != nil {
+		return uow, fmt.Errorf("err getting site, %v", err)
+	}
+	if site.Status != consts.SiteStatusCreated {
+		return uow, errs.PermanentError{Err: fmt.Errorf("site %v is %v, only a created site can be published", site.ID, site.Status)}
+	}
+	provision, err := repo.NewProvisionRepo(tx).GetProvisionByID(ctx, site.ID)
+	if err != nil {
+		return uow, fmt.Errorf("err getting provision, %v", err)
+	}
+
+	version := db.SiteVersion{
+		SiteID:    site.ID,
+		Version:   number,
+		Fields:    built,
+		Prefix:    prefix,
+		CreatedBy: &build.CreatedBy,
+		CreatedAt: time.Now(),
+	}
+	err = repo.NewSiteVersionRepo(tx).InsertSiteVersion(ctx, version)
+	if err != nil {
+		return uow, err
+	}
+	err = c.publisher.SwitchVersion(ctx, tx, site, provision, &version, lifecycle.TransitionParams{
+		Actor:   consts.ActorUser,
+		ActorID: &build.CreatedBy,
+		Reason:  fmt.Sprintf("Version %v was published", number),
+	})
+	if err != nil {
+		return uow, err
+	}
+
+	build.Version = number
+	err = c.succeed(ctx, tx, build)
+	if err != nil {
+		return uow, err
+	}
+
+	return uow, nil
+}
+
+// preview serves the build on a subdomain of the base domain, it's covered by the default certificate.
+// Preview is saved before its distribution is created and the distribution right after it, so a retry reuses them
+// instead of creating a second distribution, which alias would be rejected
+func (c *RebuildSite) preview(ctx context.Context, build *db.SiteBuild, token, prefix string) (shared.UoW, error) {
+	err := c.progress(ctx, build, stepDeploying)
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := c.pendingPreview(ctx, build, token, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if preview.CloudfrontID == "" {
+		timeoutCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		distribution, err := c.cdn.CreateDistribution(timeoutCtx, "/"+preview.Prefix, c.cfg.Defaults.S3Domain,
+			preview.Domain, c.cfg.Defaults.CertARN)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		err = c.uowFactory.RunInTx(ctx, func(ctx context.Context, tx pgx.Tx) error {
+			return repo.NewSitePreviewRepo(tx).UpdatePreviewDistribution(ctx, token, distribution.ID)
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "distribution of preview isn't saved, it has to be removed manually", "token", token,
+				"distributionID", distribution.ID, "err", err)
+			return nil, err
+		}
+		preview.CloudfrontID = distribution.ID
+	}
+
+	uow := c.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repo.NewEventRepo(tx).InsertEvent(ctx, events.FinalizePreview{
+		Token:          token,
+		DistributionID: preview.CloudfrontID,
+		Domain:         preview.Domain,
+	})
+	if err != nil {
+		return uow, err
+	}
+
+	build.PreviewToken = token
+	err = c.succeed(ctx, tx, build)
+	if err != nil {
+		return uow, err
+	}
+
+	return uow, nil
+}
+
+// pendingPreview returns preview saved by a previous attempt or saves a new one without a distribution.
+// Its expiry is scheduled with it, so a preview of a build, that fails later, is removed too
+func (c *RebuildSite) pendingPreview(ctx context.Context, build *db.SiteBuild, token, prefix string) (*db.SitePreview, error) {
+	var preview *db.SitePreview
+	err := c.uowFactory.RunInTx(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		previewRepo := repo.NewSitePreviewRepo(tx)
+		var err error
+		preview, err = previewRepo.GetPreview(ctx, token)
+		if err == nil {
+			if preview.Status != consts.PreviewStatusPending {
+				return errs.PermanentError{Err: fmt.Errorf("preview %v is already %v", token, preview.Status)}
+			}
+			return nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("err getting preview, %v", err)
+		}
+
+		preview = &db.SitePreview{
+			Token:     token,
+			SiteID:    build.SiteID,
+			Status:    consts.PreviewStatusPending,
+			Prefix:    prefix,
+			Domain:    fmt.Sprintf("preview-%v.%v", token, c.cfg.BaseDomain),
+			CreatedBy: build.CreatedBy,
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(c.cfg.PreviewTTL),
+		}
+		err = previewRepo.InsertPreview(ctx, *preview)
+		if err != nil {
+			return err
+		}
+		return repo.NewEventRepo(tx).InsertEvent(ctx, events.ExpirePreview{Token: token, ExpireAt: preview.ExpiresAt})
+	})
+
+	return preview, err
+}
+
+// progress is saved in its own transaction, so it's visible while the build is running
+func (c *RebuildSite) progress(ctx context.Context, build *db.SiteBuild, step string) error {
+	build.Status = consts.BuildStatusRunning
+	build.Step = step
+	build.UpdatedAt = time.Now()
+	return c.uowFactory.RunInTx(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		buildRepo := repo.NewSiteBuildRepo(tx)
+		current, err := buildRepo.GetBuild(ctx, build.ID)
+		if err != nil {
+			return fmt.Errorf("err getting site build, %v", err)
+		}
+		if current.CancelRequested {
+			build.CancelRequested = true
+			return errBuildCancelled
+		}
+		return buildRepo.UpdateBuild(ctx, *build)
+	})
+}
+
+// watchCancel cancels the build, when its cancel is requested
+func (c *RebuildSite) watchCancel(ctx context.Context, id uuid.UUID, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(cancelCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			var requested bool
+			err := c.uowFactory.RunInReadOnlyTx(ctx, func(ctx context.Context, tx pgx.Tx) error {
+				build, err := repo.NewSiteBuildRepo(tx).GetBuild(ctx, id)
+				if err != nil {
+					return err
+				}
+				requested = build.CancelRequested
+				return nil
+			})
+			if err != nil {
+				slog.WarnContext(ctx, "err checking cancel of site build", "jobID", id, "err", err)
+				continue
+			}
+			if requested {
+				slog.InfoContext(ctx, "cancelling site build", "jobID", id)
+				cancel(errBuildCancelled)
+				return
+			}
+		}
+	}
+}
+
+func (c *RebuildSite) succeed(ctx context.Context, tx pgx.Tx, build *db.SiteBuild) error {
+	now := time.Now()
+	build.Status = consts.BuildStatusSucceeded
+	build.Step = ""
+	build.UpdatedAt = now
+	build.FinishedAt = &now
+	err := repo.NewSiteBuildRepo(tx).UpdateBuild(ctx, *build)
+	if err != nil {
+		return err
+	}
+	slog.InfoContext(ctx, "site build succeeded", "jobID", build.ID, "siteID", build.SiteID, "kind", build.Kind)
+
+	return nil
+}
+
+// requeue returns a build, that didn't get a worker, to the queue, the attempt isn't counted
+func (c *RebuildSite) requeue(ctx context.Context, build *db.SiteBuild, event events.RebuildSite, buildErr error) error {
+	build.Status = consts.BuildStatusQueued
+	build.Step = ""
+	build.Attempts = event.Attempt - 1
+	build.UpdatedAt = time.Now()
+	slog.InfoContext(ctx, "build queue is full, site build is deferred", "jobID", build.ID)
+
+	err := c.uowFactory.RunInTx(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		return repo.NewSiteBuildRepo(tx).UpdateBuild(ctx, *build)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "err requeueing site build", "jobID", build.ID, "err", err)
+	}
+
+	return errs.DeferredError{Err: buildErr, Delay: queueFullDelay}
+}
+
+// fail saves the error and the output of an attempt, build is Queued again, unless the event won't be retried
+func (c *RebuildSite) fail(ctx context.Context, build *db.SiteBuild, buildErr error, cancelled bool) error {
+	now := time.Now()
+	build.Error = buildErr.Error()
+	build.UpdatedAt = now
+	build.Status = consts.BuildStatusQueued
+	var p errs.PermanentError
+	switch {
+	case cancelled:
+		build.Status = consts.BuildStatusCancelled
+		build.FinishedAt = &now
+	case rebuildRetryPolicy.Exhausted(build.Attempts) || errors.As(buildErr, &p):
+		build.Status = consts.BuildStatusFailed
+		build.FinishedAt = &now
+	}
+	slog.WarnContext(ctx, "site build attempt failed", "jobID", build.ID, "status", build.Status, "attempts", build.Attempts,
+		"err", buildErr)
+
+	return c.uowFactory.RunInTx(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		buildRepo := repo.NewSiteBuildRepo(tx)
+		err := buildRepo.UpdateBuild(ctx, *build)
+		if err != nil {
+			return err
+		}
+		var buildErrWithLog infrabuild.Error
+		if errors.As(buildErr, &buildErrWithLog) {
+			return buildRepo.SaveBuildLog(ctx, build.ID, buildErrWithLog.Log)
+		}
+		return nil
+	})
+}
@@ -10,47 +10,62 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/mail"
 	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
 	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+	"github.com/google/uuid"
 )
 
 type SendMail struct {
-	server     *mail.MailServer
+	server     mail.Sender
 	uowFactory *dbs.UOWFactory
 }
 
-func NewSendMail(server *mail.MailServer, uowFactory *dbs.UOWFactory) *SendMail {
+func NewSendMail(server mail.Sender, uowFactory *dbs.UOWFactory) *SendMail {
 	return &SendMail{server: server, uowFactory: uowFactory}
 }
 
+func (c *SendMail) Register(registry *events.Registry) {
+	events.Register(registry, c.Handle, nil, events.RetryPolicy{
+		MaxAttempts:  8,
+		InitialDelay: 10 * time.Second,
+		MaxDelay:     30 * time.Minute,
+		Multiplier:   3,
+	})
+}
+
 func (c *SendMail) Handle(ctx context.Context, event events.SendMail) (shared.UoW, error) {
 	mailData, err := mapToMailData(event)
 	if err != nil {
 		return nil, err
 	}
 	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
-	var email string
-	err = tx.QueryRow(ctx, "SELECT email FROM builder.users WHERE id = $1", event.UserID).Scan(&email)
+	userID, err := uuid.Parse(event.UserID)
+	if err != nil {
+		return uow, errs.PermanentError{Err: fmt.Errorf("err parsing user id, %v", err)}
+	}
+	user, err := repo.NewUserRepo(tx).GetUser(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("err getting user email, %v", err)
+		return uow, fmt.Errorf("err getting user email, %v", err)
 	}
 	recipients := make([]string, 0)
-	recipients = append(recipients, email)
+	recipients = append(recipients, user.Email)
 
-	var mailTemplate string
-	err = tx.QueryRow(ctx, "SELECT content FROM builder.mail_templates WHERE type = $1", mailData.GetMailType()).Scan(&mailTemplate)
+	mailRepo := repo.NewMailRepo(tx)
+	mailTemplate, err := mailRepo.GetMailTemplate(ctx, string(mailData.GetMailType()))
 	if err != nil {
 		return uow, fmt.Errorf("err getting content for template, %v", err)
 	}
 
-	htmlBody, err := renderHTML(mailTemplate, mailData)
+	htmlBody, err := renderHTML(mailTemplate.Content, mailData)
 	if err != nil {
 		return uow, fmt.Errorf("error rendering html, %v", err)
 	}
@@ -62,9 +77,7 @@ func (c *SendMail) Handle(ctx context.Context, event events.SendMail) (shared.Uo
 		Content:    htmlBody,
 		SentAt:     time.Now(),
 	}
-	_, err = tx.Exec(ctx, "INSERT INTO builder.mails(type, recipients, subject, content, sent_at) VALUES ($1,$2,$3,$4,$5)",
-		createdMail.MailType, createdMail.Recipients, createdMail.Subject, createdMail.Content, createdMail.SentAt,
-	)
+	createdMail.ID, err = mailRepo.InsertMail(ctx, createdMail)
 	if err != nil {
 		return uow, fmt.Errorf("err inserting mail in db, %v", err)
 	}
@@ -73,7 +86,7 @@ func (c *SendMail) Handle(ctx context.Context, event events.SendMail) (shared.Uo
 		return uow, err
 	}
 
-	slog.Info("mail sent", "id", createdMail.ID)
+	slog.InfoContext(ctx, "mail sent", "id", createdMail.ID)
 
 	return uow, nil
 }
@@ -111,6 +124,7 @@ var mailDataRegistry = map[string]func() mail.MailData{
 	mail.FreeTrialEndsData{}.GetSubject():       func() mail.MailData { return &mail.FreeTrialEndsData{} },
 	mail.SiteCreatedData{}.GetSubject():         func() mail.MailData { return &mail.SiteCreatedData{} },
 	mail.SiteDeactivatedData{}.GetSubject():     func() mail.MailData { return &mail.SiteDeactivatedData{} },
+	mail.SiteReactivatedData{}.GetSubject():     func() mail.MailData { return &mail.SiteReactivatedData{} },
 	mail.RegistrationConfirmData{}.GetSubject(): func() mail.MailData { return &mail.RegistrationConfirmData{} },
 	mail.RegistrationSuccessData{}.GetSubject(): func() mail.MailData { return &mail.RegistrationSuccessData{} },
 }
@@ -0,0 +1,40 @@
+package publishing
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// maxInvalidationPaths - above it a single wildcard is cheaper, than invalidating every path
+const maxInvalidationPaths = 30
+
+// InvalidationPaths maps changed keys of a build to paths of the distribution. A page is requested by its
+// directory, so an index.html also invalidates the directory with and without the trailing slash
+func InvalidationPaths(changed []string) []string {
+	unique := make(map[string]bool)
+	for _, key := range changed {
+		unique["/"+key] = true
+		if path.Base(key) != "index.html" {
+			continue
+		}
+		dir := strings.TrimSuffix(key, "index.html")
+		if dir == "" {
+			unique["/"] = true
+			continue
+		}
+		unique["/"+dir] = true
+		unique["/"+strings.TrimSuffix(dir, "/")] = true
+	}
+	if len(unique) > maxInvalidationPaths {
+		return []string{"/*"}
+	}
+
+	paths := make([]string, 0, len(unique))
+	for p := range unique {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
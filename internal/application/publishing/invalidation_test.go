@@ -0,0 +1,24 @@
+package publishing_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/publishing"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_InvalidationPaths_When_Pages_Changed_Then_Invalidate_Their_Directories(t *testing.T) {
+	paths := publishing.InvalidationPaths([]string{"index.html", "about/index.html", "_astro/main.1a2b.css"})
+
+	require.Equal(t, []string{"/", "/_astro/main.1a2b.css", "/about", "/about/", "/about/index.html", "/index.html"}, paths)
+}
+
+func Test_InvalidationPaths_When_Too_Many_Changed_Then_Invalidate_Everything(t *testing.T) {
+	changed := make([]string, 0, 40)
+	for i := range 40 {
+		changed = append(changed, fmt.Sprintf("img/%d.png", i))
+	}
+
+	require.Equal(t, []string{"/*"}, publishing.InvalidationPaths(changed))
+}
@@ -0,0 +1,197 @@
+package publishing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/lifecycle"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/build"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/jackc/pgx/v5"
+)
+
+// Publisher builds site's fields and switches site's distribution between published versions
+type Publisher struct {
+	repos         interfaces.Repositories
+	templateBuild *build.TemplateBuild
+	cdn           dns.CDNProvider
+	storage       *storage.Storage
+	lifecycle     *lifecycle.SiteLifecycle
+	cfg           config.ProvisionConfig
+}
+
+func NewPublisher(repos interfaces.Repositories, templateBuild *build.TemplateBuild, cdn dns.CDNProvider, storage *storage.Storage,
+	cfg config.ProvisionConfig,
+) *Publisher {
+	return &Publisher{repos: repos, templateBuild: templateBuild, cdn: cdn, storage: storage,
+		lifecycle: lifecycle.NewSiteLifecycle(repos), cfg: cfg}
+}
+
+// cacheMarker - written last under a cache prefix, output without it is incomplete and is built again
+const cacheMarker = ".complete"
+
+// Build uploads a build of the template with passed fields and its pages.json under prefix.
+// Output is cached by template's sources and fields, cached output is copied inside the bucket without building
+func (p *Publisher) Build(ctx context.Context, prefix, templateName string, fields json.RawMessage) error {
+	sourceHash, err := p.templateBuild.SourceHash(ctx, templateName)
+	if err != nil {
+		return err
+	}
+	key, err := build.CacheKey(sourceHash, fields)
+	if err != nil {
+		return err
+	}
+	cached, err := p.storage.ObjectExists(ctx, storage.BuildCachePrefix(key)+"/"+cacheMarker)
+	if err != nil {
+		return err
+	}
+	if cached {
+		slog.InfoContext(ctx, "build cache hit", "key", key, "prefix", prefix)
+	} else {
+		key, err = p.buildToCache(ctx, templateName, fields)
+		if err != nil {
+			return err
+		}
+	}
+
+	changed, err := p.storage.SyncPrefix(ctx, storage.BuildCachePrefix(key), prefix, func(key string) bool {
+		return path.Base(key) == cacheMarker
+	})
+	if err != nil {
+		return fmt.Errorf("err copying cached build, %v", err)
+	}
+	slog.InfoContext(ctx, "copied build", "key", key, "prefix", prefix, "changed", len(changed))
+
+	return nil
+}
+
+// buildToCache builds in a workspace and uploads output under the key of the sources, that were copied to it
+func (p *Publisher) buildToCache(ctx context.Context, templateName string, fields json.RawMessage) (string, error) {
+	ws, err := p.templateBuild.NewWorkspace(ctx, templateName)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := ws.Remove(); err != nil {
+			slog.ErrorContext(ctx, "err removing build workspace", "path", ws.Path, "err", err)
+		}
+	}()
+	key, err := build.CacheKey(ws.SourceHash, fields)
+	if err != nil {
+		return "", err
+	}
+	cachePrefix := storage.BuildCachePrefix(key)
+
+	customizeJsonPath := filepath.Join(ws.Path+p.cfg.PathToFile, p.cfg.Filename)
+	err = saveFieldsToFile(fields, customizeJsonPath)
+	if err != nil {
+		return "", err
+	}
+
+	slog.InfoContext(ctx, "Building", "key", key)
+	buildPath, err := p.templateBuild.BuildWorkspace(ctx, ws)
+	if err != nil {
+		return "", fmt.Errorf("err building site, %w", err)
+	}
+	// sync removes leftovers of an interrupted build, including its marker, so structure file is uploaded after it
+	_, err = p.templateBuild.Sync(ctx, cachePrefix, buildPath, nil)
+	if err != nil {
+		return "", err
+	}
+	structureFile, err := os.Open(customizeJsonPath)
+	if err != nil {
+		return "", fmt.Errorf("err reading site structure file, %v", err)
+	}
+	defer structureFile.Close()
+	_, err = p.storage.UploadFile(ctx, cachePrefix+"/"+p.cfg.Filename, aws.String("application/json"), structureFile)
+	if err != nil {
+		return "", fmt.Errorf("err uploading structure file, %v", err)
+	}
+	_, err = p.storage.UploadFile(ctx, cachePrefix+"/"+cacheMarker, aws.String("text/plain"), strings.NewReader(ws.SourceHash))
+	if err != nil {
+		return "", fmt.Errorf("err marking cached build, %v", err)
+	}
+
+	return key, nil
+}
+
+// SwitchVersion saves the change before updating the distribution, so a failed update rolls it back
+func (p *Publisher) SwitchVersion(ctx context.Context, tx pgx.Tx, site *db.Site, provision *db.Provision,
+	version *db.SiteVersion, params lifecycle.TransitionParams,
+) error {
+	err := p.repos.Sites(tx).UpdateSitePublishedVersion(ctx, site.ID, version.Version)
+	if err != nil {
+		return err
+	}
+	err = p.repos.Provisions(tx).UpdateProvisionStructure(ctx, site.ID, p.storage.GetFileURL(version.Prefix+"/"+p.cfg.Filename))
+	if err != nil {
+		return err
+	}
+	err = p.lifecycle.RecordVersionChange(ctx, tx, site.ID, site.PublishedVersion, version.Version, params)
+	if err != nil {
+		return err
+	}
+
+	err = p.cdn.SetOriginPath(ctx, provision.CloudfrontID, "/"+version.Prefix)
+	if err != nil {
+		return fmt.Errorf("err switching cf distribution to version %v, %v", version.Version, err)
+	}
+	err = p.cdn.InvalidatePaths(ctx, provision.CloudfrontID, p.changedPaths(ctx, tx, site, version))
+	if err != nil {
+		return fmt.Errorf("err invalidating cf distribution, %v", err)
+	}
+	slog.InfoContext(ctx, "site version published", "siteID", site.ID, "from", site.PublishedVersion, "to", version.Version)
+
+	return nil
+}
+
+// changedPaths - paths of the distribution, that differ between the published version and the new one.
+// When they can't be compared, everything is invalidated
+func (p *Publisher) changedPaths(ctx context.Context, tx pgx.Tx, site *db.Site, version *db.SiteVersion) []string {
+	if site.PublishedVersion == 0 {
+		return []string{"/*"}
+	}
+	published, err := p.repos.SiteVersions(tx).GetSiteVersion(ctx, site.ID, site.PublishedVersion)
+	if err != nil {
+		slog.WarnContext(ctx, "err getting published version, invalidating all paths", "siteID", site.ID, "err", err)
+		return []string{"/*"}
+	}
+	changed, err := p.storage.DiffPrefixes(ctx, published.Prefix, version.Prefix)
+	if err != nil {
+		slog.WarnContext(ctx, "err comparing versions, invalidating all paths", "siteID", site.ID, "err", err)
+		return []string{"/*"}
+	}
+
+	return InvalidationPaths(changed)
+}
+
+func saveFieldsToFile(fields json.RawMessage, path string) error {
+	jsonBytes, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	slog.Info("saving fields to file", "fields", string(jsonBytes), "path", path)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("mkdir: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, jsonBytes, 0o644); err != nil {
+		return fmt.Errorf("write tmp: %w", err)
+	}
+
+	return os.Rename(tmp, path)
+}
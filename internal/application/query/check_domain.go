@@ -7,15 +7,15 @@ import (
 )
 
 type CheckDomain struct {
-	dnsProvisioner *dns.DNSProvisioner
+	registrar dns.DomainRegistrar
 }
 
-func NewCheckDomain(dns *dns.DNSProvisioner) *CheckDomain {
+func NewCheckDomain(registrar dns.DomainRegistrar) *CheckDomain {
 	return &CheckDomain{
-		dns,
+		registrar,
 	}
 }
 
 func (c *CheckDomain) Query(ctx context.Context, domain string) (bool, error) {
-	return c.dnsProvisioner.CheckAvailability(ctx, domain)
+	return c.registrar.CheckAvailability(ctx, domain)
 }
@@ -0,0 +1,169 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const outboxColumns = "id, event, status, payload, attempts, next_attempt_at, last_error, locked_by, locked_until, correlation_id, created_at"
+
+type GetOutboxEvent struct {
+	uowFactory *dbs.UOWFactory
+}
+
+func NewGetOutboxEvent(uowFactory *dbs.UOWFactory) *GetOutboxEvent {
+	return &GetOutboxEvent{uowFactory: uowFactory}
+}
+
+func (c *GetOutboxEvent) Query(ctx context.Context, id uint64, identity *auth.Identity) (*dto.OutboxEvent, error) {
+	if !identity.IsAdmin {
+		return nil, errs.PermissionsError{Err: fmt.Errorf("only admins can view events")}
+	}
+
+	uow := c.uowFactory.GetReadOnlyUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer uow.Finalize(&err)
+
+	outbox, err := scanOutbox(tx.QueryRow(ctx, "SELECT "+outboxColumns+" FROM builder.outbox WHERE id = $1", id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = errs.NotFoundError{Err: fmt.Errorf("event %v doesn't exist", id)}
+			return nil, err
+		}
+		return nil, fmt.Errorf("err getting event, %v", err)
+	}
+
+	event := mapOutboxToDto(outbox)
+	return &event, nil
+}
+
+// QueryList returns events matching the filters, newest first
+func (c *GetOutboxEvent) QueryList(ctx context.Context, params *dto.ListOutboxEventsParams, identity *auth.Identity) (*dto.OutboxEventList, error) {
+	if !identity.IsAdmin {
+		return nil, errs.PermissionsError{Err: fmt.Errorf("only admins can view events")}
+	}
+
+	page := 0
+	size := 20
+	if params.Page != nil && *params.Page >= 0 {
+		page = *params.Page
+	}
+	if params.Size != nil && *params.Size > 0 && *params.Size <= 100 {
+		size = *params.Size
+	}
+
+	var filters []any
+	where := "WHERE true"
+	if params.Event != nil {
+		filters = append(filters, *params.Event)
+		where += fmt.Sprintf(" AND event = $%d", len(filters))
+	}
+	if params.Status != nil {
+		status, ok := consts.ParseOutboxStatus(string(*params.Status))
+		if !ok {
+			return nil, errs.ValidationError{Err: fmt.Errorf("unknown event status %v", *params.Status)}
+		}
+		filters = append(filters, status)
+		where += fmt.Sprintf(" AND status = $%d", len(filters))
+	}
+	if params.SiteID != nil {
+		filters = append(filters, strconv.FormatUint(*params.SiteID, 10))
+		where += fmt.Sprintf(" AND payload->>'SiteID' = $%d", len(filters))
+	}
+	if params.CorrelationID != nil {
+		filters = append(filters, *params.CorrelationID)
+		where += fmt.Sprintf(" AND correlation_id = $%d", len(filters))
+	}
+
+	uow := c.uowFactory.GetReadOnlyUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer uow.Finalize(&err)
+
+	var total int
+	err = tx.QueryRow(ctx, "SELECT count(*) FROM builder.outbox "+where, filters...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("err counting events, %v", err)
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM builder.outbox %s ORDER BY id DESC LIMIT $%d OFFSET $%d",
+		outboxColumns, where, len(filters)+1, len(filters)+2)
+	rows, err := tx.Query(ctx, query, append(filters, size, page*size)...)
+	if err != nil {
+		return nil, fmt.Errorf("err listing events, %v", err)
+	}
+	defer rows.Close()
+
+	list := make([]dto.OutboxEvent, 0, size)
+	for rows.Next() {
+		var outbox *db.Outbox
+		outbox, err = scanOutbox(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, mapOutboxToDto(outbox))
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &dto.OutboxEventList{
+		Elements: list,
+		Page:     page,
+		Total:    total,
+		HasNext:  (page+1)*size < total,
+	}, nil
+}
+
+func scanOutbox(row pgx.Row) (*db.Outbox, error) {
+	var outbox db.Outbox
+	err := row.Scan(&outbox.ID, &outbox.Event, &outbox.Status, &outbox.Payload, &outbox.Attempts,
+		&outbox.NextAttemptAt, &outbox.LastError, &outbox.LockedBy, &outbox.LockedUntil, &outbox.CorrelationID, &outbox.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &outbox, nil
+}
+
+func mapOutboxToDto(outbox *db.Outbox) dto.OutboxEvent {
+	event := dto.OutboxEvent{
+		Id:            outbox.ID,
+		Event:         outbox.Event,
+		Status:        dto.OutboxEventStatus(consts.OutboxStatus(outbox.Status).String()),
+		Attempts:      outbox.Attempts,
+		NextAttemptAt: outbox.NextAttemptAt,
+		CreatedAt:     outbox.CreatedAt,
+	}
+	// payload, which isn't a json object, is shown as a string
+	if err := json.Unmarshal(outbox.Payload, &event.Payload); err != nil {
+		event.Payload = map[string]interface{}{"raw": string(outbox.Payload)}
+	}
+	if outbox.LastError.Valid {
+		event.LastError = &outbox.LastError.String
+	}
+	if outbox.LockedBy.Valid {
+		event.LockedBy = &outbox.LockedBy.String
+	}
+	if outbox.CorrelationID.Valid {
+		event.CorrelationID = &outbox.CorrelationID.String
+	}
+
+	return event
+}
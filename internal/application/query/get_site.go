@@ -2,6 +2,8 @@ package query
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -16,75 +18,91 @@ import (
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
 	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	"github.com/jackc/pgx/v5"
 )
 
 type GetSite struct {
-	cfg            config.ProvisionConfig
-	uowFactory     *dbs.UOWFactory
-	dnsProvisioner *dns.DNSProvisioner
-	client         http.Client
+	cfg        config.ProvisionConfig
+	uowFactory *dbs.UOWFactory
+	cdn        dns.CDNProvider
+	client     http.Client
 }
 
 func NewGetSite(
-	cfg config.ProvisionConfig, factory *dbs.UOWFactory, dns *dns.DNSProvisioner,
+	cfg config.ProvisionConfig, factory *dbs.UOWFactory, cdn dns.CDNProvider,
 ) *GetSite {
 	return &GetSite{
 		cfg,
 		factory,
-		dns,
+		cdn,
 		http.Client{Timeout: 4 * time.Second},
 	}
 }
 
 func (c *GetSite) Query(ctx context.Context, siteIDParam uint64, identity *auth.Identity) (*dto.GetSiteResponse, error) {
 	siteID := strconv.FormatUint(siteIDParam, 10)
-	var site db.Site
+	var site *db.Site
 
-	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
-	if err != nil {
-		return nil, err
-	}
-	err = tx.QueryRow(ctx, "SELECT creator_id, template_id, status, fields from builder.sites WHERE id = $1", siteID).Scan(
-		&site.CreatorID,
-		&site.TemplateID,
-		&site.Status,
-		&site.Fields,
-	)
+	var provision *db.Provision
+	err := c.uowFactory.RunInReadOnlyTx(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		var err error
+		site, err = repo.NewSiteRepo(tx).GetSite(ctx, siteIDParam)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errs.NotFoundError{Err: fmt.Errorf("site %v doesn't exist", siteIDParam)}
+			}
+			return fmt.Errorf("err getting site, %v", err)
+		}
+
+		if identity.UserID != site.CreatorID {
+			return errs.PermissionsError{Err: fmt.Errorf("user %v is not a creator of site %v", identity.UserID, siteIDParam)}
+		}
+
+		provisionRepo := repo.NewProvisionRepo(tx)
+		provision, err = provisionRepo.GetProvisionByID(ctx, siteIDParam)
+		if err != nil {
+			slog.ErrorContext(ctx, "site is not provisioned yet", "siteID", siteID)
+			provision = nil
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if identity.UserID != site.CreatorID {
-		return nil, errs.PermissionsError{Err: fmt.Errorf("user requesting site info, is not site's creator")}
-	}
-
+	// transaction is released before the health check, so the site's response time doesn't hold a connection
 	response := dto.GetSiteResponse{
 		HealthCheckStatus: dto.Healthy,
 		CreatedAt:         site.CreatedAt.String(),
 	}
-
-	provisionRepo := repo.NewProvisionRepo(tx)
-	provision, err := provisionRepo.GetProvisionByID(ctx, siteIDParam)
-	if err != nil {
-		slog.Error("site is not provisioned yet", "siteID", site)
+	if len(site.Fields) > 0 {
+		var draft []map[string]interface{}
+		if err = json.Unmarshal(site.Fields, &draft); err != nil {
+			return nil, fmt.Errorf("err reading site's draft, %v", err)
+		}
+		response.Draft = &draft
+	}
+	if site.PublishedVersion != 0 {
+		response.PublishedVersion = &site.PublishedVersion
+	}
+	if provision == nil {
 		response.HealthCheckStatus = dto.NotProvisioned
 		return &response, nil
 	}
 	response.Structure = provision.StructurePath
 	req, err := http.NewRequestWithContext(ctx, "GET", "https://"+provision.Domain, http.NoBody)
 	if err != nil {
-		slog.Error("error creating request to provisioned site", "siteID", siteID)
+		slog.ErrorContext(ctx, "error creating request to provisioned site", "siteID", siteID)
 		return &response, nil
 	}
 	resp, err := c.client.Do(req)
 	if err != nil {
-		slog.Error("site is unreachable", "siteID", siteID)
+		slog.ErrorContext(ctx, "site is unreachable", "siteID", siteID)
 		response.HealthCheckStatus = dto.Unhealthy
 		return &response, nil
 	}
 	if resp.StatusCode != 200 {
-		slog.Error("error response status from site", "siteID", siteID)
+		slog.ErrorContext(ctx, "error response status from site", "siteID", siteID)
 		response.HealthCheckStatus = dto.Unhealthy
 		return &response, nil
 	}
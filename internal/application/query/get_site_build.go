@@ -0,0 +1,111 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type GetSiteBuild struct {
+	uowFactory *dbs.UOWFactory
+}
+
+func NewGetSiteBuild(uowFactory *dbs.UOWFactory) *GetSiteBuild {
+	return &GetSiteBuild{uowFactory: uowFactory}
+}
+
+func (c *GetSiteBuild) Query(ctx context.Context, siteID uint64, jobID uuid.UUID, identity *auth.Identity) (*dto.SiteBuild, error) {
+	uow := c.uowFactory.GetReadOnlyUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer uow.Finalize(&err)
+
+	build, err := getSiteBuild(ctx, tx, siteID, jobID, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.SiteBuild{
+		JobId:      build.ID,
+		Kind:       dto.SiteBuildKind(build.Kind),
+		Status:     dto.SiteBuildStatus(build.Status),
+		Attempts:   build.Attempts,
+		CreatedAt:  build.CreatedAt,
+		UpdatedAt:  build.UpdatedAt,
+		FinishedAt: build.FinishedAt,
+	}
+	if build.Step != "" {
+		resp.Step = &build.Step
+	}
+	if build.Error != "" {
+		resp.Error = &build.Error
+	}
+	if build.Version != 0 {
+		resp.Version = &build.Version
+	}
+	if build.PreviewToken != "" {
+		resp.PreviewToken = &build.PreviewToken
+	}
+
+	return resp, nil
+}
+
+// QueryLog returns output of the last failed attempt of a build
+func (c *GetSiteBuild) QueryLog(ctx context.Context, siteID uint64, jobID uuid.UUID, identity *auth.Identity) (string, error) {
+	uow := c.uowFactory.GetReadOnlyUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer uow.Finalize(&err)
+
+	_, err = getSiteBuild(ctx, tx, siteID, jobID, identity)
+	if err != nil {
+		return "", err
+	}
+	log, err := repo.NewSiteBuildRepo(tx).GetBuildLog(ctx, jobID)
+	if err != nil {
+		return "", fmt.Errorf("err getting site build log, %v", err)
+	}
+	if log == "" {
+		err = errs.NotFoundError{Err: fmt.Errorf("build %v has no log", jobID)}
+		return "", err
+	}
+
+	return log, nil
+}
+
+// getSiteBuild - builds are available to site's creator and admins
+func getSiteBuild(ctx context.Context, tx pgx.Tx, siteID uint64, jobID uuid.UUID, identity *auth.Identity) (*db.SiteBuild, error) {
+	site, err := repo.NewSiteRepo(tx).GetSite(ctx, siteID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errs.NotFoundError{Err: fmt.Errorf("site %v doesn't exist", siteID)}
+		}
+		return nil, fmt.Errorf("err getting site, %v", err)
+	}
+	if site.CreatorID != identity.UserID && !identity.IsAdmin {
+		return nil, errs.PermissionsError{Err: fmt.Errorf("user requesting site build, is not site's creator")}
+	}
+
+	build, err := repo.NewSiteBuildRepo(tx).GetBuild(ctx, jobID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("err getting site build, %v", err)
+	}
+	if build == nil || build.SiteID != siteID {
+		return nil, errs.NotFoundError{Err: fmt.Errorf("site %v has no build %v", siteID, jobID)}
+	}
+
+	return build, nil
+}
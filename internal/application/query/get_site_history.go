@@ -0,0 +1,79 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	"github.com/jackc/pgx/v5"
+)
+
+type GetSiteHistory struct {
+	uowFactory *dbs.UOWFactory
+}
+
+func NewGetSiteHistory(uowFactory *dbs.UOWFactory) *GetSiteHistory {
+	return &GetSiteHistory{uowFactory: uowFactory}
+}
+
+func (c *GetSiteHistory) Query(ctx context.Context, siteID uint64, identity *auth.Identity) (*dto.SiteHistory, error) {
+	uow := c.uowFactory.GetReadOnlyUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer uow.Finalize(&err)
+
+	site, err := repo.NewSiteRepo(tx).GetSite(ctx, siteID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = errs.NotFoundError{Err: fmt.Errorf("site %v doesn't exist", siteID)}
+			return nil, err
+		}
+		return nil, fmt.Errorf("err getting site, %v", err)
+	}
+	if site.CreatorID != identity.UserID && !identity.IsAdmin {
+		err = errs.PermissionsError{Err: fmt.Errorf("user requesting site history, is not site's creator")}
+		return nil, err
+	}
+
+	history, err := repo.NewSiteEventRepo(tx).ListSiteEvents(ctx, siteID)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make([]dto.SiteHistoryEntry, 0, len(history))
+	for _, event := range history {
+		elements = append(elements, mapSiteEventToDto(event))
+	}
+
+	return &dto.SiteHistory{Elements: elements}, nil
+}
+
+func mapSiteEventToDto(event db.SiteEvent) dto.SiteHistoryEntry {
+	entry := dto.SiteHistoryEntry{
+		Id:        event.ID,
+		Kind:      dto.SiteHistoryEntryKind(event.Kind),
+		ToStatus:  event.ToStatus,
+		Actor:     dto.SiteHistoryEntryActor(event.Actor),
+		ActorID:   event.ActorID,
+		CreatedAt: event.CreatedAt,
+	}
+	if event.FromStatus != "" {
+		entry.FromStatus = &event.FromStatus
+	}
+	if event.Reason != "" {
+		entry.Reason = &event.Reason
+	}
+	if event.CorrelationID != "" {
+		entry.CorrelationID = &event.CorrelationID
+	}
+
+	return entry
+}
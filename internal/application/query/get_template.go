@@ -24,8 +24,8 @@ func NewGetTemplate(uowFactory *db.UOWFactory, storage *storage.Storage, provisi
 
 func (c *GetTemplate) Query(ctx context.Context, templateID uint16) (*dto.TemplateInfo, error) {
 
-	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	uow := c.uowFactory.GetReadOnlyUoW()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -68,8 +68,8 @@ func (c *GetTemplate) QueryList(ctx context.Context, req *dto.ListTemplatePagina
 	}
 	offset := page * size
 
-	uow := c.uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	uow := c.uowFactory.GetReadOnlyUoW()
+	tx, err := uow.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,62 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	"github.com/jackc/pgx/v5"
+)
+
+type ListSitePreviews struct {
+	uowFactory *dbs.UOWFactory
+}
+
+func NewListSitePreviews(uowFactory *dbs.UOWFactory) *ListSitePreviews {
+	return &ListSitePreviews{uowFactory: uowFactory}
+}
+
+func (c *ListSitePreviews) Query(ctx context.Context, siteID uint64, identity *auth.Identity) (*dto.SitePreviewList, error) {
+	uow := c.uowFactory.GetReadOnlyUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer uow.Finalize(&err)
+
+	site, err := repo.NewSiteRepo(tx).GetSite(ctx, siteID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = errs.NotFoundError{Err: fmt.Errorf("site %v doesn't exist", siteID)}
+			return nil, err
+		}
+		return nil, fmt.Errorf("err getting site, %v", err)
+	}
+	if site.CreatorID != identity.UserID && !identity.IsAdmin {
+		err = errs.PermissionsError{Err: fmt.Errorf("user requesting site previews, is not site's creator")}
+		return nil, err
+	}
+
+	previews, err := repo.NewSitePreviewRepo(tx).ListPreviews(ctx, siteID)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make([]dto.SitePreview, 0, len(previews))
+	for _, preview := range previews {
+		elements = append(elements, dto.SitePreview{
+			Token:     preview.Token,
+			Url:       "https://" + preview.Domain,
+			Status:    dto.SitePreviewStatus(preview.Status),
+			CreatedAt: preview.CreatedAt,
+			ExpiresAt: preview.ExpiresAt,
+		})
+	}
+
+	return &dto.SitePreviewList{Elements: elements}, nil
+}
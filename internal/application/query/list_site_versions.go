@@ -0,0 +1,61 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	"github.com/jackc/pgx/v5"
+)
+
+type ListSiteVersions struct {
+	uowFactory *dbs.UOWFactory
+}
+
+func NewListSiteVersions(uowFactory *dbs.UOWFactory) *ListSiteVersions {
+	return &ListSiteVersions{uowFactory: uowFactory}
+}
+
+func (c *ListSiteVersions) Query(ctx context.Context, siteID uint64, identity *auth.Identity) (*dto.SiteVersionList, error) {
+	uow := c.uowFactory.GetReadOnlyUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer uow.Finalize(&err)
+
+	site, err := repo.NewSiteRepo(tx).GetSite(ctx, siteID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = errs.NotFoundError{Err: fmt.Errorf("site %v doesn't exist", siteID)}
+			return nil, err
+		}
+		return nil, fmt.Errorf("err getting site, %v", err)
+	}
+	if site.CreatorID != identity.UserID && !identity.IsAdmin {
+		err = errs.PermissionsError{Err: fmt.Errorf("user requesting site versions, is not site's creator")}
+		return nil, err
+	}
+
+	versions, err := repo.NewSiteVersionRepo(tx).ListSiteVersions(ctx, siteID)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := make([]dto.SiteVersion, 0, len(versions))
+	for _, version := range versions {
+		elements = append(elements, dto.SiteVersion{
+			Version:   version.Version,
+			Published: version.Version == site.PublishedVersion,
+			CreatedBy: version.CreatedBy,
+			CreatedAt: version.CreatedAt,
+		})
+	}
+
+	return &dto.SiteVersionList{Elements: elements}, nil
+}
@@ -0,0 +1,72 @@
+package query
+
+import (
+	"context"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+)
+
+type ListSites struct {
+	uowFactory *dbs.UOWFactory
+}
+
+func NewListSites(uowFactory *dbs.UOWFactory) *ListSites {
+	return &ListSites{uowFactory: uowFactory}
+}
+
+// Query returns only sites, created by the user
+func (c *ListSites) Query(ctx context.Context, params *dto.ListSitesParams, identity *auth.Identity) (*dto.SiteList, error) {
+	page := 0
+	size := 20
+	if params.Page != nil && *params.Page >= 0 {
+		page = *params.Page
+	}
+	if params.Size != nil && *params.Size > 0 && *params.Size <= 100 {
+		size = *params.Size
+	}
+	filter := interfaces.SiteFilter{CreatorID: identity.UserID}
+	if params.Status != nil {
+		filter.Status = consts.SiteStatus(*params.Status)
+	}
+
+	uow := c.uowFactory.GetReadOnlyUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer uow.Finalize(&err)
+
+	siteRepo := repo.NewSiteRepo(tx)
+	total, err := siteRepo.CountSites(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	sites, err := siteRepo.ListSites(ctx, filter, size, page*size)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]dto.SiteSummary, 0, len(sites))
+	for _, site := range sites {
+		list = append(list, dto.SiteSummary{
+			Id:         site.ID,
+			TemplateID: site.TemplateID,
+			PlanID:     site.PlanID,
+			Status:     dto.SiteStatus(site.Status),
+			CreatedAt:  site.CreatedAt,
+			UpdatedAt:  site.UpdatedAt,
+		})
+	}
+
+	return &dto.SiteList{
+		Elements: list,
+		Page:     page,
+		Total:    total,
+		HasNext:  (page+1)*size < total,
+	}, nil
+}
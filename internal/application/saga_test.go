@@ -0,0 +1,290 @@
+package application_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/build"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	dnsFake "github.com/Builder-Lawyers/builder-backend/internal/infra/dns/fake"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/mail"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
+	"github.com/Builder-Lawyers/builder-backend/internal/testinfra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SiteSaga_When_Site_Is_Provisioned_And_Deactivated_Then_Every_Step_Is_Applied(t *testing.T) {
+	ctx := context.Background()
+	h := testinfra.NewHarness(t)
+	user := h.NewUser(t)
+	identity := &auth.Identity{UserID: user.ID}
+	fields := []map[string]interface{}{{"title": "Acme Law"}}
+
+	// created - only a draft, nothing is emitted
+	siteID, err := h.CreateSite.Execute(ctx, &dto.CreateSiteRequest{TemplateID: 1, PlanID: 1, Fields: &fields}, identity)
+	require.NoError(t, err)
+	require.Equal(t, consts.SiteStatusInCreation, h.Site(t, siteID).Status)
+	require.Empty(t, h.Events(t))
+
+	// awaiting provision - requested by the owner after payment
+	h.Subscribe(t, siteID)
+	newStatus := dto.UpdateSiteRequestNewStatusAwaitingProvision
+	subdomain := "acme"
+	_, err = h.UpdateSite.Execute(ctx, siteID, &dto.UpdateSiteRequest{NewStatus: &newStatus, Domain: &subdomain}, identity)
+	require.NoError(t, err)
+	require.Equal(t, consts.SiteStatusAwaitingProvision, h.Site(t, siteID).Status)
+	requireEvents(t, h.Events(t), "SiteAwaitingProvision", consts.NotProcessed)
+
+	// created - built, served by a deployed distribution and announced by mail
+	h.RunOutbox(t)
+	site := h.Site(t, siteID)
+	require.Equal(t, consts.SiteStatusCreated, site.Status)
+	require.Equal(t, 1, site.PublishedVersion)
+	provision := h.Provision(t, siteID)
+	require.Equal(t, consts.ProvisionStatusProvisioned, provision.Status)
+	require.Equal(t, "acme."+testinfra.BaseDomain, provision.Domain)
+	versions := h.Versions(t, siteID)
+	require.Len(t, versions, 1)
+	require.Equal(t, storage.SiteVersionPrefix(siteID, 1), versions[0].Prefix)
+	require.ElementsMatch(t, []string{"index.html", "_astro/site.js", "pages.json"}, h.StoredFiles(t, versions[0].Prefix))
+	page, err := h.Storage.GetFile(ctx, versions[0].Prefix+"/index.html")
+	require.NoError(t, err)
+	require.Contains(t, string(page), "Acme Law")
+
+	distribution, ok := h.DNS.Distribution(provision.CloudfrontID)
+	require.True(t, ok)
+	require.True(t, distribution.Enabled)
+	require.True(t, distribution.Deployed())
+	require.Equal(t, "/"+versions[0].Prefix, distribution.OriginPath)
+	record, ok := h.DNS.Record(provision.Domain)
+	require.True(t, ok)
+	require.Equal(t, distribution.Domain, record)
+
+	events := h.Events(t)
+	requireEvents(t, events, "SiteAwaitingProvision", consts.Processed, "FinalizeProvision", consts.Processed,
+		"SendMail", consts.Processed)
+	// finalization waited for the deployment through retries
+	require.Equal(t, 3, events[1].Attempts)
+	requireMails(t, h, user, mail.SiteCreated)
+
+	// deactivated - requested by the owner, taken off the domain and announced by mail
+	newStatus = dto.UpdateSiteRequestNewStatus(consts.SiteStatusAwaitingDeactivation)
+	_, err = h.UpdateSite.Execute(ctx, siteID, &dto.UpdateSiteRequest{NewStatus: &newStatus}, identity)
+	require.NoError(t, err)
+	require.Equal(t, consts.SiteStatusAwaitingDeactivation, h.Site(t, siteID).Status)
+	require.Equal(t, "DeactivateSite", h.Events(t)[3].Event)
+
+	h.RunOutbox(t)
+	require.Equal(t, consts.SiteStatusDeactivated, h.Site(t, siteID).Status)
+	require.Equal(t, consts.ProvisionStatusDeactivated, h.Provision(t, siteID).Status)
+	distribution, ok = h.DNS.Distribution(provision.CloudfrontID)
+	require.True(t, ok)
+	require.False(t, distribution.Enabled)
+	_, ok = h.DNS.Record(provision.Domain)
+	require.False(t, ok)
+
+	requireEvents(t, h.Events(t), "SiteAwaitingProvision", consts.Processed, "FinalizeProvision", consts.Processed,
+		"SendMail", consts.Processed, "DeactivateSite", consts.Processed, "SendMail", consts.Processed)
+	requireMails(t, h, user, mail.SiteCreated, mail.SiteDeactivated)
+}
+
+// requireEvents compares the outbox with pairs of event type and status
+func requireEvents(t *testing.T, events []db.Outbox, expected ...any) {
+	t.Helper()
+	var actual []any
+	for _, event := range events {
+		actual = append(actual, event.Event, consts.OutboxStatus(event.Status))
+	}
+	require.Equal(t, expected, actual)
+}
+
+// requireMails checks, that mails of types were both saved and sent to the user in order
+func requireMails(t *testing.T, h *testinfra.Harness, user db.User, types ...mail.MailType) {
+	t.Helper()
+	saved := h.Mails(t, user.Email)
+	sent := h.Mail.Sent()
+	require.Len(t, saved, len(types))
+	require.Len(t, sent, len(types))
+	for i, mailType := range types {
+		require.Equal(t, mailType, saved[i].MailType)
+		require.Equal(t, []string{user.Email}, sent[i].To)
+		require.Equal(t, saved[i].Subject, sent[i].Subject)
+		require.Equal(t, saved[i].Content, sent[i].Body)
+	}
+}
+
+func Test_SiteSaga_When_Preview_Is_Retried_After_Its_Distribution_Was_Created_Then_Reuse_It(t *testing.T) {
+	ctx := context.Background()
+	h := testinfra.NewHarness(t)
+	user := h.NewUser(t)
+	identity := &auth.Identity{UserID: user.ID}
+	siteID := provisionSite(t, h, identity)
+
+	queued, err := h.PreviewSite.Execute(ctx, siteID, identity)
+	require.NoError(t, err)
+	// previous attempt saved the preview with its distribution and failed before the build was finished
+	token := strings.ReplaceAll(queued.JobId.String(), "-", "")[:12]
+	preview := db.SitePreview{
+		Token:     token,
+		SiteID:    siteID,
+		Status:    consts.PreviewStatusPending,
+		Prefix:    storage.SitePreviewPrefix(siteID, token),
+		Domain:    fmt.Sprintf("preview-%v.%v", token, testinfra.BaseDomain),
+		CreatedBy: user.ID,
+		CreatedAt: time.Now(),
+		ExpiresAt: time.Now().Add(h.Config.PreviewTTL),
+	}
+	distribution, err := h.DNS.CreateDistribution(ctx, "/"+preview.Prefix, h.Config.Defaults.S3Domain, preview.Domain,
+		h.Config.Defaults.CertARN)
+	require.NoError(t, err)
+	preview.CloudfrontID = distribution.ID
+	uow := h.UoWFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, repo.NewSitePreviewRepo(tx).InsertPreview(ctx, preview))
+	require.NoError(t, uow.Commit())
+	created := h.DNS.Calls(dnsFake.CreateDistribution)
+
+	h.RunOutbox(t)
+
+	require.Equal(t, created, h.DNS.Calls(dnsFake.CreateDistribution))
+	saved := h.Preview(t, token)
+	require.Equal(t, consts.PreviewStatusReady, saved.Status)
+	require.Equal(t, distribution.ID, saved.CloudfrontID)
+	record, ok := h.DNS.Record(preview.Domain)
+	require.True(t, ok)
+	require.Equal(t, distribution.Domain, record)
+}
+
+func Test_SiteSaga_When_Provision_Is_Retried_After_Its_Distribution_Was_Created_Then_Reuse_It(t *testing.T) {
+	ctx := context.Background()
+	h := testinfra.NewHarness(t)
+	user := h.NewUser(t)
+	identity := &auth.Identity{UserID: user.ID}
+	fields := []map[string]interface{}{{"title": "Acme Law"}}
+	siteID, err := h.CreateSite.Execute(ctx, &dto.CreateSiteRequest{TemplateID: 1, PlanID: 1, Fields: &fields}, identity)
+	require.NoError(t, err)
+	h.Subscribe(t, siteID)
+	// previous attempt saved the provision with its distribution and failed before the first version was committed
+	domain := "acme." + testinfra.BaseDomain
+	distribution, err := h.DNS.CreateDistribution(ctx, "/"+storage.SiteVersionPrefix(siteID, 1), h.Config.Defaults.S3Domain,
+		domain, h.Config.Defaults.CertARN)
+	require.NoError(t, err)
+	uow := h.UoWFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, repo.NewProvisionRepo(tx).InsertProvision(ctx, db.Provision{
+		SiteID:         siteID,
+		Type:           consts.DefaultDomain,
+		Status:         consts.ProvisionStatusInProcess,
+		Domain:         domain,
+		CertificateARN: h.Config.Defaults.CertARN,
+		CloudfrontID:   distribution.ID,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}))
+	require.NoError(t, uow.Commit())
+	created := h.DNS.Calls(dnsFake.CreateDistribution)
+
+	newStatus := dto.UpdateSiteRequestNewStatusAwaitingProvision
+	subdomain := "acme"
+	_, err = h.UpdateSite.Execute(ctx, siteID, &dto.UpdateSiteRequest{NewStatus: &newStatus, Domain: &subdomain}, identity)
+	require.NoError(t, err)
+	h.RunOutbox(t)
+
+	require.Equal(t, created, h.DNS.Calls(dnsFake.CreateDistribution))
+	require.Equal(t, consts.SiteStatusCreated, h.Site(t, siteID).Status)
+	provision := h.Provision(t, siteID)
+	require.Equal(t, consts.ProvisionStatusProvisioned, provision.Status)
+	require.Equal(t, distribution.ID, provision.CloudfrontID)
+	require.Equal(t, 1, h.Site(t, siteID).PublishedVersion)
+	record, ok := h.DNS.Record(domain)
+	require.True(t, ok)
+	require.Equal(t, distribution.Domain, record)
+}
+
+func Test_SiteSaga_When_Build_Queue_Is_Full_Then_Preview_Is_Deferred_Without_Counting_Attempts(t *testing.T) {
+	ctx := context.Background()
+	h := testinfra.NewHarness(t)
+	user := h.NewUser(t)
+	identity := &auth.Identity{UserID: user.ID}
+	siteID := provisionSite(t, h, identity)
+	// changed draft isn't cached by the provision, so it's built by the runner
+	fields := []map[string]interface{}{{"title": "Acme Law Partners"}}
+	_, err := h.UpdateSite.Execute(ctx, siteID, &dto.UpdateSiteRequest{Fields: &fields}, identity)
+	require.NoError(t, err)
+	h.Runner.Fail("npm run build", build.ErrQueueFull, build.ErrQueueFull, build.ErrQueueFull, build.ErrQueueFull)
+
+	queued, err := h.PreviewSite.Execute(ctx, siteID, identity)
+	require.NoError(t, err)
+	h.RunOutbox(t)
+
+	result := h.Build(t, queued.JobId)
+	require.Equal(t, consts.BuildStatusSucceeded, result.Status)
+	require.Equal(t, 1, result.Attempts)
+	for _, event := range h.Events(t) {
+		if event.Event == "RebuildSite" {
+			require.Equal(t, int(consts.Processed), event.Status)
+			require.Equal(t, 1, event.Attempts)
+		}
+	}
+}
+
+func Test_SiteSaga_When_Draft_Is_Published_Then_Version_Is_Committed_And_Served(t *testing.T) {
+	ctx := context.Background()
+	h := testinfra.NewHarness(t)
+	user := h.NewUser(t)
+	identity := &auth.Identity{UserID: user.ID}
+	siteID := provisionSite(t, h, identity)
+	fields := []map[string]interface{}{{"title": "Acme Law Partners"}}
+	_, err := h.UpdateSite.Execute(ctx, siteID, &dto.UpdateSiteRequest{Fields: &fields}, identity)
+	require.NoError(t, err)
+
+	queued, err := h.ManageVersions.Publish(ctx, siteID, identity)
+	require.NoError(t, err)
+	h.RunOutbox(t)
+
+	result := h.Build(t, queued.JobId)
+	require.Equal(t, consts.BuildStatusSucceeded, result.Status)
+	require.Equal(t, 2, result.Version)
+	require.Equal(t, 2, h.Site(t, siteID).PublishedVersion)
+	versions := h.Versions(t, siteID)
+	require.Len(t, versions, 2)
+	require.Equal(t, 2, versions[1].Version)
+	require.Equal(t, storage.SiteVersionPrefix(siteID, 2), versions[1].Prefix)
+	page, err := h.Storage.GetFile(ctx, versions[1].Prefix+"/index.html")
+	require.NoError(t, err)
+	require.Contains(t, string(page), "Acme Law Partners")
+	distribution, ok := h.DNS.Distribution(h.Provision(t, siteID).CloudfrontID)
+	require.True(t, ok)
+	require.Equal(t, "/"+versions[1].Prefix, distribution.OriginPath)
+	for _, event := range h.Events(t) {
+		if event.Event == "RebuildSite" {
+			require.Equal(t, int(consts.Processed), event.Status)
+		}
+	}
+}
+
+// provisionSite creates a site of the user and runs its provision on a subdomain of the base domain
+func provisionSite(t *testing.T, h *testinfra.Harness, identity *auth.Identity) uint64 {
+	t.Helper()
+	ctx := context.Background()
+	fields := []map[string]interface{}{{"title": "Acme Law"}}
+	siteID, err := h.CreateSite.Execute(ctx, &dto.CreateSiteRequest{TemplateID: 1, PlanID: 1, Fields: &fields}, identity)
+	require.NoError(t, err)
+	h.Subscribe(t, siteID)
+	newStatus := dto.UpdateSiteRequestNewStatusAwaitingProvision
+	subdomain := "acme"
+	_, err = h.UpdateSite.Execute(ctx, siteID, &dto.UpdateSiteRequest{NewStatus: &newStatus, Domain: &subdomain}, identity)
+	require.NoError(t, err)
+	h.RunOutbox(t)
+	require.Equal(t, consts.SiteStatusCreated, h.Site(t, siteID).Status)
+	return siteID
+}
@@ -4,6 +4,7 @@ import (
 	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/Builder-Lawyers/builder-backend/pkg/env"
 	"github.com/google/uuid"
@@ -18,6 +19,8 @@ type OIDCConfig struct {
 	GoogleIssuerURL            string
 	Mode                       string
 	TestUser                   uuid.UUID
+	// Admins are allowed to manage internal state, f.e. outbox events
+	Admins []uuid.UUID
 }
 
 func NewOIDCConfig() OIDCConfig {
@@ -41,6 +44,18 @@ func NewOIDCConfig() OIDCConfig {
 		slog.Error("err parsing SESSION_LIFETIME, set to default", "err", err)
 		sessionLifetimeHours = 60
 	}
+	var admins []uuid.UUID
+	for _, admin := range strings.Split(os.Getenv("ADMIN_USERS"), ",") {
+		if admin = strings.TrimSpace(admin); admin == "" {
+			continue
+		}
+		adminID, err := uuid.Parse(admin)
+		if err != nil {
+			slog.Error("err parsing ADMIN_USERS, skipping", "user", admin, "err", err)
+			continue
+		}
+		admins = append(admins, adminID)
+	}
 	return OIDCConfig{
 		UserPoolID:                 os.Getenv("COGNITO_POOL_ID"),
 		RedirectURL:                os.Getenv("SIGNUP_REDIRECT"),
@@ -50,5 +65,6 @@ func NewOIDCConfig() OIDCConfig {
 		GoogleIssuerURL:            os.Getenv("GOOGLE_ISSUER"),
 		Mode:                       os.Getenv("MODE"),
 		TestUser:                   testUserID,
+		Admins:                     admins,
 	}
 }
@@ -11,7 +11,8 @@ type IdentityProvider struct {
 }
 
 type Identity struct {
-	UserID uuid.UUID
+	UserID  uuid.UUID
+	IsAdmin bool
 }
 
 func (p IdentityProvider) GetIdentity(tokenString string) (*Identity, error) {
@@ -5,28 +5,29 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
-	"log"
 	"log/slog"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
 
 type TemplateBuild struct {
-	storage *storage.Storage
-	cfg     config.ProvisionConfig
+	storage  *storage.Storage
+	cfg      config.ProvisionConfig
+	executor Runner
+	// cacheMu - guards downloaded templates, while they are refreshed or copied to a workspace
+	cacheMu sync.Mutex
 }
 
-func NewTemplateBuild(storage *storage.Storage, provisionConfig config.ProvisionConfig) *TemplateBuild {
+func NewTemplateBuild(storage *storage.Storage, provisionConfig config.ProvisionConfig, executor Runner) *TemplateBuild {
 	return &TemplateBuild{
-		storage: storage,
-		cfg:     provisionConfig,
+		storage:  storage,
+		cfg:      provisionConfig,
+		executor: executor,
 	}
 }
 
@@ -45,13 +46,13 @@ func (b *TemplateBuild) DownloadTemplate(ctx context.Context, templateName strin
 			return nil
 		}
 	}
-	slog.Warn("Folder with templates doesn't exist, creating now")
-	err = os.MkdirAll(filepath.Join(b.cfg.TemplatesFolder, templateName), fs.ModeDir)
+	slog.WarnContext(ctx, "Folder with templates doesn't exist, creating now")
+	err = os.MkdirAll(filepath.Join(b.cfg.TemplatesFolder, templateName), 0o755)
 	if err != nil {
-		slog.Error("Failed to create dirs for templates", "template", err)
+		slog.ErrorContext(ctx, "Failed to create dirs for templates", "template", err)
 		return err
 	}
-	slog.Info("Created folders for templates")
+	slog.InfoContext(ctx, "Created folders for templates")
 	err = b.DownloadMissingRootFiles(ctx, b.cfg.BuildFolder, b.cfg.TemplateSrcBucketPath)
 	if err != nil {
 		return err
@@ -65,6 +66,8 @@ func (b *TemplateBuild) DownloadTemplate(ctx context.Context, templateName strin
 }
 
 func (b *TemplateBuild) RefreshTemplate(ctx context.Context, templateName string) error {
+	b.cacheMu.Lock()
+	defer b.cacheMu.Unlock()
 	targetTemplate := filepath.Join(b.cfg.TemplatesFolder, templateName)
 	exists, err := dirExists(targetTemplate)
 	if err != nil {
@@ -79,13 +82,13 @@ func (b *TemplateBuild) RefreshTemplate(ctx context.Context, templateName string
 			return nil
 		}
 	}
-	slog.Warn("Folder with templates doesn't exist, creating now")
-	err = os.MkdirAll(filepath.Join(b.cfg.TemplatesFolder, templateName), fs.ModeDir)
+	slog.WarnContext(ctx, "Folder with templates doesn't exist, creating now")
+	err = os.MkdirAll(filepath.Join(b.cfg.TemplatesFolder, templateName), 0o755)
 	if err != nil {
-		slog.Error("Failed to create dirs for templates", "template", err)
+		slog.ErrorContext(ctx, "Failed to create dirs for templates", "template", err)
 		return err
 	}
-	slog.Info("Created folders for templates")
+	slog.InfoContext(ctx, "Created folders for templates")
 	err = b.DownloadMissingRootFiles(ctx, b.cfg.BuildFolder, b.cfg.TemplateSrcBucketPath)
 	if err != nil {
 		return err
@@ -105,15 +108,15 @@ func (b *TemplateBuild) DownloadMissingRootFiles(ctx context.Context, path, buck
 	}
 	// 1 - because previously we MkDir all dirs to template's dir
 	if len(dir) > 1 { // if only templates folder is present
-		slog.Info("root dir is not empty")
+		slog.InfoContext(ctx, "root dir is not empty")
 		return nil
 	}
 
-	slog.Info("directory is empty, downloading sources", "path", path)
-	// TODO: if there are many templates, this is bad
-	files := b.storage.ListFiles(ctx, 100, &s3.ListObjectsV2Input{
-		Prefix: aws.String(bucketPath),
-	})
+	slog.InfoContext(ctx, "directory is empty, downloading sources", "path", path)
+	files, err := b.storage.ListKeys(ctx, bucketPath, 0)
+	if err != nil {
+		return err
+	}
 	filesToDownload := make([]string, 0)
 	for _, file := range files {
 		// everything under templates/
@@ -124,7 +127,7 @@ func (b *TemplateBuild) DownloadMissingRootFiles(ctx context.Context, path, buck
 	}
 	err = b.storage.DownloadFiles(ctx, filesToDownload, path, bucketPath)
 	if err != nil {
-		slog.Error("err downloading template's sources", "err", err)
+		slog.ErrorContext(ctx, "err downloading template's sources", "err", err)
 		return err
 	}
 
@@ -137,7 +140,7 @@ func (b *TemplateBuild) DownloadMissingTemplateFiles(ctx context.Context, path,
 		return err
 	}
 	if len(dir) > 1 {
-		slog.Info("template's sources are present", "template", path)
+		slog.InfoContext(ctx, "template's sources are present", "template", path)
 		return nil
 	}
 
@@ -149,12 +152,13 @@ func (b *TemplateBuild) DownloadMissingTemplateFiles(ctx context.Context, path,
 // bucketPath - path to template on S3
 func (b *TemplateBuild) DownloadTemplateFiles(ctx context.Context, localPath, bucketPath string) error {
 
-	files := b.storage.ListFiles(ctx, 100, &s3.ListObjectsV2Input{
-		Prefix: aws.String(bucketPath),
-	})
-	err := b.storage.DownloadFiles(ctx, files, localPath, bucketPath)
+	files, err := b.storage.ListKeys(ctx, bucketPath, 0)
 	if err != nil {
-		slog.Error("err downloading template's sources", "err", err)
+		return err
+	}
+	err = b.storage.DownloadFiles(ctx, files, localPath, bucketPath)
+	if err != nil {
+		slog.ErrorContext(ctx, "err downloading template's sources", "err", err)
 		return err
 	}
 
@@ -164,45 +168,21 @@ func (b *TemplateBuild) DownloadTemplateFiles(ctx context.Context, localPath, bu
 // To run this, container running application has to have npm installed and "npm i -g pnpm"
 func (b *TemplateBuild) RunSiteBuild(ctx context.Context, path string) (string, error) {
 	templatesRootDir := filepath.Dir(path)
-	if len(path) != 0 {
-		slog.Info("Valid dir")
-	}
-	// TODO: first check if node modules exist, then run build or first install
-	build := createProcess(ctx, path, "npm run build")
-	err := build.Start()
-	if err != nil {
-		slog.Error("failed to start npm run build", "err", err)
-	}
-
-	slog.Info("npm run build started", "pid", build.Process.Pid)
-
-	err = build.Wait()
+	_, err := b.executor.Run(ctx, path, "npm run build")
 	if err != nil {
-		slog.Error("npm run build exited with err", "err", err)
-		installDeps := createProcess(ctx, templatesRootDir, "pnpm i")
-
-		err = installDeps.Start()
-		if err != nil {
-			slog.Error("failed to install dependencies", "err", err)
+		if errors.Is(err, ErrQueueFull) || ctx.Err() != nil {
 			return "", err
 		}
-		err = installDeps.Wait()
+		slog.ErrorContext(ctx, "npm run build exited with err", "err", err)
+		_, err = b.executor.Run(ctx, templatesRootDir, "pnpm i")
 		if err != nil {
-			slog.Error("failed to install dependencies", "err", err)
+			slog.ErrorContext(ctx, "failed to install dependencies", "err", err)
 			return "", err
 		}
 
-		build = createProcess(ctx, path, "npm run build")
-		err = build.Start()
+		_, err = b.executor.Run(ctx, path, "npm run build")
 		if err != nil {
-			slog.Error("failed to start npm run build", "err", err)
-			return "", err
-		}
-
-		slog.Info("npm run build started", "pid", build.Process.Pid)
-		err = build.Wait()
-		if err != nil {
-			slog.Error("fatal error", "err", err)
+			slog.ErrorContext(ctx, "fatal error", "err", err)
 			return "", err
 		}
 	}
@@ -211,30 +191,9 @@ func (b *TemplateBuild) RunSiteBuild(ctx context.Context, path string) (string,
 }
 
 // Uploads file from a filesystem path to a s3 prefix
-func (b *TemplateBuild) UploadFiles(ctx context.Context, bucketPath, templateName, dir string) error {
-	files := readFilesFromDir(dir)
-	for _, f := range files {
-		file, err := os.Open(f)
-		// gets a substring after dist/
-		normalized := filepath.ToSlash(f)
-		parts := strings.SplitN(normalized, templateName+"/dist", 2)
-		//parts := strings.SplitN(normalized, "template/"+templateName, 2)
-		if err != nil {
-			return fmt.Errorf("malformed filepath, %s: %v", f, err)
-		}
-		_, err = b.storage.UploadFile(ctx, bucketPath+parts[1], nil, file)
-		if err != nil {
-			return fmt.Errorf("can't put object %v", err)
-		}
-		if err = file.Close(); err != nil {
-			return fmt.Errorf("failed to close file %s: %v", f, err)
-		}
-		slog.Info("Uploaded file", "fileUpload", f)
-	}
-	return nil
-}
-
 func (b *TemplateBuild) ClearTemplateFilesLocally(root string) error {
+	b.cacheMu.Lock()
+	defer b.cacheMu.Unlock()
 	exists, err := dirExists(root)
 	if err != nil {
 		return err
@@ -255,35 +214,6 @@ func (b *TemplateBuild) ClearTemplateFilesLocally(root string) error {
 	return nil
 }
 
-func readFilesFromDir(dir string) []string {
-	var files []string
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		slog.Error("Can't find provided directory", "dir", err)
-	}
-	for _, entry := range entries {
-		fullPath := filepath.Join(dir, entry.Name())
-		if entry.IsDir() {
-			subFiles := readFilesFromDir(fullPath)
-			files = append(files, subFiles...)
-		} else {
-			files = append(files, fullPath)
-		}
-	}
-	return files
-}
-
-func createProcess(ctx context.Context, dir string, command string) *exec.Cmd {
-	log.Println(command)
-	params := strings.Split(command, " ")
-	proc := exec.CommandContext(ctx, params[0], params[1:]...)
-	proc.Dir = dir
-	proc.Stdout = os.Stdout
-	proc.Stderr = os.Stderr
-	proc.Stdin = os.Stdin
-	return proc
-}
-
 func dirExists(path string) (bool, error) {
 	_, err := os.Stat(path)
 	if err == nil {
@@ -0,0 +1,107 @@
+package build
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hashSkip - entries of the build folder, that don't change output of a build
+var hashSkip = map[string]bool{
+	"node_modules": true,
+	"dist":         true,
+	".astro":       true,
+	".git":         true,
+}
+
+// SourceHash returns a hash of template's sources and shared files of the build folder, it changes with template's version
+func (b *TemplateBuild) SourceHash(ctx context.Context, templateName string) (string, error) {
+	b.cacheMu.Lock()
+	defer b.cacheMu.Unlock()
+
+	err := b.DownloadTemplate(ctx, templateName)
+	if err != nil {
+		return "", err
+	}
+
+	return b.hashSources(templateName)
+}
+
+// CacheKey addresses output of a build by template's sources and site's fields.
+// Fields are normalized, so their formatting and order of keys don't change the key
+func CacheKey(sourceHash string, fields json.RawMessage) (string, error) {
+	var value any
+	if err := json.Unmarshal(fields, &value); err != nil {
+		return "", fmt.Errorf("err normalizing fields, %v", err)
+	}
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("err normalizing fields, %v", err)
+	}
+
+	h := sha256.New()
+	h.Write([]byte(sourceHash))
+	h.Write([]byte{0})
+	h.Write(normalized)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashSources has to be called with cacheMu held. Site's fields, left in the template, aren't hashed
+func (b *TemplateBuild) hashSources(templateName string) (string, error) {
+	templatePath := filepath.Join(b.cfg.TemplatesFolder, templateName)
+	fieldsPath := filepath.Join(templatePath+b.cfg.PathToFile, b.cfg.Filename)
+	templatesRoot := filepath.Join(b.cfg.BuildFolder, strings.Split(filepath.ToSlash(b.templatesDir()), "/")[0])
+
+	h := sha256.New()
+	hashDir := func(root string, skip func(path string, d fs.DirEntry) bool) error {
+		return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if path != root && (hashSkip[d.Name()] || skip(path, d)) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(b.cfg.BuildFolder, path)
+			if err != nil {
+				return err
+			}
+			h.Write([]byte(filepath.ToSlash(rel)))
+			h.Write([]byte{0})
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			_, err = io.Copy(h, file)
+			return err
+		})
+	}
+
+	err := hashDir(b.cfg.BuildFolder, func(path string, d fs.DirEntry) bool {
+		return path == templatesRoot
+	})
+	if err != nil {
+		return "", fmt.Errorf("err hashing build folder, %v", err)
+	}
+	err = hashDir(templatePath, func(path string, d fs.DirEntry) bool {
+		return path == fieldsPath
+	})
+	if err != nil {
+		return "", fmt.Errorf("err hashing template's sources, %v", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,49 @@
+package build_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/build"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CacheKey_When_Only_Formatting_Of_Fields_Differs_Then_Keys_Match(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.ProvisionConfig{
+		BuildFolder:     filepath.Join(root, "templates-repo"),
+		TemplatesFolder: filepath.Join(root, "templates-repo", "templates"),
+		Filename:        "pages.json",
+	}
+	templatePath := filepath.Join(cfg.TemplatesFolder, "landing")
+	require.NoError(t, os.MkdirAll(filepath.Join(templatePath, "node_modules"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(templatePath, "index.astro"), []byte("---"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.BuildFolder, "package.json"), []byte("{}"), 0o644))
+	SUT := build.NewTemplateBuild(nil, cfg, nil)
+
+	sourceHash, err := SUT.SourceHash(context.Background(), "landing")
+	require.NoError(t, err)
+	first, err := build.CacheKey(sourceHash, json.RawMessage(`{"title": "Law", "pages": [1, 2]}`))
+	require.NoError(t, err)
+	second, err := build.CacheKey(sourceHash, json.RawMessage(`{"pages":[1,2],"title":"Law"}`))
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	// fields, left in the template, and dependencies don't change the hash
+	require.NoError(t, os.WriteFile(filepath.Join(templatePath, "pages.json"), []byte(`{"old":true}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(templatePath, "node_modules", "dep.js"), []byte("x"), 0o644))
+	unchanged, err := SUT.SourceHash(context.Background(), "landing")
+	require.NoError(t, err)
+	require.Equal(t, sourceHash, unchanged)
+
+	require.NoError(t, os.WriteFile(filepath.Join(templatePath, "index.astro"), []byte("--- v2"), 0o644))
+	changed, err := SUT.SourceHash(context.Background(), "landing")
+	require.NoError(t, err)
+	third, err := build.CacheKey(changed, json.RawMessage(`{"pages":[1,2],"title":"Law"}`))
+	require.NoError(t, err)
+	require.NotEqual(t, first, third)
+}
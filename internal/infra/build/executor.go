@@ -0,0 +1,217 @@
+package build
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/pkg/env"
+)
+
+var (
+	ErrQueueFull   = errors.New("build queue is full")
+	ErrTimeout     = errors.New("build exceeded its time limit")
+	ErrMemoryLimit = errors.New("build exceeded its memory limit")
+)
+
+// memoryCheckInterval - how often memory of a running build is checked
+const memoryCheckInterval = 2 * time.Second
+
+// ExecutorConfig - limits of builds. Timeout has to be shorter than the lease of outbox events,
+// so a build isn't picked up by another worker, while it's running
+type ExecutorConfig struct {
+	Workers   int
+	QueueSize int
+	Timeout   time.Duration
+	// MemoryLimit - in megabytes, for all processes of a build
+	MemoryLimit int
+	// LogLimit - in bytes, only the tail of build's output is kept
+	LogLimit int
+}
+
+func NewExecutorConfig() ExecutorConfig {
+	timeout, err := time.ParseDuration(env.GetEnv("P_BUILD_TIMEOUT", "8m"))
+	if err != nil || timeout <= 0 {
+		timeout = 8 * time.Minute
+	}
+	return ExecutorConfig{
+		Workers:     getPositiveInt("P_BUILD_WORKERS", 2),
+		QueueSize:   getPositiveInt("P_BUILD_QUEUE_SIZE", 10),
+		Timeout:     timeout,
+		MemoryLimit: getPositiveInt("P_BUILD_MEMORY_LIMIT", 2048),
+		LogLimit:    getPositiveInt("P_BUILD_LOG_LIMIT", 64*1024),
+	}
+}
+
+func getPositiveInt(key string, defaultVal int) int {
+	value, err := strconv.Atoi(env.GetEnv(key, strconv.Itoa(defaultVal)))
+	if err != nil || value <= 0 {
+		return defaultVal
+	}
+	return value
+}
+
+// Error - failed command with the tail of its output
+type Error struct {
+	Err error
+	Log string
+}
+
+func (e Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// Runner runs a build command in dir and returns its output, a failed command returns Error with its output
+type Runner interface {
+	Run(ctx context.Context, dir, command string) (string, error)
+}
+
+var _ Runner = (*Executor)(nil)
+
+// Executor runs build commands on a fixed number of workers, commands wait for a free worker in a bounded queue
+type Executor struct {
+	cfg  ExecutorConfig
+	jobs chan *job
+}
+
+type job struct {
+	ctx     context.Context
+	dir     string
+	command string
+	done    chan result
+}
+
+type result struct {
+	log string
+	err error
+}
+
+func NewExecutor(cfg ExecutorConfig) *Executor {
+	e := &Executor{cfg: cfg, jobs: make(chan *job, cfg.QueueSize)}
+	for i := 0; i < cfg.Workers; i++ {
+		go e.work()
+	}
+	return e
+}
+
+// Run queues a command and waits until a worker runs it. Cancelling ctx removes a queued command or kills a running one.
+// Output of the command is returned, a failed command returns Error with its output
+func (e *Executor) Run(ctx context.Context, dir, command string) (string, error) {
+	j := &job{ctx: ctx, dir: dir, command: command, done: make(chan result, 1)}
+	select {
+	case e.jobs <- j:
+	default:
+		return "", ErrQueueFull
+	}
+
+	select {
+	case r := <-j.done:
+		return r.log, r.err
+	case <-ctx.Done():
+		// worker skips a cancelled job, a running process is killed by its context
+		return "", ctx.Err()
+	}
+}
+
+func (e *Executor) work() {
+	for j := range e.jobs {
+		if j.ctx.Err() != nil {
+			j.done <- result{err: j.ctx.Err()}
+			continue
+		}
+		j.done <- e.run(j)
+	}
+}
+
+func (e *Executor) run(j *job) result {
+	ctx, cancelTimeout := context.WithTimeoutCause(j.ctx, e.cfg.Timeout, ErrTimeout)
+	defer cancelTimeout()
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	params := strings.Split(j.command, " ")
+	cmd := exec.CommandContext(ctx, params[0], params[1:]...)
+	cmd.Dir = j.dir
+	output := newTailBuffer(e.cfg.LogLimit)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	// heap of every node process is limited, the rest of the memory is checked by the watcher
+	cmd.Env = append(os.Environ(), fmt.Sprintf("NODE_OPTIONS=--max-old-space-size=%d", e.cfg.MemoryLimit))
+	cmd.WaitDelay = 10 * time.Second
+	setProcessGroup(cmd)
+
+	started := time.Now()
+	slog.InfoContext(ctx, "running build command", "command", j.command, "dir", j.dir)
+	if err := cmd.Start(); err != nil {
+		return result{err: Error{Err: fmt.Errorf("failed to start %v, %v", j.command, err)}}
+	}
+	go e.watchMemory(ctx, cmd.Process.Pid, cancel)
+
+	err := cmd.Wait()
+	if err != nil {
+		if cause := context.Cause(ctx); cause != nil {
+			err = cause
+		}
+		slog.WarnContext(ctx, "build command failed", "command", j.command, "duration", time.Since(started), "err", err)
+		return result{log: output.String(), err: Error{Err: err, Log: output.String()}}
+	}
+	slog.InfoContext(ctx, "build command finished", "command", j.command, "duration", time.Since(started))
+
+	return result{log: output.String()}
+}
+
+// watchMemory kills the build, when its processes use more memory, than the limit
+func (e *Executor) watchMemory(ctx context.Context, pid int, cancel context.CancelCauseFunc) {
+	limit := uint64(e.cfg.MemoryLimit) * 1024 * 1024
+	ticker := time.NewTicker(memoryCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			used, err := groupMemory(pid)
+			if err != nil {
+				slog.DebugContext(ctx, "err reading memory of build", "pid", pid, "err", err)
+				continue
+			}
+			if used > limit {
+				slog.WarnContext(ctx, "build exceeded memory limit", "pid", pid, "used", used, "limit", limit)
+				cancel(ErrMemoryLimit)
+				return
+			}
+		}
+	}
+}
+
+// tailBuffer keeps the last bytes written to it
+type tailBuffer struct {
+	limit int
+	buf   []byte
+}
+
+func newTailBuffer(limit int) *tailBuffer {
+	return &tailBuffer{limit: limit}
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = b.buf[len(b.buf)-b.limit:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	return string(b.buf)
+}
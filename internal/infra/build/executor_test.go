@@ -0,0 +1,29 @@
+package build_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/build"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Run_When_Build_Exceeds_Timeout_Then_Kill_It_And_Keep_Its_Output(t *testing.T) {
+	dir := t.TempDir()
+	script := "echo compiling pages\nsleep 30\necho done\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "build.sh"), []byte(script), 0o755))
+	SUT := build.NewExecutor(build.ExecutorConfig{Workers: 1, QueueSize: 1, Timeout: 300 * time.Millisecond,
+		MemoryLimit: 512, LogLimit: 1024})
+
+	started := time.Now()
+	_, err := SUT.Run(context.Background(), dir, "sh build.sh")
+
+	require.ErrorIs(t, err, build.ErrTimeout)
+	var buildErr build.Error
+	require.ErrorAs(t, err, &buildErr)
+	require.Equal(t, "compiling pages\n", buildErr.Log)
+	require.Less(t, time.Since(started), 10*time.Second)
+}
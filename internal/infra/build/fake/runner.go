@@ -0,0 +1,115 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/build"
+)
+
+const (
+	Install = "pnpm i"
+	Build   = "npm run build"
+)
+
+// Command - command passed to the runner with its directory
+type Command struct {
+	Dir     string
+	Command string
+}
+
+// Runner - build.Runner, that emulates the template's toolchain without node.
+// Install creates node_modules of the workspace and its packages, Build renders fields from fieldsFile into dist/index.html
+type Runner struct {
+	mu         sync.Mutex
+	fieldsFile string
+	commands   []Command
+	failures   map[string][]error
+}
+
+var _ build.Runner = (*Runner)(nil)
+
+// NewRunner - fieldsFile is the name of the file, where sites' fields are saved before a build
+func NewRunner(fieldsFile string) *Runner {
+	return &Runner{fieldsFile: fieldsFile, failures: make(map[string][]error)}
+}
+
+// Fail makes the next runs of command return errs, one per run in order
+func (r *Runner) Fail(command string, errs ...error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures[command] = append(r.failures[command], errs...)
+}
+
+// Commands returns commands in order of running, including failed ones
+func (r *Runner) Commands() []Command {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Command(nil), r.commands...)
+}
+
+func (r *Runner) Run(ctx context.Context, dir, command string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	r.commands = append(r.commands, Command{Dir: dir, Command: command})
+	var err error
+	if queued := r.failures[command]; len(queued) > 0 {
+		err, r.failures[command] = queued[0], queued[1:]
+	}
+	r.mu.Unlock()
+	if err != nil {
+		return "", build.Error{Err: err, Log: err.Error()}
+	}
+
+	switch command {
+	case Install:
+		err = install(dir)
+	case Build:
+		err = r.render(dir)
+	default:
+		err = fmt.Errorf("unknown command %v", command)
+	}
+	if err != nil {
+		return "", build.Error{Err: err, Log: err.Error()}
+	}
+
+	return command + " done", nil
+}
+
+// install - like pnpm in a workspace, dependencies are installed for dir and every package in it
+func install(dir string) error {
+	packages, err := filepath.Glob(filepath.Join(dir, "*", "package.json"))
+	if err != nil {
+		return err
+	}
+	for _, pkg := range append(packages, filepath.Join(dir, "package.json")) {
+		if err = os.MkdirAll(filepath.Join(filepath.Dir(pkg), "node_modules"), 0o755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// render writes a page with the fields and a hashed asset, like the bundler does
+func (r *Runner) render(dir string) error {
+	fields, err := os.ReadFile(filepath.Join(dir, r.fieldsFile))
+	if err != nil {
+		return fmt.Errorf("err reading fields, %v", err)
+	}
+	dist := filepath.Join(dir, "dist")
+	if err = os.MkdirAll(filepath.Join(dist, "_astro"), 0o755); err != nil {
+		return err
+	}
+	page := fmt.Sprintf(`<!doctype html><html><body><script id="fields" type="application/json">%s</script>`+
+		`<script src="/_astro/site.js"></script></body></html>`, fields)
+	if err = os.WriteFile(filepath.Join(dist, "index.html"), []byte(page), 0o644); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dist, "_astro", "site.js"), []byte("console.log('site')"), 0o644)
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package build
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// setProcessGroup runs a build in its own process group, so processes started by npm are killed together with it
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// groupMemory sums resident memory of processes in the group of pgid
+func groupMemory(pgid int) (uint64, error) {
+	stats, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil {
+		return 0, err
+	}
+	pageSize := uint64(os.Getpagesize())
+	var total uint64
+	for _, path := range stats {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			// process has exited
+			continue
+		}
+		// command can contain spaces, fields are counted after it
+		stat := string(content)
+		fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+		// pgrp and rss are the 5th and 24th fields of stat
+		if len(fields) < 22 || fields[2] != strconv.Itoa(pgid) {
+			continue
+		}
+		rss, err := strconv.ParseUint(fields[21], 10, 64)
+		if err != nil {
+			continue
+		}
+		total += rss * pageSize
+	}
+
+	return total, nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package build
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// groupMemory - memory of a build is only limited by the heap limit of node outside of linux
+func groupMemory(pgid int) (uint64, error) {
+	return 0, errors.New("memory of builds isn't tracked on this platform")
+}
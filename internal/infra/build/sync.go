@@ -0,0 +1,122 @@
+package build
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// revalidated - pages and data keep their urls between versions, so browsers check them on every request
+	revalidated = "public, max-age=0, must-revalidate"
+	// immutable - bundler puts a hash of the content into names of these files
+	immutable = "public, max-age=31536000, immutable"
+	cached    = "public, max-age=86400"
+)
+
+// hashedDirs - output folders of the bundler, where file names change with their content.
+// Files from public/ keep their names, so they aren't immutable
+var hashedDirs = []string{"_astro/"}
+
+// extraTypes - extensions, that can be missing from the mime table of the system
+var extraTypes = map[string]string{
+	".woff":        "font/woff",
+	".woff2":       "font/woff2",
+	".ttf":         "font/ttf",
+	".ico":         "image/x-icon",
+	".txt":         "text/plain; charset=utf-8",
+	".map":         "application/json",
+	".webmanifest": "application/manifest+json",
+}
+
+// Sync uploads files of dir, that differ from objects under bucketPath, and deletes objects, that aren't in dir anymore.
+// Keys accepted by keep aren't deleted. Changed keys are returned relative to bucketPath
+func (b *TemplateBuild) Sync(ctx context.Context, bucketPath, dir string, keep func(key string) bool) ([]string, error) {
+	existing, err := b.storage.ListObjects(ctx, bucketPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	local := make(map[string]bool)
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		local[key] = true
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("err reading %v, %v", p, err)
+		}
+		sum := md5.Sum(data)
+		if obj, ok := existing[key]; ok && obj.ETag == hex.EncodeToString(sum[:]) {
+			return nil
+		}
+		err = b.storage.PutObject(ctx, bucketPath+"/"+key, data, ContentType(key, data), CacheControl(key))
+		if err != nil {
+			return err
+		}
+		changed = append(changed, key)
+		return nil
+	})
+	if err != nil {
+		return changed, fmt.Errorf("err uploading build output, %v", err)
+	}
+
+	var orphaned []string
+	for key := range existing {
+		if !local[key] && (keep == nil || !keep(key)) {
+			orphaned = append(orphaned, bucketPath+"/"+key)
+			changed = append(changed, key)
+		}
+	}
+	if err = b.storage.DeleteKeys(ctx, orphaned); err != nil {
+		return changed, err
+	}
+	slog.InfoContext(ctx, "synced build output", "prefix", bucketPath,
+		"files", len(local), "uploaded", len(changed)-len(orphaned), "deleted", len(orphaned))
+
+	return changed, nil
+}
+
+// ContentType detects type of a file by its extension, falling back to its content
+func ContentType(key string, data []byte) string {
+	ext := strings.ToLower(path.Ext(key))
+	if contentType, ok := extraTypes[ext]; ok {
+		return contentType
+	}
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return contentType
+	}
+	return http.DetectContentType(data)
+}
+
+// CacheControl - how long browsers and cdn keep a file
+func CacheControl(key string) string {
+	for _, dir := range hashedDirs {
+		if strings.HasPrefix(key, dir) {
+			return immutable
+		}
+	}
+	switch strings.ToLower(path.Ext(key)) {
+	case ".html", ".json", ".xml", ".txt", ".webmanifest":
+		return revalidated
+	default:
+		return cached
+	}
+}
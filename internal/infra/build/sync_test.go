@@ -0,0 +1,52 @@
+package build_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/build"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Sync_When_Output_Changed_Then_Upload_Changed_And_Delete_Stale_Files(t *testing.T) {
+	ctx := context.Background()
+	localStore, err := storage.NewLocalStore(t.TempDir(), "")
+	require.NoError(t, err)
+	blobs := storage.NewBlobStorage(localStore)
+	SUT := build.NewTemplateBuild(blobs, config.ProvisionConfig{}, nil)
+	dist := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dist, "_astro"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dist, "index.html"), []byte("<h1>v1</h1>"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dist, "_astro", "main.1a2b.css"), []byte("body{}"), 0o644))
+
+	changed, err := SUT.Sync(ctx, "sites/1/versions/1", dist, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"index.html", "_astro/main.1a2b.css"}, changed)
+
+	require.NoError(t, os.Remove(filepath.Join(dist, "_astro", "main.1a2b.css")))
+	require.NoError(t, os.WriteFile(filepath.Join(dist, "_astro", "main.3c4d.css"), []byte("body{margin:0}"), 0o644))
+	changed, err = SUT.Sync(ctx, "sites/1/versions/1", dist, nil)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"_astro/main.3c4d.css", "_astro/main.1a2b.css"}, changed)
+
+	keys, err := blobs.ListKeys(ctx, "sites/1/", 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"sites/1/versions/1/_astro/main.3c4d.css", "sites/1/versions/1/index.html"}, keys)
+}
+
+func Test_ContentType_When_Extension_Is_Known_Then_Use_It_Instead_Of_Content(t *testing.T) {
+	require.Equal(t, "text/css; charset=utf-8", build.ContentType("_astro/main.css", []byte("body{}")))
+	require.Equal(t, "image/svg+xml", build.ContentType("logo.svg", []byte("<svg></svg>")))
+	require.Equal(t, "font/woff2", build.ContentType("fonts/inter.woff2", []byte{0}))
+	require.Equal(t, "text/plain; charset=utf-8", build.ContentType("LICENSE", []byte("MIT")))
+}
+
+func Test_CacheControl_When_Asset_Is_Hashed_Then_It_Is_Immutable(t *testing.T) {
+	require.Equal(t, "public, max-age=31536000, immutable", build.CacheControl("_astro/main.1a2b.css"))
+	require.Equal(t, "public, max-age=0, must-revalidate", build.CacheControl("about/index.html"))
+	require.Equal(t, "public, max-age=86400", build.CacheControl("favicon.png"))
+}
@@ -0,0 +1,220 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// workspaceSkip - entries of a cached template, that aren't copied to a workspace
+var workspaceSkip = map[string]bool{
+	"node_modules": true,
+	"dist":         true,
+	".astro":       true,
+}
+
+// Workspace - private copy of a cached template, where a single site is built, so concurrent builds of the same
+// template don't overwrite each other's fields and output. It mirrors the layout of the build folder, so relative
+// paths of template's configs resolve the same way. Shared files of the build folder and template's node_modules
+// are linked, not copied
+type Workspace struct {
+	root string
+	// Path - template's directory in the workspace
+	Path string
+	// SourceHash - hash of the sources, that were copied to the workspace
+	SourceHash string
+}
+
+// NewWorkspace downloads the template and installs its dependencies into the cache, if they are missing,
+// and copies its sources to a new workspace. Workspace has to be removed, when the build is done
+func (b *TemplateBuild) NewWorkspace(ctx context.Context, templateName string) (*Workspace, error) {
+	b.cacheMu.Lock()
+	defer b.cacheMu.Unlock()
+
+	err := b.DownloadTemplate(ctx, templateName)
+	if err != nil {
+		return nil, err
+	}
+	templatePath := filepath.Join(b.cfg.TemplatesFolder, templateName)
+	err = b.ensureDependencies(ctx, templatePath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(b.cfg.WorkspacesFolder, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("err creating workspaces folder, %v", err)
+	}
+	root, err := os.MkdirTemp(b.cfg.WorkspacesFolder, "build-")
+	if err != nil {
+		return nil, fmt.Errorf("err creating workspace, %v", err)
+	}
+	ws := &Workspace{root: root, Path: filepath.Join(root, b.templatesDir(), templateName)}
+
+	ws.SourceHash, err = b.hashSources(templateName)
+	if err == nil {
+		err = b.populate(ws, templatePath)
+	}
+	if err != nil {
+		if removeErr := ws.Remove(); removeErr != nil {
+			slog.ErrorContext(ctx, "err removing workspace", "path", root, "err", removeErr)
+		}
+		return nil, err
+	}
+	slog.InfoContext(ctx, "created build workspace", "template", templateName, "path", ws.Path)
+
+	return ws, nil
+}
+
+// BuildWorkspace builds the template in a workspace and returns the path to its output,
+// a failed build returns Error with its output
+func (b *TemplateBuild) BuildWorkspace(ctx context.Context, ws *Workspace) (string, error) {
+	_, err := b.executor.Run(ctx, ws.Path, "npm run build")
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(ws.Path, "dist"), nil
+}
+
+// Remove deletes the workspace with its build output
+func (w *Workspace) Remove() error {
+	return os.RemoveAll(w.root)
+}
+
+// CleanWorkspaces removes workspaces, that were left by builds interrupted with a restart
+func (b *TemplateBuild) CleanWorkspaces(ctx context.Context) error {
+	entries, err := os.ReadDir(b.cfg.WorkspacesFolder)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if err = os.RemoveAll(filepath.Join(b.cfg.WorkspacesFolder, entry.Name())); err != nil {
+			return err
+		}
+	}
+	slog.InfoContext(ctx, "removed stale build workspaces", "count", len(entries))
+
+	return nil
+}
+
+// ensureDependencies installs dependencies into the cache, so every workspace links them instead of installing its own
+func (b *TemplateBuild) ensureDependencies(ctx context.Context, templatePath string) error {
+	for _, dir := range []string{templatePath, b.cfg.BuildFolder} {
+		exists, err := dirExists(filepath.Join(dir, "node_modules"))
+		if err != nil || exists {
+			return err
+		}
+	}
+	_, err := b.executor.Run(ctx, filepath.Dir(templatePath), "pnpm i")
+	if err != nil {
+		return fmt.Errorf("failed to install dependencies, %w", err)
+	}
+
+	return nil
+}
+
+// templatesDir - path of the templates folder relative to the build folder
+func (b *TemplateBuild) templatesDir() string {
+	rel, err := filepath.Rel(b.cfg.BuildFolder, b.cfg.TemplatesFolder)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "templates"
+	}
+	return rel
+}
+
+// populate links entries of the build folder, except the templates folder, and copies the template into the workspace
+func (b *TemplateBuild) populate(ws *Workspace, templatePath string) error {
+	templatesRoot := strings.Split(filepath.ToSlash(b.templatesDir()), "/")[0]
+	entries, err := os.ReadDir(b.cfg.BuildFolder)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == templatesRoot {
+			continue
+		}
+		err = os.Symlink(filepath.Join(b.cfg.BuildFolder, entry.Name()), filepath.Join(ws.root, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("err linking %v to workspace, %v", entry.Name(), err)
+		}
+	}
+
+	err = copyDir(templatePath, ws.Path)
+	if err != nil {
+		return fmt.Errorf("err copying template to workspace, %v", err)
+	}
+	// dependencies can be hoisted to node_modules of the build folder, that is linked above
+	exists, err := dirExists(filepath.Join(templatePath, "node_modules"))
+	if err != nil || !exists {
+		return err
+	}
+	err = os.Symlink(filepath.Join(templatePath, "node_modules"), filepath.Join(ws.Path, "node_modules"))
+	if err != nil {
+		return fmt.Errorf("err linking node_modules to workspace, %v", err)
+	}
+
+	return nil
+}
+
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel != "." && filepath.Dir(rel) == "." && workspaceSkip[d.Name()] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target)
+		}
+	})
+}
+
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
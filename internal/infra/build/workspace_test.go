@@ -0,0 +1,58 @@
+package build_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/build"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewWorkspace_When_Template_Is_Cached_Then_Copy_Sources_And_Link_Dependencies(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.ProvisionConfig{
+		BuildFolder:      filepath.Join(root, "templates-repo"),
+		TemplatesFolder:  filepath.Join(root, "templates-repo", "templates"),
+		WorkspacesFolder: filepath.Join(root, "builds"),
+		Filename:         "pages.json",
+	}
+	templatePath := filepath.Join(cfg.TemplatesFolder, "landing")
+	require.NoError(t, os.MkdirAll(filepath.Join(templatePath, "src"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(templatePath, "node_modules", "astro"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(templatePath, "dist"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(templatePath, "package.json"), []byte("{}"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(templatePath, "src", "index.astro"), []byte("---"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(templatePath, "pages.json"), []byte(`{"cached":true}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.BuildFolder, "tsconfig.base.json"), []byte("{}"), 0o644))
+	SUT := build.NewTemplateBuild(nil, cfg, nil)
+
+	first, err := SUT.NewWorkspace(context.Background(), "landing")
+	require.NoError(t, err)
+	second, err := SUT.NewWorkspace(context.Background(), "landing")
+	require.NoError(t, err)
+
+	require.NotEqual(t, first.Path, second.Path)
+	require.FileExists(t, filepath.Join(first.Path, "src", "index.astro"))
+	require.NoDirExists(t, filepath.Join(first.Path, "dist"))
+	require.FileExists(t, filepath.Join(first.Path, "..", "..", "tsconfig.base.json"))
+	link, err := os.Readlink(filepath.Join(first.Path, "node_modules"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(templatePath, "node_modules"), link)
+
+	require.NoError(t, os.WriteFile(filepath.Join(first.Path, "pages.json"), []byte(`{"site":1}`), 0o644))
+	cached, err := os.ReadFile(filepath.Join(templatePath, "pages.json"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"cached":true}`, string(cached))
+	copied, err := os.ReadFile(filepath.Join(second.Path, "pages.json"))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"cached":true}`, string(copied))
+
+	require.NoError(t, first.Remove())
+	require.NoDirExists(t, first.Path)
+	require.DirExists(t, second.Path)
+	require.NoError(t, SUT.CleanWorkspaces(context.Background()))
+	require.NoDirExists(t, second.Path)
+}
@@ -2,12 +2,26 @@ package certs
 
 import (
 	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/acm"
 	"github.com/aws/aws-sdk-go-v2/service/acm/types"
 )
 
+// Manager issues certificates for separate domains of sites
+type Manager interface {
+	// CreateCertificate requests a certificate for domain and returns its arn, it's validated through dns
+	CreateCertificate(ctx context.Context, domain string) (string, error)
+	// DeleteCertificate is a no-op for a missing certificate, RetryableError while it's used by a distribution
+	DeleteCertificate(ctx context.Context, arn string) error
+}
+
+var _ Manager = (*ACMCertificates)(nil)
+
 type ACMCertificates struct {
 	client *acm.Client
 }
@@ -37,3 +51,21 @@ func (a *ACMCertificates) CreateCertificate(ctx context.Context, domain string)
 
 	return aws.ToString(res.CertificateArn), nil
 }
+
+// DeleteCertificate is a no-op for a missing certificate, certificate used by a distribution can be deleted only after it
+func (a *ACMCertificates) DeleteCertificate(ctx context.Context, arn string) error {
+	_, err := a.client.DeleteCertificate(ctx, &acm.DeleteCertificateInput{CertificateArn: aws.String(arn)})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		var inUse *types.ResourceInUseException
+		if errors.As(err, &inUse) {
+			return errs.RetryableError{Err: fmt.Errorf("certificate %v is still in use, %v", arn, err)}
+		}
+		return fmt.Errorf("err deleting certificate, %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/certs"
+)
+
+// Certificates - in-memory certs.Manager, arns are numbered in order of creation
+type Certificates struct {
+	mu      sync.Mutex
+	seq     int
+	domains map[string]string
+	inUse   map[string]bool
+	// failures - errors returned by the next calls, one per call in order
+	failures []error
+}
+
+var _ certs.Manager = (*Certificates)(nil)
+
+func NewCertificates() *Certificates {
+	return &Certificates{
+		domains: make(map[string]string),
+		inUse:   make(map[string]bool),
+	}
+}
+
+// Fail makes the next calls return errs, one per call in order
+func (c *Certificates) Fail(errs ...error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures = append(c.failures, errs...)
+}
+
+// SetInUse - certificate is used by a distribution, so it can't be deleted
+func (c *Certificates) SetInUse(arn string, inUse bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inUse[arn] = inUse
+}
+
+// Domain returns domain of an existing certificate
+func (c *Certificates) Domain(arn string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	domain, ok := c.domains[arn]
+	return domain, ok
+}
+
+func (c *Certificates) CreateCertificate(ctx context.Context, domain string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.failure(); err != nil {
+		return "", err
+	}
+	c.seq++
+	arn := fmt.Sprintf("arn:aws:acm:us-east-1:000000000000:certificate/%06d", c.seq)
+	c.domains[arn] = domain
+
+	return arn, nil
+}
+
+func (c *Certificates) DeleteCertificate(ctx context.Context, arn string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.failure(); err != nil {
+		return err
+	}
+	if c.inUse[arn] {
+		return errs.RetryableError{Err: fmt.Errorf("certificate %v is still in use", arn)}
+	}
+	delete(c.domains, arn)
+
+	return nil
+}
+
+// failure has to be called with mu held
+func (c *Certificates) failure() error {
+	if len(c.failures) == 0 {
+		return nil
+	}
+	err := c.failures[0]
+	c.failures = c.failures[1:]
+	return err
+}
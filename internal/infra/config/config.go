@@ -1,8 +1,10 @@
 package config
 
 import (
+	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/Builder-Lawyers/builder-backend/pkg/env"
 )
@@ -10,13 +12,18 @@ import (
 type ProvisionConfig struct {
 	BuildFolder             string
 	TemplatesFolder         string
+	WorkspacesFolder        string
 	S3ObjectURL             string
 	TemplateSrcBucketPath   string
 	TemplateBuildBucketPath string
 	PathToFile              string
 	Filename                string
 	BaseDomain              string
-	Defaults                *Defaults
+	// DeletionGracePeriod - how long a deactivated site is kept, before its resources are removed
+	DeletionGracePeriod time.Duration
+	// PreviewTTL - how long a preview of site's draft is served, before it's removed
+	PreviewTTL time.Duration
+	Defaults   *Defaults
 }
 
 type Defaults struct {
@@ -35,12 +42,15 @@ func NewProvisionConfig() ProvisionConfig {
 	return ProvisionConfig{
 		BuildFolder:             env.GetEnv("P_BUILD_FOLDER", buildFolder),
 		TemplatesFolder:         env.GetEnv("P_TEMPLATES_FOLDER", templatesFolder),
+		WorkspacesFolder:        env.GetEnv("P_WORKSPACES_FOLDER", filepath.Join(parent, "builds")),
 		S3ObjectURL:             os.Getenv("P_S3_OBJECT_URL"),
 		TemplateSrcBucketPath:   env.GetEnv("P_SRC_BUCKET_PATH", "templates-sources/"),
 		TemplateBuildBucketPath: env.GetEnv("P_BUILD_BUCKET_PATH", "templates-builds/"),
 		PathToFile:              env.GetEnv("P_PATH_TO_FILE", ""),
 		Filename:                env.GetEnv("P_FILENAME", "pages.json"),
 		BaseDomain:              os.Getenv("P_BASE_DOMAIN"),
+		DeletionGracePeriod:     getDuration("P_DELETION_GRACE_PERIOD", 30*24*time.Hour),
+		PreviewTTL:              getDuration("P_PREVIEW_TTL", 24*time.Hour),
 		Defaults:                NewDefaults(),
 	}
 }
@@ -51,3 +61,12 @@ func NewDefaults() *Defaults {
 		os.Getenv("P_DEFAULT_CERT_ARN"),
 	}
 }
+
+func getDuration(key string, defaultVal time.Duration) time.Duration {
+	value, err := time.ParseDuration(env.GetEnv(key, defaultVal.String()))
+	if err != nil {
+		slog.Warn("invalid duration, using default", "key", key, "default", defaultVal)
+		return defaultVal
+	}
+	return value
+}
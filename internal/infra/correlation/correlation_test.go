@@ -0,0 +1,32 @@
+package correlation_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/correlation"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LogHandler_When_Context_Has_Correlation_ID_Then_Record_Contains_It(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(correlation.NewLogHandler(slog.NewTextHandler(&buf, nil))).With("component", "test")
+
+	logger.InfoContext(correlation.WithID(context.Background(), "abc-123"), "handled")
+	logger.InfoContext(context.Background(), "uncorrelated")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "correlationID=abc-123")
+	require.NotContains(t, lines[1], "correlationID")
+}
+
+func Test_Valid_Rejects_Empty_Long_And_Unsafe_IDs(t *testing.T) {
+	require.True(t, correlation.Valid(correlation.NewID()))
+	require.False(t, correlation.Valid(""))
+	require.False(t, correlation.Valid(strings.Repeat("a", 65)))
+	require.False(t, correlation.Valid("id\nforged=log"))
+}
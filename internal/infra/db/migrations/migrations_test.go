@@ -0,0 +1,14 @@
+package migrations_test
+
+import (
+	"testing"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/migrations"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewMigrator_When_Embedded_Migrations_Are_Loaded_Then_Each_Has_Up_And_Down(t *testing.T) {
+	_, err := migrations.NewMigrator(nil)
+
+	require.NoError(t, err)
+}
@@ -0,0 +1,101 @@
+CREATE SCHEMA IF NOT EXISTS builder;
+
+CREATE TABLE IF NOT EXISTS builder.sites (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    template_id SMALLINT NOT NULL,
+    creator_id UUID NOT NULL,
+    plan_id SMALLINT NOT NULL,
+    subscription_id VARCHAR(60),
+    status VARCHAR(30) NOT NULL,
+    fields JSONB,
+    file_id UUID,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS builder.users (
+    id UUID PRIMARY KEY,
+    stripe_id VARCHAR(60),
+    status VARCHAR(60),
+    first_name varchar(100),
+    second_name varchar(100),
+    email varchar(100) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS builder.user_identities (
+    id UUID,
+    provider VARCHAR(30) NOT NULL,
+    sub VARCHAR(200) NOT NULL,
+    PRIMARY KEY(provider,sub)
+);
+
+CREATE TABLE IF NOT EXISTS builder.templates (
+    id INTEGER GENERATED ALWAYS AS IDENTITY,
+    name VARCHAR(100) NOT NULL,
+    fields JSONB,
+    styles VARCHAR(255),
+    preview VARCHAR(255),
+    file_id UUID
+);
+
+CREATE TABLE IF NOT EXISTS builder.outbox (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    event VARCHAR(200) NOT NULL,
+    status SMALLINT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS builder.provisions (
+    site_id BIGINT PRIMARY KEY,
+    "type" VARCHAR(40) NOT NULL,
+    status VARCHAR(40) NOT NULL,
+    domain VARCHAR(80),
+    cert_arn VARCHAR(120),
+    cloudfront_id VARCHAR(60),
+    structure_path TEXT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS builder.mails (
+    id INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    "type" VARCHAR(60) NOT NULL,
+    recipients VARCHAR(255) NOT NULL,
+    subject VARCHAR(100),
+    content TEXT,
+    sent_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS builder.mail_templates (
+    id SMALLINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    "type" VARCHAR(60) NOT NULL,
+    content TEXT
+);
+
+CREATE TABLE IF NOT EXISTS builder.payment_plans (
+    id SMALLINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    stripe_id VARCHAR(60) NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL,
+    features JSONB NOT NULL,
+    price INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS builder.sessions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    refresh_token TEXT,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS builder.confirmation_codes (
+    code UUID PRIMARY KEY,
+    sub_id VARCHAR(200) NOT NULL,
+    email VARCHAR(100),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS builder.files (
+    id UUID PRIMARY KEY
+);
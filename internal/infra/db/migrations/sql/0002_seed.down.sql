@@ -0,0 +1,5 @@
+DELETE FROM builder.mail_templates WHERE "type" IN ('FreeTrialEnds', 'SiteCreated', 'SiteDeactivated', 'RegistrationConfirm');
+DELETE FROM builder.payment_plans WHERE stripe_id IN ('price_1S2g3TBUqUlKX6nYFU5mN5HW', 'price_1S3d1JBUqUlKX6nYewiReS7I');
+DELETE FROM builder.templates WHERE name IN ('template-v1', 'template-v2');
+DELETE FROM builder.user_identities WHERE id = '421804b8-6271-7049-7034-8853ffd88056';
+DELETE FROM builder.users WHERE id = '421804b8-6271-7049-7034-8853ffd88056';
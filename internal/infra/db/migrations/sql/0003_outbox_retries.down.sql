@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS builder.outbox_pending_idx;
+
+ALTER TABLE builder.outbox
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS last_error;
@@ -0,0 +1,6 @@
+ALTER TABLE builder.outbox
+    ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS last_error TEXT;
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON builder.outbox (next_attempt_at) WHERE status IN (0, 3);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS builder.outbox_leased_idx;
+
+ALTER TABLE builder.outbox
+    DROP COLUMN IF EXISTS locked_by,
+    DROP COLUMN IF EXISTS locked_until;
@@ -0,0 +1,5 @@
+ALTER TABLE builder.outbox
+    ADD COLUMN IF NOT EXISTS locked_by VARCHAR(120),
+    ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS outbox_leased_idx ON builder.outbox (locked_until) WHERE status = 1;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS builder.outbox_audit;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS builder.outbox_audit (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    event_id BIGINT NOT NULL,
+    action VARCHAR(30) NOT NULL,
+    actor_id UUID NOT NULL,
+    previous_status SMALLINT,
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS outbox_audit_event_idx ON builder.outbox_audit (event_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS builder.outbox_correlation_idx;
+
+ALTER TABLE builder.outbox DROP COLUMN IF EXISTS correlation_id;
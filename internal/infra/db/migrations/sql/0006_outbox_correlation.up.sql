@@ -0,0 +1,3 @@
+ALTER TABLE builder.outbox ADD COLUMN IF NOT EXISTS correlation_id VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS outbox_correlation_idx ON builder.outbox (correlation_id);
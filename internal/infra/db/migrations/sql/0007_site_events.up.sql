@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS builder.site_events (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    site_id BIGINT NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    from_status VARCHAR(30),
+    to_status VARCHAR(30) NOT NULL,
+    actor VARCHAR(20) NOT NULL,
+    actor_id UUID,
+    reason TEXT,
+    correlation_id VARCHAR(64),
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS site_events_site_idx ON builder.site_events (site_id, id);
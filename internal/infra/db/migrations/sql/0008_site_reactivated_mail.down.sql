@@ -0,0 +1 @@
+DELETE FROM builder.mail_templates WHERE "type" = 'SiteReactivated';
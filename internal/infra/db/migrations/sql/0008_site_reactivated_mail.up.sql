@@ -0,0 +1 @@
+insert into builder.mail_templates(type, content) VALUES ('SiteReactivated', '<!doctype html><html lang="en"><head><meta charset="utf-8"><title>Site Reactivated</title><meta name="viewport" content="width=device-width,initial-scale=1.0"/></head><body style="margin:0;padding:0;background-color:#f4f6f8;font-family:Arial,Helvetica,sans-serif;"><table width="100%" cellpadding="0" cellspacing="0" role="presentation"><tr><td align="center" style="padding:20px 12px;"><table width="600" cellpadding="0" cellspacing="0" role="presentation" style="background:#ffffff;border-radius:8px;overflow:hidden;box-shadow:0 2px 6px rgba(0,0,0,0.08);"><tr><td style="padding:24px 28px;background:#16a34a;color:#ffffff;"><h1 style="margin:0;font-size:20px;font-weight:700;">Site Reactivated</h1></td></tr><tr><td style="padding:28px;"><p style="margin:0 0 16px 0;color:#0f172a;font-size:15px;line-height:1.5;">Hi {{if .CustomerFirstName}}{{.CustomerFirstName}}{{end}}{{if .CustomerSecondName}} {{.CustomerSecondName}}{{end}},</p><p style="margin:0 0 16px 0;color:#334155;font-size:15px;line-height:1.5;">Your site <a href="{{.SiteURL}}" style="color:#2563eb;text-decoration:none;">{{.SiteURL}}</a> is <strong>active</strong> again and available to your visitors.</p><p style="margin:0 0 18px 0;color:#334155;font-size:15px;line-height:1.5;">DNS changes may take a few minutes to reach everyone.</p><hr style="border:none;border-top:1px solid #e6eef6;margin:22px 0;"/><p style="margin:0;color:#64748b;font-size:13px;line-height:1.4;">Need help? Contact our support at <a href="mailto:support@example.com" style="color:#2563eb;text-decoration:none;">support@example.com</a>.</p></td></tr><tr><td style="padding:18px 28px;background:#f8fafc;color:#94a3b8;font-size:12px;text-align:center;"><div>© {{.Year}} Lawyers-Builder. All rights reserved.</div></td></tr></table></td></tr></table></body></html>');
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS builder.sites_creator_idx;
+
+ALTER TABLE builder.payment_plans DROP COLUMN IF EXISTS max_sites;
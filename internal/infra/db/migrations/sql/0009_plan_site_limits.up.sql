@@ -0,0 +1,3 @@
+ALTER TABLE builder.payment_plans ADD COLUMN IF NOT EXISTS max_sites SMALLINT NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS sites_creator_idx ON builder.sites (creator_id, id);
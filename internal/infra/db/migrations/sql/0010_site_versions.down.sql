@@ -0,0 +1,3 @@
+ALTER TABLE builder.sites DROP COLUMN IF EXISTS published_version;
+
+DROP TABLE IF EXISTS builder.site_versions;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS builder.site_versions (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    site_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    fields JSONB NOT NULL,
+    prefix TEXT NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (site_id, version)
+);
+
+ALTER TABLE builder.sites ADD COLUMN IF NOT EXISTS published_version INT;
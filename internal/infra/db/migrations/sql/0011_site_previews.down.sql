@@ -0,0 +1 @@
+DROP TABLE IF EXISTS builder.site_previews;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS builder.site_previews (
+    token VARCHAR(32) PRIMARY KEY,
+    site_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    prefix TEXT NOT NULL,
+    domain TEXT NOT NULL,
+    cloudfront_id VARCHAR(64) NOT NULL,
+    cloudfront_domain TEXT,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS site_previews_site_idx ON builder.site_previews (site_id, created_at);
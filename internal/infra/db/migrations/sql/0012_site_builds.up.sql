@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS builder.site_builds (
+    id UUID PRIMARY KEY,
+    site_id BIGINT NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    step VARCHAR(50),
+    error TEXT,
+    attempts INT NOT NULL DEFAULT 0,
+    version INT,
+    preview_token VARCHAR(32),
+    created_by UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS site_builds_site_idx ON builder.site_builds (site_id, status);
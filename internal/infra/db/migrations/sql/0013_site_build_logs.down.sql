@@ -0,0 +1,3 @@
+ALTER TABLE builder.site_builds
+    DROP COLUMN IF EXISTS log,
+    DROP COLUMN IF EXISTS cancel_requested;
@@ -0,0 +1,3 @@
+ALTER TABLE builder.site_builds
+    ADD COLUMN IF NOT EXISTS log TEXT,
+    ADD COLUMN IF NOT EXISTS cancel_requested BOOLEAN NOT NULL DEFAULT FALSE;
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"database/sql"
 	"encoding/json"
 	"time"
 
@@ -16,33 +17,126 @@ type Site struct {
 	PlanID         uint8             `db:"plan_id"`
 	SubscriptionID string            `db:"subscription_id"`
 	Status         consts.SiteStatus `db:"status"`
-	Fields         json.RawMessage   `db:"fields"`
-	CreatedAt      time.Time         `db:"created_at"`
-	UpdatedAt      time.Time         `db:"updated_at,omitempty"`
+	// Fields - draft of site's content, published content is kept in site's versions
+	Fields    json.RawMessage `db:"fields"`
+	FileID    *uuid.UUID      `db:"file_id"`
+	CreatedAt time.Time       `db:"created_at"`
+	UpdatedAt time.Time       `db:"updated_at,omitempty"`
+	// PublishedVersion - version served by site's distribution, 0 when nothing was published yet
+	PublishedVersion int `db:"published_version"`
+}
+
+// SiteVersion - immutable snapshot of published fields, Prefix points to its build on s3
+type SiteVersion struct {
+	ID        uint64          `db:"id"`
+	SiteID    uint64          `db:"site_id"`
+	Version   int             `db:"version"`
+	Fields    json.RawMessage `db:"fields"`
+	Prefix    string          `db:"prefix"`
+	CreatedBy *uuid.UUID      `db:"created_by"`
+	CreatedAt time.Time       `db:"created_at"`
+}
+
+// SitePreview - temporary build of site's draft, served by its own distribution on Domain until ExpiresAt
+type SitePreview struct {
+	Token            string               `db:"token"`
+	SiteID           uint64               `db:"site_id"`
+	Status           consts.PreviewStatus `db:"status"`
+	Prefix           string               `db:"prefix"`
+	Domain           string               `db:"domain"`
+	CloudfrontID     string               `db:"cloudfront_id"`
+	CloudfrontDomain string               `db:"cloudfront_domain"`
+	CreatedBy        uuid.UUID            `db:"created_by"`
+	CreatedAt        time.Time            `db:"created_at"`
+	ExpiresAt        time.Time            `db:"expires_at"`
+}
+
+// SiteBuild - job building site's draft, Version or PreviewToken is set, when it succeeds.
+// Output of the last failed attempt is stored separately, it isn't loaded with the build
+type SiteBuild struct {
+	ID              uuid.UUID          `db:"id"`
+	SiteID          uint64             `db:"site_id"`
+	Kind            consts.BuildKind   `db:"kind"`
+	Status          consts.BuildStatus `db:"status"`
+	Step            string             `db:"step"`
+	Error           string             `db:"error"`
+	Attempts        int                `db:"attempts"`
+	Version         int                `db:"version"`
+	PreviewToken    string             `db:"preview_token"`
+	CancelRequested bool               `db:"cancel_requested"`
+	CreatedBy       uuid.UUID          `db:"created_by"`
+	CreatedAt       time.Time          `db:"created_at"`
+	UpdatedAt       time.Time          `db:"updated_at"`
+	FinishedAt      *time.Time         `db:"finished_at"`
+}
+
+// SiteEvent - entry of site's history, from/to are site or provision statuses or published versions depending on kind
+type SiteEvent struct {
+	ID            uint64               `db:"id"`
+	SiteID        uint64               `db:"site_id"`
+	Kind          consts.SiteEventKind `db:"kind"`
+	FromStatus    string               `db:"from_status"`
+	ToStatus      string               `db:"to_status"`
+	Actor         consts.Actor         `db:"actor"`
+	ActorID       *uuid.UUID           `db:"actor_id"`
+	Reason        string               `db:"reason"`
+	CorrelationID string               `db:"correlation_id"`
+	CreatedAt     time.Time            `db:"created_at"`
 }
 
 type User struct {
-	ID         uuid.UUID `db:"id"`
-	StripeID   string    `db:"stripe_id"`
-	FirstName  string    `db:"first_name"`
-	SecondName string    `db:"second_name"`
-	Email      string    `db:"email"`
-	CreatedAt  time.Time `db:"created_at,omitempty"`
+	ID         uuid.UUID         `db:"id"`
+	StripeID   string            `db:"stripe_id"`
+	Status     consts.UserStatus `db:"status"`
+	FirstName  string            `db:"first_name"`
+	SecondName string            `db:"second_name"`
+	Email      string            `db:"email"`
+	CreatedAt  time.Time         `db:"created_at,omitempty"`
+}
+
+type UserIdentity struct {
+	UserID   uuid.UUID `db:"id"`
+	Provider string    `db:"provider"`
+	Sub      string    `db:"sub"`
+}
+
+type ConfirmationCode struct {
+	Code      uuid.UUID `db:"code"`
+	SubID     string    `db:"sub_id"`
+	Email     string    `db:"email"`
+	ExpiresAt time.Time `db:"expires_at"`
 }
 
 type Template struct {
-	ID      uint8  `db:"id"`
-	Name    string `db:"name"`
-	Styles  string `db:"styles"`
-	Preview string `db:"preview"`
+	ID      uint8           `db:"id"`
+	Name    string          `db:"name"`
+	Fields  json.RawMessage `db:"fields"`
+	Styles  string          `db:"styles"`
+	Preview string          `db:"preview"`
 }
 
 type Outbox struct {
-	ID        uint64          `db:"id"`
-	Event     string          `db:"event"`
-	Status    int             `db:"status"`
-	Payload   json.RawMessage `db:"payload"`
-	CreatedAt time.Time       `db:"created_at"`
+	ID            uint64          `db:"id"`
+	Event         string          `db:"event"`
+	Status        int             `db:"status"`
+	Payload       json.RawMessage `db:"payload"`
+	Attempts      int             `db:"attempts"`
+	NextAttemptAt time.Time       `db:"next_attempt_at"`
+	LastError     sql.NullString  `db:"last_error"`
+	LockedBy      sql.NullString  `db:"locked_by"`
+	LockedUntil   sql.NullTime    `db:"locked_until"`
+	CorrelationID sql.NullString  `db:"correlation_id"`
+	CreatedAt     time.Time       `db:"created_at"`
+}
+
+type OutboxAudit struct {
+	ID             uint64         `db:"id"`
+	EventID        uint64         `db:"event_id"`
+	Action         string         `db:"action"`
+	ActorID        uuid.UUID      `db:"actor_id"`
+	PreviousStatus sql.NullInt16  `db:"previous_status"`
+	Reason         sql.NullString `db:"reason"`
+	CreatedAt      time.Time      `db:"created_at"`
 }
 
 type Provision struct {
@@ -73,10 +167,13 @@ type MailTemplates struct {
 }
 
 type PaymentPlan struct {
-	ID          uint8  `db:"id"`
-	StripeID    string `db:"stripe_id"`
-	Description string `db:"description"`
-	Price       int    `db:"price"`
+	ID          uint8           `db:"id"`
+	StripeID    string          `db:"stripe_id"`
+	Description string          `db:"description"`
+	Features    json.RawMessage `db:"features"`
+	Price       int             `db:"price"`
+	// MaxSites - how many not deleted sites on the plan a user may have
+	MaxSites int `db:"max_sites"`
 }
 
 type Session struct {
@@ -0,0 +1,41 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/jackc/pgx/v5"
+)
+
+type MailRepo struct {
+	tx pgx.Tx
+}
+
+var _ interfaces.MailRepo = (*MailRepo)(nil)
+
+func NewMailRepo(tx pgx.Tx) *MailRepo {
+	return &MailRepo{tx: tx}
+}
+
+func (r *MailRepo) GetMailTemplate(ctx context.Context, mailType string) (*db.MailTemplates, error) {
+	var template db.MailTemplates
+	err := r.tx.QueryRow(ctx, "SELECT id, type, COALESCE(content, '') FROM builder.mail_templates WHERE type = $1", mailType).
+		Scan(&template.ID, &template.Type, &template.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+func (r *MailRepo) InsertMail(ctx context.Context, mail db.Mail) (uint64, error) {
+	err := r.tx.QueryRow(ctx, "INSERT INTO builder.mails(type, recipients, subject, content, sent_at) VALUES ($1,$2,$3,$4,$5) RETURNING id",
+		mail.MailType, mail.Recipients, mail.Subject, mail.Content, mail.SentAt).Scan(&mail.ID)
+	if err != nil {
+		return 0, fmt.Errorf("err inserting mail, %v", err)
+	}
+
+	return mail.ID, nil
+}
@@ -0,0 +1,727 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Store keeps all aggregates in memory and implements repositories on top of them, for unit tests of commands
+type Store struct {
+	mu sync.Mutex
+
+	SitesByID           map[uint64]db.Site
+	SiteHistory         []db.SiteEvent
+	PublishedVersions   []db.SiteVersion
+	PreviewsByToken     map[string]db.SitePreview
+	BuildsByID          map[uuid.UUID]db.SiteBuild
+	BuildLogs           map[uuid.UUID]string
+	UsersByID           map[uuid.UUID]db.User
+	Identities          []db.UserIdentity
+	ConfirmationCodes   map[uuid.UUID]db.ConfirmationCode
+	TemplatesByID       map[uint8]db.Template
+	SessionsByID        map[uuid.UUID]db.Session
+	MailTemplatesByType map[string]db.MailTemplates
+	SentMails           []db.Mail
+	PlansByID           map[uint8]db.PaymentPlan
+	ProvisionsBySite    map[uint64]db.Provision
+	InsertedEvents      []shared.Event
+
+	lastSiteID      uint64
+	lastSiteEventID uint64
+	lastVersionID   uint64
+	lastTemplateID  uint8
+}
+
+var _ interfaces.Repositories = (*Store)(nil)
+
+func NewStore() *Store {
+	return &Store{
+		SitesByID:           make(map[uint64]db.Site),
+		UsersByID:           make(map[uuid.UUID]db.User),
+		ConfirmationCodes:   make(map[uuid.UUID]db.ConfirmationCode),
+		TemplatesByID:       make(map[uint8]db.Template),
+		SessionsByID:        make(map[uuid.UUID]db.Session),
+		MailTemplatesByType: make(map[string]db.MailTemplates),
+		PlansByID:           make(map[uint8]db.PaymentPlan),
+		PreviewsByToken:     make(map[string]db.SitePreview),
+		BuildsByID:          make(map[uuid.UUID]db.SiteBuild),
+		BuildLogs:           make(map[uuid.UUID]string),
+		ProvisionsBySite:    make(map[uint64]db.Provision),
+	}
+}
+
+func (s *Store) Sites(tx pgx.Tx) interfaces.SiteRepo {
+	return siteRepo{s}
+}
+
+func (s *Store) SiteEvents(tx pgx.Tx) interfaces.SiteEventRepo {
+	return siteEventRepo{s}
+}
+
+func (s *Store) SiteVersions(tx pgx.Tx) interfaces.SiteVersionRepo {
+	return siteVersionRepo{s}
+}
+
+func (s *Store) SitePreviews(tx pgx.Tx) interfaces.SitePreviewRepo {
+	return sitePreviewRepo{s}
+}
+
+func (s *Store) SiteBuilds(tx pgx.Tx) interfaces.SiteBuildRepo {
+	return siteBuildRepo{s}
+}
+
+func (s *Store) Users(tx pgx.Tx) interfaces.UserRepo {
+	return userRepo{s}
+}
+
+func (s *Store) Templates(tx pgx.Tx) interfaces.TemplateRepo {
+	return templateRepo{s}
+}
+
+func (s *Store) Sessions(tx pgx.Tx) interfaces.SessionRepo {
+	return sessionRepo{s}
+}
+
+func (s *Store) Mails(tx pgx.Tx) interfaces.MailRepo {
+	return mailRepo{s}
+}
+
+func (s *Store) Plans(tx pgx.Tx) interfaces.PlanRepo {
+	return planRepo{s}
+}
+
+func (s *Store) Provisions(tx pgx.Tx) interfaces.ProvisionRepo {
+	return provisionRepo{s}
+}
+
+func (s *Store) Events(tx pgx.Tx) interfaces.EventRepo {
+	return eventRepo{s}
+}
+
+type siteRepo struct{ s *Store }
+
+func (r siteRepo) GetSite(ctx context.Context, id uint64) (*db.Site, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	site, ok := r.s.SitesByID[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &site, nil
+}
+
+func (r siteRepo) GetSiteBySubscription(ctx context.Context, subscriptionID string) (*db.Site, error) {
+	return r.find(func(site db.Site) bool { return site.SubscriptionID == subscriptionID })
+}
+
+func (r siteRepo) GetFirstSiteOfCreator(ctx context.Context, creatorID uuid.UUID) (*db.Site, error) {
+	return r.find(func(site db.Site) bool { return site.CreatorID == creatorID })
+}
+
+// LockCreator - store is locked by every call, so there is nothing to serialize
+func (r siteRepo) LockCreator(ctx context.Context, creatorID uuid.UUID) error {
+	return nil
+}
+
+func (r siteRepo) ListSites(ctx context.Context, filter interfaces.SiteFilter, limit, offset int) ([]db.Site, error) {
+	sites := r.filter(filter)
+	sort.Slice(sites, func(i, j int) bool { return sites[i].ID > sites[j].ID })
+	if offset >= len(sites) {
+		return nil, nil
+	}
+	return sites[offset:min(offset+limit, len(sites))], nil
+}
+
+func (r siteRepo) CountSites(ctx context.Context, filter interfaces.SiteFilter) (int, error) {
+	return len(r.filter(filter)), nil
+}
+
+func (r siteRepo) filter(filter interfaces.SiteFilter) []db.Site {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var sites []db.Site
+	for _, site := range r.s.SitesByID {
+		if filter.CreatorID != uuid.Nil && site.CreatorID != filter.CreatorID ||
+			filter.PlanID != 0 && site.PlanID != filter.PlanID ||
+			filter.Status != "" && site.Status != filter.Status ||
+			filter.Status == "" && site.Status == consts.SiteStatusDeleted {
+			continue
+		}
+		sites = append(sites, site)
+	}
+	return sites
+}
+
+func (r siteRepo) InsertSite(ctx context.Context, site db.Site) (uint64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.lastSiteID++
+	site.ID = r.s.lastSiteID
+	r.s.SitesByID[site.ID] = site
+	return site.ID, nil
+}
+
+func (r siteRepo) UpdateSiteStatus(ctx context.Context, id uint64, status consts.SiteStatus) error {
+	return r.update(id, func(site *db.Site) { site.Status = status })
+}
+
+func (r siteRepo) UpdateSiteContent(ctx context.Context, id uint64, fields json.RawMessage, fileID *uuid.UUID) error {
+	return r.update(id, func(site *db.Site) {
+		if fields != nil {
+			site.Fields = fields
+		}
+		if fileID != nil {
+			site.FileID = fileID
+		}
+	})
+}
+
+func (r siteRepo) UpdateSiteSubscription(ctx context.Context, id uint64, subscriptionID string) error {
+	return r.update(id, func(site *db.Site) { site.SubscriptionID = subscriptionID })
+}
+
+func (r siteRepo) UpdateSitePublishedVersion(ctx context.Context, id uint64, version int) error {
+	return r.update(id, func(site *db.Site) { site.PublishedVersion = version })
+}
+
+// find returns the matching site with the lowest id
+func (r siteRepo) find(match func(site db.Site) bool) (*db.Site, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var found *db.Site
+	for _, site := range r.s.SitesByID {
+		if match(site) && (found == nil || site.ID < found.ID) {
+			found = &site
+		}
+	}
+	if found == nil {
+		return nil, pgx.ErrNoRows
+	}
+	return found, nil
+}
+
+// update mirrors UPDATE statements, which don't fail when nothing matches
+func (r siteRepo) update(id uint64, apply func(site *db.Site)) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	site, ok := r.s.SitesByID[id]
+	if !ok {
+		return nil
+	}
+	apply(&site)
+	site.UpdatedAt = time.Now()
+	r.s.SitesByID[id] = site
+	return nil
+}
+
+type userRepo struct{ s *Store }
+
+func (r userRepo) GetUser(ctx context.Context, id uuid.UUID) (*db.User, error) {
+	return r.find(func(user db.User) bool { return user.ID == id })
+}
+
+func (r userRepo) GetUserByEmail(ctx context.Context, email string) (*db.User, error) {
+	return r.find(func(user db.User) bool { return user.Email == email })
+}
+
+func (r userRepo) GetUserByStripeID(ctx context.Context, stripeID string) (*db.User, error) {
+	return r.find(func(user db.User) bool { return user.StripeID != "" && user.StripeID == stripeID })
+}
+
+func (r userRepo) InsertUser(ctx context.Context, user db.User) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.UsersByID[user.ID] = user
+	return nil
+}
+
+func (r userRepo) UpdateUserStatus(ctx context.Context, id uuid.UUID, status consts.UserStatus) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if user, ok := r.s.UsersByID[id]; ok {
+		user.Status = status
+		r.s.UsersByID[id] = user
+	}
+	return nil
+}
+
+func (r userRepo) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.UsersByID, id)
+	return nil
+}
+
+func (r userRepo) GetUserIDByIdentity(ctx context.Context, provider, sub string) (uuid.UUID, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, identity := range r.s.Identities {
+		if identity.Provider == provider && identity.Sub == sub {
+			return identity.UserID, nil
+		}
+	}
+	return uuid.UUID{}, pgx.ErrNoRows
+}
+
+func (r userRepo) GetIdentities(ctx context.Context, userID uuid.UUID) ([]db.UserIdentity, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var identities []db.UserIdentity
+	for _, identity := range r.s.Identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+func (r userRepo) InsertIdentity(ctx context.Context, identity db.UserIdentity) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.Identities = append(r.s.Identities, identity)
+	return nil
+}
+
+func (r userRepo) DeleteIdentities(ctx context.Context, userID uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	kept := r.s.Identities[:0]
+	for _, identity := range r.s.Identities {
+		if identity.UserID != userID {
+			kept = append(kept, identity)
+		}
+	}
+	r.s.Identities = kept
+	return nil
+}
+
+func (r userRepo) GetConfirmationCode(ctx context.Context, code uuid.UUID) (*db.ConfirmationCode, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	confirmation, ok := r.s.ConfirmationCodes[code]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &confirmation, nil
+}
+
+func (r userRepo) InsertConfirmationCode(ctx context.Context, code db.ConfirmationCode) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.ConfirmationCodes[code.Code] = code
+	return nil
+}
+
+func (r userRepo) DeleteConfirmationCode(ctx context.Context, code uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.ConfirmationCodes, code)
+	return nil
+}
+
+func (r userRepo) find(match func(user db.User) bool) (*db.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, user := range r.s.UsersByID {
+		if match(user) {
+			return &user, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+type templateRepo struct{ s *Store }
+
+func (r templateRepo) GetTemplate(ctx context.Context, id uint8) (*db.Template, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	template, ok := r.s.TemplatesByID[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &template, nil
+}
+
+func (r templateRepo) GetTemplateByName(ctx context.Context, name string) (*db.Template, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, template := range r.s.TemplatesByID {
+		if template.Name == name {
+			return &template, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (r templateRepo) ListTemplateNames(ctx context.Context) ([]string, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	ids := make([]int, 0, len(r.s.TemplatesByID))
+	for id := range r.s.TemplatesByID {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, r.s.TemplatesByID[uint8(id)].Name)
+	}
+	return names, nil
+}
+
+func (r templateRepo) InsertTemplate(ctx context.Context, template db.Template) (uint8, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.lastTemplateID++
+	template.ID = r.s.lastTemplateID
+	r.s.TemplatesByID[template.ID] = template
+	return template.ID, nil
+}
+
+func (r templateRepo) UpdateTemplate(ctx context.Context, id uint8, name *string, fileID *uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	template, ok := r.s.TemplatesByID[id]
+	if ok && name != nil {
+		template.Name = *name
+		r.s.TemplatesByID[id] = template
+	}
+	return nil
+}
+
+func (r templateRepo) UpdateTemplateBuild(ctx context.Context, name, styles, preview string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, template := range r.s.TemplatesByID {
+		if template.Name == name {
+			template.Styles = styles
+			template.Preview = preview
+			r.s.TemplatesByID[id] = template
+		}
+	}
+	return nil
+}
+
+type sessionRepo struct{ s *Store }
+
+func (r sessionRepo) GetSession(ctx context.Context, id uuid.UUID) (*db.Session, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	session, ok := r.s.SessionsByID[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &session, nil
+}
+
+func (r sessionRepo) GetActiveSessionOfUser(ctx context.Context, userID uuid.UUID, now time.Time) (*db.Session, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var found *db.Session
+	for _, session := range r.s.SessionsByID {
+		if session.UserID == userID && session.ExpiresAt.After(now) && (found == nil || session.ExpiresAt.After(found.ExpiresAt)) {
+			found = &session
+		}
+	}
+	if found == nil {
+		return nil, pgx.ErrNoRows
+	}
+	return found, nil
+}
+
+func (r sessionRepo) InsertSession(ctx context.Context, session db.Session) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.SessionsByID[session.ID] = session
+	return nil
+}
+
+type mailRepo struct{ s *Store }
+
+func (r mailRepo) GetMailTemplate(ctx context.Context, mailType string) (*db.MailTemplates, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	template, ok := r.s.MailTemplatesByType[mailType]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &template, nil
+}
+
+func (r mailRepo) InsertMail(ctx context.Context, mail db.Mail) (uint64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	mail.ID = uint64(len(r.s.SentMails) + 1)
+	r.s.SentMails = append(r.s.SentMails, mail)
+	return mail.ID, nil
+}
+
+type planRepo struct{ s *Store }
+
+func (r planRepo) GetPlan(ctx context.Context, id uint8) (*db.PaymentPlan, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	plan, ok := r.s.PlansByID[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &plan, nil
+}
+
+func (r planRepo) ListPlans(ctx context.Context) ([]db.PaymentPlan, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	plans := make([]db.PaymentPlan, 0, len(r.s.PlansByID))
+	for _, plan := range r.s.PlansByID {
+		plans = append(plans, plan)
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].ID < plans[j].ID })
+	return plans, nil
+}
+
+type siteEventRepo struct{ s *Store }
+
+func (r siteEventRepo) InsertSiteEvent(ctx context.Context, event db.SiteEvent) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.lastSiteEventID++
+	event.ID = r.s.lastSiteEventID
+	r.s.SiteHistory = append(r.s.SiteHistory, event)
+	return nil
+}
+
+func (r siteEventRepo) ListSiteEvents(ctx context.Context, siteID uint64) ([]db.SiteEvent, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var history []db.SiteEvent
+	for _, event := range r.s.SiteHistory {
+		if event.SiteID == siteID {
+			history = append(history, event)
+		}
+	}
+	return history, nil
+}
+
+type siteVersionRepo struct{ s *Store }
+
+func (r siteVersionRepo) GetSiteVersion(ctx context.Context, siteID uint64, version int) (*db.SiteVersion, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, v := range r.s.PublishedVersions {
+		if v.SiteID == siteID && v.Version == version {
+			return &v, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (r siteVersionRepo) ListSiteVersions(ctx context.Context, siteID uint64) ([]db.SiteVersion, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var versions []db.SiteVersion
+	for _, v := range r.s.PublishedVersions {
+		if v.SiteID == siteID {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version > versions[j].Version })
+	return versions, nil
+}
+
+func (r siteVersionRepo) NextSiteVersion(ctx context.Context, siteID uint64) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	next := 1
+	for _, v := range r.s.PublishedVersions {
+		if v.SiteID == siteID && v.Version >= next {
+			next = v.Version + 1
+		}
+	}
+	return next, nil
+}
+
+func (r siteVersionRepo) InsertSiteVersion(ctx context.Context, version db.SiteVersion) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.lastVersionID++
+	version.ID = r.s.lastVersionID
+	r.s.PublishedVersions = append(r.s.PublishedVersions, version)
+	return nil
+}
+
+type sitePreviewRepo struct{ s *Store }
+
+func (r sitePreviewRepo) GetPreview(ctx context.Context, token string) (*db.SitePreview, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	preview, ok := r.s.PreviewsByToken[token]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &preview, nil
+}
+
+func (r sitePreviewRepo) ListPreviews(ctx context.Context, siteID uint64) ([]db.SitePreview, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var previews []db.SitePreview
+	for _, preview := range r.s.PreviewsByToken {
+		if preview.SiteID == siteID && preview.Status != consts.PreviewStatusExpired {
+			previews = append(previews, preview)
+		}
+	}
+	sort.Slice(previews, func(i, j int) bool { return previews[i].CreatedAt.After(previews[j].CreatedAt) })
+	return previews, nil
+}
+
+func (r sitePreviewRepo) InsertPreview(ctx context.Context, preview db.SitePreview) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.PreviewsByToken[preview.Token] = preview
+	return nil
+}
+
+func (r sitePreviewRepo) UpdatePreviewDistribution(ctx context.Context, token, cloudfrontID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if preview, ok := r.s.PreviewsByToken[token]; ok {
+		preview.CloudfrontID = cloudfrontID
+		r.s.PreviewsByToken[token] = preview
+	}
+	return nil
+}
+
+func (r sitePreviewRepo) UpdatePreviewStatus(ctx context.Context, token string, status consts.PreviewStatus, cloudfrontDomain string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if preview, ok := r.s.PreviewsByToken[token]; ok {
+		preview.Status = status
+		if cloudfrontDomain != "" {
+			preview.CloudfrontDomain = cloudfrontDomain
+		}
+		r.s.PreviewsByToken[token] = preview
+	}
+	return nil
+}
+
+type siteBuildRepo struct{ s *Store }
+
+func (r siteBuildRepo) GetBuild(ctx context.Context, id uuid.UUID) (*db.SiteBuild, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	build, ok := r.s.BuildsByID[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &build, nil
+}
+
+func (r siteBuildRepo) CountActiveBuilds(ctx context.Context, siteID uint64, kind consts.BuildKind) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var count int
+	for _, build := range r.s.BuildsByID {
+		if build.SiteID == siteID && build.Kind == kind &&
+			(build.Status == consts.BuildStatusQueued || build.Status == consts.BuildStatusRunning) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r siteBuildRepo) InsertBuild(ctx context.Context, build db.SiteBuild) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.BuildsByID[build.ID] = build
+	return nil
+}
+
+func (r siteBuildRepo) UpdateBuild(ctx context.Context, build db.SiteBuild) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	// cancel is requested separately, an update doesn't reset it
+	build.CancelRequested = build.CancelRequested || r.s.BuildsByID[build.ID].CancelRequested
+	r.s.BuildsByID[build.ID] = build
+	return nil
+}
+
+func (r siteBuildRepo) RequestCancel(ctx context.Context, id uuid.UUID) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if build, ok := r.s.BuildsByID[id]; ok {
+		build.CancelRequested = true
+		r.s.BuildsByID[id] = build
+	}
+	return nil
+}
+
+func (r siteBuildRepo) GetBuildLog(ctx context.Context, id uuid.UUID) (string, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.BuildsByID[id]; !ok {
+		return "", pgx.ErrNoRows
+	}
+	return r.s.BuildLogs[id], nil
+}
+
+func (r siteBuildRepo) SaveBuildLog(ctx context.Context, id uuid.UUID, log string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.BuildLogs[id] = log
+	return nil
+}
+
+type provisionRepo struct{ s *Store }
+
+func (r provisionRepo) GetProvisionByID(ctx context.Context, siteID uint64) (*db.Provision, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	provision, ok := r.s.ProvisionsBySite[siteID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &provision, nil
+}
+
+func (r provisionRepo) InsertProvision(ctx context.Context, provision db.Provision) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.ProvisionsBySite[provision.SiteID] = provision
+	return nil
+}
+
+func (r provisionRepo) UpdateProvisionStructure(ctx context.Context, siteID uint64, structurePath string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if provision, ok := r.s.ProvisionsBySite[siteID]; ok {
+		provision.StructurePath = structurePath
+		provision.UpdatedAt = time.Now()
+		r.s.ProvisionsBySite[siteID] = provision
+	}
+	return nil
+}
+
+func (r provisionRepo) DeleteProvision(ctx context.Context, siteID uint64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.ProvisionsBySite, siteID)
+	return nil
+}
+
+type eventRepo struct{ s *Store }
+
+func (r eventRepo) InsertEvent(ctx context.Context, event shared.Event) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.InsertedEvents = append(r.s.InsertedEvents, event)
+	return nil
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+	"github.com/jackc/pgx/v5"
+)
+
+// UoWFactory hands out units of work without a database, they only record how they were finished.
+// Changes made through the in-memory repositories are visible immediately and aren't reverted on rollback
+type UoWFactory struct {
+	Commits   int
+	Rollbacks int
+}
+
+var _ interfaces.UoWFactory = (*UoWFactory)(nil)
+
+func NewUoWFactory() *UoWFactory {
+	return &UoWFactory{}
+}
+
+func (f *UoWFactory) GetUoW() shared.UoW {
+	return &UoW{factory: f}
+}
+
+type UoW struct {
+	factory      *UoWFactory
+	started      bool
+	finished     bool
+	rollbackOnly bool
+}
+
+var _ shared.UoW = (*UoW)(nil)
+
+// Begin returns a nil transaction, in-memory repositories don't use it
+func (u *UoW) Begin(ctx context.Context) (pgx.Tx, error) {
+	u.started = true
+	u.finished = false
+	return nil, nil
+}
+
+func (u *UoW) Commit() error {
+	if !u.started {
+		return fmt.Errorf("transaction is not started yet")
+	}
+	if u.finished {
+		return fmt.Errorf("transaction is already finished")
+	}
+	u.finished = true
+	u.factory.Commits++
+	return nil
+}
+
+func (u *UoW) Rollback() error {
+	if !u.started {
+		return fmt.Errorf("transaction is not started yet")
+	}
+	if u.finished {
+		return nil
+	}
+	u.finished = true
+	u.factory.Rollbacks++
+	return nil
+}
+
+func (u *UoW) Finalize(err *error) {
+	if !u.started || u.finished {
+		return
+	}
+	if *err != nil || u.rollbackOnly {
+		_ = u.Rollback()
+		return
+	}
+	_ = u.Commit()
+}
+
+func (u *UoW) SetRollbackOnly() {
+	u.rollbackOnly = true
+}
+
+func (u *UoW) GetTx() pgx.Tx {
+	return nil
+}
@@ -0,0 +1,55 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const planColumns = "id, stripe_id, description, features, price, max_sites"
+
+type PlanRepo struct {
+	tx pgx.Tx
+}
+
+var _ interfaces.PlanRepo = (*PlanRepo)(nil)
+
+func NewPlanRepo(tx pgx.Tx) *PlanRepo {
+	return &PlanRepo{tx: tx}
+}
+
+func (r *PlanRepo) GetPlan(ctx context.Context, id uint8) (*db.PaymentPlan, error) {
+	return scanPlan(r.tx.QueryRow(ctx, "SELECT "+planColumns+" FROM builder.payment_plans WHERE id = $1", id))
+}
+
+func (r *PlanRepo) ListPlans(ctx context.Context) ([]db.PaymentPlan, error) {
+	rows, err := r.tx.Query(ctx, "SELECT "+planColumns+" FROM builder.payment_plans ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("err getting plans, %v", err)
+	}
+	defer rows.Close()
+
+	var plans []db.PaymentPlan
+	for rows.Next() {
+		plan, err := scanPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, *plan)
+	}
+
+	return plans, rows.Err()
+}
+
+func scanPlan(row pgx.Row) (*db.PaymentPlan, error) {
+	var plan db.PaymentPlan
+	err := row.Scan(&plan.ID, &plan.StripeID, &plan.Description, &plan.Features, &plan.Price, &plan.MaxSites)
+	if err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
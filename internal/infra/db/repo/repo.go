@@ -2,12 +2,14 @@ package repo
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/correlation"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
 	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
 	"github.com/jackc/pgx/v5"
@@ -46,6 +48,28 @@ func (p *ProvisionRepo) InsertProvision(ctx context.Context, provision db.Provis
 	return nil
 }
 
+func (p *ProvisionRepo) UpdateProvisionStructure(ctx context.Context, siteID uint64, structurePath string) error {
+	_, err := p.tx.Exec(ctx, "UPDATE builder.provisions SET structure_path = $1, updated_at = $2 WHERE site_id = $3",
+		structurePath, time.Now(), siteID)
+	if err != nil {
+		return fmt.Errorf("err updating provision's structure, %v", err)
+	}
+
+	return nil
+}
+
+func (p *ProvisionRepo) DeleteProvision(ctx context.Context, siteID uint64) error {
+	_, err := p.tx.Exec(ctx, "DELETE FROM builder.provisions WHERE site_id = $1", siteID)
+	if err != nil {
+		return fmt.Errorf("err deleting provision, %v", err)
+	}
+
+	return nil
+}
+
+// OutboxChannel is notified on every inserted event, so that pollers don't wait for the next poll interval
+const OutboxChannel = "builder_outbox"
+
 type EventRepo struct {
 	tx pgx.Tx
 }
@@ -61,16 +85,96 @@ func (e *EventRepo) InsertEvent(ctx context.Context, event shared.Event) error {
 	if err != nil {
 		return fmt.Errorf("err marshalling event payload, %v", err)
 	}
+	notBefore := time.Now()
+	if scheduled, ok := event.(shared.ScheduledEvent); ok && scheduled.GetNotBefore().After(notBefore) {
+		notBefore = scheduled.GetNotBefore()
+	}
+	_, err = e.insertOutbox(ctx, event.GetType(), payload, notBefore)
+
+	return err
+}
+
+// InsertRawEvent stores an already serialized event, payload must be decodable by event's handler
+func (e *EventRepo) InsertRawEvent(ctx context.Context, eventType string, payload json.RawMessage) (uint64, error) {
+	return e.insertOutbox(ctx, eventType, payload, time.Now())
+}
+
+func (e *EventRepo) insertOutbox(ctx context.Context, eventType string, payload json.RawMessage, notBefore time.Time) (uint64, error) {
 	outbox := db.Outbox{
-		Event:     event.GetType(),
-		Status:    int(consts.NotProcessed),
-		Payload:   json.RawMessage(payload),
-		CreatedAt: time.Now(),
+		Event:         eventType,
+		Status:        int(consts.NotProcessed),
+		Payload:       payload,
+		NextAttemptAt: notBefore,
+		CreatedAt:     time.Now(),
+	}
+	// follow-up events inherit correlation ID of a request or an event, that caused them
+	if correlationID := correlation.FromContext(ctx); correlationID != "" {
+		outbox.CorrelationID = sql.NullString{String: correlationID, Valid: true}
 	}
-	_, err = e.tx.Exec(ctx, "INSERT INTO builder.outbox (event, status, payload, created_at) VALUES ($1,$2,$3,$4)",
-		outbox.Event, outbox.Status, outbox.Payload, outbox.CreatedAt)
+	err := e.tx.QueryRow(ctx, `INSERT INTO builder.outbox (event, status, payload, next_attempt_at, correlation_id, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6) RETURNING id`,
+		outbox.Event, outbox.Status, outbox.Payload, outbox.NextAttemptAt, outbox.CorrelationID, outbox.CreatedAt).Scan(&outbox.ID)
+	if err != nil {
+		return 0, fmt.Errorf("err inserting a new event, %v", err)
+	}
+
+	err = e.notify(ctx, outbox.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	return outbox.ID, nil
+}
+
+// GetEventForUpdate locks the event until the end of transaction
+func (e *EventRepo) GetEventForUpdate(ctx context.Context, id uint64) (*db.Outbox, error) {
+	var outbox db.Outbox
+	err := e.tx.QueryRow(ctx, `SELECT id, event, status, payload, attempts, next_attempt_at, last_error, locked_by, locked_until, correlation_id, created_at
+		FROM builder.outbox WHERE id = $1 FOR UPDATE`, id).Scan(&outbox.ID, &outbox.Event, &outbox.Status, &outbox.Payload,
+		&outbox.Attempts, &outbox.NextAttemptAt, &outbox.LastError, &outbox.LockedBy, &outbox.LockedUntil, &outbox.CorrelationID, &outbox.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &outbox, nil
+}
+
+// RequeueEvent returns the event to the queue with a fresh retry budget
+func (e *EventRepo) RequeueEvent(ctx context.Context, outbox db.Outbox) error {
+	_, err := e.tx.Exec(ctx, `UPDATE builder.outbox
+		SET status = $1, attempts = 0, next_attempt_at = now(), locked_by = NULL, locked_until = NULL
+		WHERE id = $2`, consts.NotProcessed, outbox.ID)
+	if err != nil {
+		return fmt.Errorf("err requeueing event, %v", err)
+	}
+
+	return e.notify(ctx, outbox.Event)
+}
+
+func (e *EventRepo) UpdateEventStatus(ctx context.Context, id uint64, status consts.OutboxStatus) error {
+	_, err := e.tx.Exec(ctx, "UPDATE builder.outbox SET status = $1, locked_by = NULL, locked_until = NULL WHERE id = $2", status, id)
+	if err != nil {
+		return fmt.Errorf("err updating event status, %v", err)
+	}
+
+	return nil
+}
+
+func (e *EventRepo) InsertAudit(ctx context.Context, audit db.OutboxAudit) error {
+	_, err := e.tx.Exec(ctx, `INSERT INTO builder.outbox_audit (event_id, action, actor_id, previous_status, reason, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6)`, audit.EventID, audit.Action, audit.ActorID, audit.PreviousStatus, audit.Reason, audit.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("err inserting outbox audit, %v", err)
+	}
+
+	return nil
+}
+
+// notify is delivered to listeners only when transaction commits
+func (e *EventRepo) notify(ctx context.Context, eventType string) error {
+	_, err := e.tx.Exec(ctx, "SELECT pg_notify($1, $2)", OutboxChannel, eventType)
 	if err != nil {
-		return fmt.Errorf("err inserting a new event, %v", err)
+		return fmt.Errorf("err notifying about a new event, %v", err)
 	}
 
 	return nil
@@ -29,8 +29,9 @@ func TestMain(m *testing.M) {
 }
 
 func TestInsertProvisionSuccessIfValidFields(t *testing.T) {
+	ctx := context.Background()
 	uow := uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	require.NoError(t, err)
 	defer uow.Rollback()
 
@@ -45,7 +46,6 @@ func TestInsertProvisionSuccessIfValidFields(t *testing.T) {
 		UpdatedAt:      time.Now(),
 	}
 
-	ctx := context.Background()
 	provisionRepo := repo.NewProvisionRepo(tx)
 
 	err = provisionRepo.InsertProvision(ctx, provision)
@@ -62,8 +62,9 @@ func TestInsertProvisionSuccessIfValidFields(t *testing.T) {
 }
 
 func TestGetProvisionReturnsProvisionIfExists(t *testing.T) {
+	ctx := context.Background()
 	uow := uowFactory.GetUoW()
-	tx, err := uow.Begin()
+	tx, err := uow.Begin(ctx)
 	require.NoError(t, err)
 	defer uow.Rollback()
 
@@ -77,7 +78,6 @@ func TestGetProvisionReturnsProvisionIfExists(t *testing.T) {
 	}
 
 	provisionRepo := repo.NewProvisionRepo(tx)
-	ctx := context.Background()
 
 	err = provisionRepo.InsertProvision(ctx, provision)
 	require.NoError(t, err)
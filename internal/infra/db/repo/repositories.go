@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/jackc/pgx/v5"
+)
+
+// Repositories builds pgx repositories on a transaction
+type Repositories struct{}
+
+var _ interfaces.Repositories = (*Repositories)(nil)
+
+func NewRepositories() *Repositories {
+	return &Repositories{}
+}
+
+func (r *Repositories) Sites(tx pgx.Tx) interfaces.SiteRepo {
+	return NewSiteRepo(tx)
+}
+
+func (r *Repositories) SiteEvents(tx pgx.Tx) interfaces.SiteEventRepo {
+	return NewSiteEventRepo(tx)
+}
+
+func (r *Repositories) SiteVersions(tx pgx.Tx) interfaces.SiteVersionRepo {
+	return NewSiteVersionRepo(tx)
+}
+
+func (r *Repositories) SitePreviews(tx pgx.Tx) interfaces.SitePreviewRepo {
+	return NewSitePreviewRepo(tx)
+}
+
+func (r *Repositories) SiteBuilds(tx pgx.Tx) interfaces.SiteBuildRepo {
+	return NewSiteBuildRepo(tx)
+}
+
+func (r *Repositories) Users(tx pgx.Tx) interfaces.UserRepo {
+	return NewUserRepo(tx)
+}
+
+func (r *Repositories) Templates(tx pgx.Tx) interfaces.TemplateRepo {
+	return NewTemplateRepo(tx)
+}
+
+func (r *Repositories) Sessions(tx pgx.Tx) interfaces.SessionRepo {
+	return NewSessionRepo(tx)
+}
+
+func (r *Repositories) Mails(tx pgx.Tx) interfaces.MailRepo {
+	return NewMailRepo(tx)
+}
+
+func (r *Repositories) Plans(tx pgx.Tx) interfaces.PlanRepo {
+	return NewPlanRepo(tx)
+}
+
+func (r *Repositories) Provisions(tx pgx.Tx) interfaces.ProvisionRepo {
+	return NewProvisionRepo(tx)
+}
+
+func (r *Repositories) Events(tx pgx.Tx) interfaces.EventRepo {
+	return NewEventRepo(tx)
+}
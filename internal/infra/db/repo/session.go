@@ -0,0 +1,53 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const sessionColumns = "id, user_id, COALESCE(refresh_token, ''), expires_at"
+
+type SessionRepo struct {
+	tx pgx.Tx
+}
+
+var _ interfaces.SessionRepo = (*SessionRepo)(nil)
+
+func NewSessionRepo(tx pgx.Tx) *SessionRepo {
+	return &SessionRepo{tx: tx}
+}
+
+func (r *SessionRepo) GetSession(ctx context.Context, id uuid.UUID) (*db.Session, error) {
+	return scanSession(r.tx.QueryRow(ctx, "SELECT "+sessionColumns+" FROM builder.sessions WHERE id = $1", id))
+}
+
+func (r *SessionRepo) GetActiveSessionOfUser(ctx context.Context, userID uuid.UUID, now time.Time) (*db.Session, error) {
+	return scanSession(r.tx.QueryRow(ctx, "SELECT "+sessionColumns+" FROM builder.sessions WHERE user_id = $1 AND expires_at > $2 "+
+		"ORDER BY expires_at DESC LIMIT 1", userID, now))
+}
+
+func (r *SessionRepo) InsertSession(ctx context.Context, session db.Session) error {
+	_, err := r.tx.Exec(ctx, "INSERT INTO builder.sessions(id, user_id, refresh_token, expires_at) VALUES ($1,$2,$3,$4)",
+		session.ID, session.UserID, session.RefreshToken, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("err inserting session, %v", err)
+	}
+
+	return nil
+}
+
+func scanSession(row pgx.Row) (*db.Session, error) {
+	var session db.Session
+	err := row.Scan(&session.ID, &session.UserID, &session.RefreshToken, &session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
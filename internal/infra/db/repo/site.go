@@ -0,0 +1,161 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const siteColumns = "id, template_id, creator_id, plan_id, COALESCE(subscription_id, ''), status, fields, file_id, created_at, COALESCE(updated_at, created_at), " +
+	"COALESCE(published_version, 0)"
+
+type SiteRepo struct {
+	tx pgx.Tx
+}
+
+var _ interfaces.SiteRepo = (*SiteRepo)(nil)
+
+func NewSiteRepo(tx pgx.Tx) *SiteRepo {
+	return &SiteRepo{tx: tx}
+}
+
+func (r *SiteRepo) GetSite(ctx context.Context, id uint64) (*db.Site, error) {
+	return scanSite(r.tx.QueryRow(ctx, "SELECT "+siteColumns+" FROM builder.sites WHERE id = $1", id))
+}
+
+func (r *SiteRepo) GetSiteBySubscription(ctx context.Context, subscriptionID string) (*db.Site, error) {
+	return scanSite(r.tx.QueryRow(ctx, "SELECT "+siteColumns+" FROM builder.sites WHERE subscription_id = $1", subscriptionID))
+}
+
+func (r *SiteRepo) GetFirstSiteOfCreator(ctx context.Context, creatorID uuid.UUID) (*db.Site, error) {
+	return scanSite(r.tx.QueryRow(ctx, "SELECT "+siteColumns+" FROM builder.sites WHERE creator_id = $1 ORDER BY id LIMIT 1", creatorID))
+}
+
+func (r *SiteRepo) LockCreator(ctx context.Context, creatorID uuid.UUID) error {
+	_, err := r.tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended($1, 0))", creatorID.String())
+	if err != nil {
+		return fmt.Errorf("err locking sites of creator, %v", err)
+	}
+
+	return nil
+}
+
+func (r *SiteRepo) ListSites(ctx context.Context, filter interfaces.SiteFilter, limit, offset int) ([]db.Site, error) {
+	where, args := siteFilterClause(filter)
+	query := fmt.Sprintf("SELECT %s FROM builder.sites %s ORDER BY id DESC LIMIT $%d OFFSET $%d", siteColumns, where, len(args)+1, len(args)+2)
+	rows, err := r.tx.Query(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("err listing sites, %v", err)
+	}
+	defer rows.Close()
+
+	var sites []db.Site
+	for rows.Next() {
+		site, err := scanSite(rows)
+		if err != nil {
+			return nil, err
+		}
+		sites = append(sites, *site)
+	}
+
+	return sites, rows.Err()
+}
+
+func (r *SiteRepo) CountSites(ctx context.Context, filter interfaces.SiteFilter) (int, error) {
+	where, args := siteFilterClause(filter)
+	var count int
+	err := r.tx.QueryRow(ctx, "SELECT count(*) FROM builder.sites "+where, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("err counting sites, %v", err)
+	}
+
+	return count, nil
+}
+
+func (r *SiteRepo) InsertSite(ctx context.Context, site db.Site) (uint64, error) {
+	err := r.tx.QueryRow(ctx, `INSERT INTO builder.sites(template_id, creator_id, plan_id, status, fields, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`, site.TemplateID, site.CreatorID, site.PlanID, site.Status,
+		site.Fields, site.CreatedAt, site.UpdatedAt).Scan(&site.ID)
+	if err != nil {
+		return 0, fmt.Errorf("err inserting site, %v", err)
+	}
+
+	return site.ID, nil
+}
+
+func (r *SiteRepo) UpdateSiteStatus(ctx context.Context, id uint64, status consts.SiteStatus) error {
+	_, err := r.tx.Exec(ctx, "UPDATE builder.sites SET status = $1, updated_at = $2 WHERE id = $3", status, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("err updating site status, %v", err)
+	}
+
+	return nil
+}
+
+func (r *SiteRepo) UpdateSiteContent(ctx context.Context, id uint64, fields json.RawMessage, fileID *uuid.UUID) error {
+	_, err := r.tx.Exec(ctx, "UPDATE builder.sites SET fields = COALESCE($1, fields), file_id = COALESCE($2, file_id), updated_at = $3 WHERE id = $4",
+		fields, fileID, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("err updating site content, %v", err)
+	}
+
+	return nil
+}
+
+func (r *SiteRepo) UpdateSiteSubscription(ctx context.Context, id uint64, subscriptionID string) error {
+	_, err := r.tx.Exec(ctx, "UPDATE builder.sites SET subscription_id = $1, updated_at = $2 WHERE id = $3", subscriptionID, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("err updating site subscription, %v", err)
+	}
+
+	return nil
+}
+
+func (r *SiteRepo) UpdateSitePublishedVersion(ctx context.Context, id uint64, version int) error {
+	_, err := r.tx.Exec(ctx, "UPDATE builder.sites SET published_version = $1, updated_at = $2 WHERE id = $3", version, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("err updating site published version, %v", err)
+	}
+
+	return nil
+}
+
+func siteFilterClause(filter interfaces.SiteFilter) (string, []any) {
+	var args []any
+	where := "WHERE true"
+	if filter.CreatorID != uuid.Nil {
+		args = append(args, filter.CreatorID)
+		where += fmt.Sprintf(" AND creator_id = $%d", len(args))
+	}
+	if filter.PlanID != 0 {
+		args = append(args, filter.PlanID)
+		where += fmt.Sprintf(" AND plan_id = $%d", len(args))
+	}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		where += fmt.Sprintf(" AND status = $%d", len(args))
+	} else {
+		args = append(args, consts.SiteStatusDeleted)
+		where += fmt.Sprintf(" AND status <> $%d", len(args))
+	}
+
+	return where, args
+}
+
+func scanSite(row pgx.Row) (*db.Site, error) {
+	var site db.Site
+	err := row.Scan(&site.ID, &site.TemplateID, &site.CreatorID, &site.PlanID, &site.SubscriptionID, &site.Status,
+		&site.Fields, &site.FileID, &site.CreatedAt, &site.UpdatedAt, &site.PublishedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &site, nil
+}
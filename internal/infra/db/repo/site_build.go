@@ -0,0 +1,99 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const siteBuildColumns = "id, site_id, kind, status, COALESCE(step, ''), COALESCE(error, ''), attempts, COALESCE(version, 0), " +
+	"COALESCE(preview_token, ''), cancel_requested, created_by, created_at, updated_at, finished_at"
+
+type SiteBuildRepo struct {
+	tx pgx.Tx
+}
+
+var _ interfaces.SiteBuildRepo = (*SiteBuildRepo)(nil)
+
+func NewSiteBuildRepo(tx pgx.Tx) *SiteBuildRepo {
+	return &SiteBuildRepo{tx: tx}
+}
+
+func (r *SiteBuildRepo) GetBuild(ctx context.Context, id uuid.UUID) (*db.SiteBuild, error) {
+	var build db.SiteBuild
+	err := r.tx.QueryRow(ctx, "SELECT "+siteBuildColumns+" FROM builder.site_builds WHERE id = $1", id).Scan(&build.ID,
+		&build.SiteID, &build.Kind, &build.Status, &build.Step, &build.Error, &build.Attempts, &build.Version, &build.PreviewToken,
+		&build.CancelRequested, &build.CreatedBy, &build.CreatedAt, &build.UpdatedAt, &build.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &build, nil
+}
+
+func (r *SiteBuildRepo) CountActiveBuilds(ctx context.Context, siteID uint64, kind consts.BuildKind) (int, error) {
+	var count int
+	err := r.tx.QueryRow(ctx, "SELECT COUNT(*) FROM builder.site_builds WHERE site_id = $1 AND kind = $2 AND status IN ($3, $4)",
+		siteID, kind, consts.BuildStatusQueued, consts.BuildStatusRunning).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("err counting site builds, %v", err)
+	}
+
+	return count, nil
+}
+
+func (r *SiteBuildRepo) InsertBuild(ctx context.Context, build db.SiteBuild) error {
+	_, err := r.tx.Exec(ctx, "INSERT INTO builder.site_builds(id, site_id, kind, status, step, attempts, created_by, created_at, "+
+		"updated_at) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)", build.ID, build.SiteID, build.Kind, build.Status,
+		build.Step, build.Attempts, build.CreatedBy, build.CreatedAt, build.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("err inserting site build, %v", err)
+	}
+
+	return nil
+}
+
+func (r *SiteBuildRepo) UpdateBuild(ctx context.Context, build db.SiteBuild) error {
+	_, err := r.tx.Exec(ctx, "UPDATE builder.site_builds SET status = $1, step = NULLIF($2, ''), error = NULLIF($3, ''), "+
+		"attempts = $4, version = NULLIF($5, 0), preview_token = NULLIF($6, ''), updated_at = $7, finished_at = $8 WHERE id = $9",
+		build.Status, build.Step, build.Error, build.Attempts, build.Version, build.PreviewToken, build.UpdatedAt, build.FinishedAt,
+		build.ID)
+	if err != nil {
+		return fmt.Errorf("err updating site build, %v", err)
+	}
+
+	return nil
+}
+
+func (r *SiteBuildRepo) RequestCancel(ctx context.Context, id uuid.UUID) error {
+	_, err := r.tx.Exec(ctx, "UPDATE builder.site_builds SET cancel_requested = TRUE WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("err requesting cancel of site build, %v", err)
+	}
+
+	return nil
+}
+
+func (r *SiteBuildRepo) GetBuildLog(ctx context.Context, id uuid.UUID) (string, error) {
+	var log string
+	err := r.tx.QueryRow(ctx, "SELECT COALESCE(log, '') FROM builder.site_builds WHERE id = $1", id).Scan(&log)
+	if err != nil {
+		return "", err
+	}
+
+	return log, nil
+}
+
+func (r *SiteBuildRepo) SaveBuildLog(ctx context.Context, id uuid.UUID, log string) error {
+	_, err := r.tx.Exec(ctx, "UPDATE builder.site_builds SET log = $1 WHERE id = $2", log, id)
+	if err != nil {
+		return fmt.Errorf("err saving site build log, %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const siteEventColumns = "id, site_id, kind, COALESCE(from_status, ''), to_status, actor, actor_id, COALESCE(reason, ''), " +
+	"COALESCE(correlation_id, ''), created_at"
+
+type SiteEventRepo struct {
+	tx pgx.Tx
+}
+
+var _ interfaces.SiteEventRepo = (*SiteEventRepo)(nil)
+
+func NewSiteEventRepo(tx pgx.Tx) *SiteEventRepo {
+	return &SiteEventRepo{tx: tx}
+}
+
+func (r *SiteEventRepo) InsertSiteEvent(ctx context.Context, event db.SiteEvent) error {
+	_, err := r.tx.Exec(ctx, "INSERT INTO builder.site_events(site_id, kind, from_status, to_status, actor, actor_id, reason, "+
+		"correlation_id, created_at) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9)",
+		event.SiteID, event.Kind, event.FromStatus, event.ToStatus, event.Actor, event.ActorID, event.Reason,
+		event.CorrelationID, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("err inserting site event, %v", err)
+	}
+
+	return nil
+}
+
+func (r *SiteEventRepo) ListSiteEvents(ctx context.Context, siteID uint64) ([]db.SiteEvent, error) {
+	rows, err := r.tx.Query(ctx, "SELECT "+siteEventColumns+" FROM builder.site_events WHERE site_id = $1 ORDER BY id", siteID)
+	if err != nil {
+		return nil, fmt.Errorf("err getting site events, %v", err)
+	}
+	defer rows.Close()
+
+	var history []db.SiteEvent
+	for rows.Next() {
+		var event db.SiteEvent
+		err = rows.Scan(&event.ID, &event.SiteID, &event.Kind, &event.FromStatus, &event.ToStatus, &event.Actor,
+			&event.ActorID, &event.Reason, &event.CorrelationID, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, event)
+	}
+
+	return history, rows.Err()
+}
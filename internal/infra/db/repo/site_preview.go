@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const sitePreviewColumns = "token, site_id, status, prefix, domain, cloudfront_id, COALESCE(cloudfront_domain, ''), created_by, " +
+	"created_at, expires_at"
+
+type SitePreviewRepo struct {
+	tx pgx.Tx
+}
+
+var _ interfaces.SitePreviewRepo = (*SitePreviewRepo)(nil)
+
+func NewSitePreviewRepo(tx pgx.Tx) *SitePreviewRepo {
+	return &SitePreviewRepo{tx: tx}
+}
+
+func (r *SitePreviewRepo) GetPreview(ctx context.Context, token string) (*db.SitePreview, error) {
+	return scanSitePreview(r.tx.QueryRow(ctx, "SELECT "+sitePreviewColumns+" FROM builder.site_previews WHERE token = $1", token))
+}
+
+func (r *SitePreviewRepo) ListPreviews(ctx context.Context, siteID uint64) ([]db.SitePreview, error) {
+	rows, err := r.tx.Query(ctx, "SELECT "+sitePreviewColumns+" FROM builder.site_previews WHERE site_id = $1 AND status <> $2 "+
+		"ORDER BY created_at DESC", siteID, consts.PreviewStatusExpired)
+	if err != nil {
+		return nil, fmt.Errorf("err getting site previews, %v", err)
+	}
+	defer rows.Close()
+
+	var previews []db.SitePreview
+	for rows.Next() {
+		preview, err := scanSitePreview(rows)
+		if err != nil {
+			return nil, err
+		}
+		previews = append(previews, *preview)
+	}
+
+	return previews, rows.Err()
+}
+
+func (r *SitePreviewRepo) InsertPreview(ctx context.Context, preview db.SitePreview) error {
+	_, err := r.tx.Exec(ctx, "INSERT INTO builder.site_previews(token, site_id, status, prefix, domain, cloudfront_id, created_by, "+
+		"created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)", preview.Token, preview.SiteID, preview.Status,
+		preview.Prefix, preview.Domain, preview.CloudfrontID, preview.CreatedBy, preview.CreatedAt, preview.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("err inserting site preview, %v", err)
+	}
+
+	return nil
+}
+
+func (r *SitePreviewRepo) UpdatePreviewDistribution(ctx context.Context, token, cloudfrontID string) error {
+	_, err := r.tx.Exec(ctx, "UPDATE builder.site_previews SET cloudfront_id = $1 WHERE token = $2", cloudfrontID, token)
+	if err != nil {
+		return fmt.Errorf("err updating distribution of site preview, %v", err)
+	}
+
+	return nil
+}
+
+func (r *SitePreviewRepo) UpdatePreviewStatus(ctx context.Context, token string, status consts.PreviewStatus, cloudfrontDomain string) error {
+	_, err := r.tx.Exec(ctx, "UPDATE builder.site_previews SET status = $1, cloudfront_domain = COALESCE(NULLIF($2, ''), cloudfront_domain) "+
+		"WHERE token = $3", status, cloudfrontDomain, token)
+	if err != nil {
+		return fmt.Errorf("err updating site preview, %v", err)
+	}
+
+	return nil
+}
+
+func scanSitePreview(row pgx.Row) (*db.SitePreview, error) {
+	var preview db.SitePreview
+	err := row.Scan(&preview.Token, &preview.SiteID, &preview.Status, &preview.Prefix, &preview.Domain, &preview.CloudfrontID,
+		&preview.CloudfrontDomain, &preview.CreatedBy, &preview.CreatedAt, &preview.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &preview, nil
+}
@@ -0,0 +1,63 @@
+package repo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LockCreator_When_Creator_Is_Locked_Then_Other_Transaction_Waits_For_Commit(t *testing.T) {
+	ctx := context.Background()
+	creatorID := uuid.New()
+	first := uowFactory.GetUoW()
+	tx, err := first.Begin(ctx)
+	require.NoError(t, err)
+	require.NoError(t, repo.NewSiteRepo(tx).LockCreator(ctx, creatorID))
+
+	locked := make(chan error, 1)
+	go func() {
+		second := uowFactory.GetUoW()
+		tx, err := second.Begin(ctx)
+		if err != nil {
+			locked <- err
+			return
+		}
+		defer second.Rollback()
+		locked <- repo.NewSiteRepo(tx).LockCreator(ctx, creatorID)
+	}()
+
+	select {
+	case <-locked:
+		require.FailNow(t, "creator was locked twice")
+	case <-time.After(300 * time.Millisecond):
+	}
+	require.NoError(t, first.Commit())
+	select {
+	case err = <-locked:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "lock wasn't released by commit")
+	}
+}
+
+func Test_LockCreator_When_Other_Creator_Is_Locked_Then_Dont_Wait(t *testing.T) {
+	ctx := context.Background()
+	first := uowFactory.GetUoW()
+	tx, err := first.Begin(ctx)
+	require.NoError(t, err)
+	defer first.Rollback()
+	require.NoError(t, repo.NewSiteRepo(tx).LockCreator(ctx, uuid.New()))
+
+	second := uowFactory.GetUoW()
+	tx, err = second.Begin(ctx)
+	require.NoError(t, err)
+	defer second.Rollback()
+	lockCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, repo.NewSiteRepo(tx).LockCreator(lockCtx, uuid.New()))
+}
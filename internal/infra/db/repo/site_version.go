@@ -0,0 +1,78 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const siteVersionColumns = "id, site_id, version, fields, prefix, created_by, created_at"
+
+type SiteVersionRepo struct {
+	tx pgx.Tx
+}
+
+var _ interfaces.SiteVersionRepo = (*SiteVersionRepo)(nil)
+
+func NewSiteVersionRepo(tx pgx.Tx) *SiteVersionRepo {
+	return &SiteVersionRepo{tx: tx}
+}
+
+func (r *SiteVersionRepo) GetSiteVersion(ctx context.Context, siteID uint64, version int) (*db.SiteVersion, error) {
+	return scanSiteVersion(r.tx.QueryRow(ctx, "SELECT "+siteVersionColumns+" FROM builder.site_versions WHERE site_id = $1 AND version = $2",
+		siteID, version))
+}
+
+func (r *SiteVersionRepo) ListSiteVersions(ctx context.Context, siteID uint64) ([]db.SiteVersion, error) {
+	rows, err := r.tx.Query(ctx, "SELECT "+siteVersionColumns+" FROM builder.site_versions WHERE site_id = $1 ORDER BY version DESC", siteID)
+	if err != nil {
+		return nil, fmt.Errorf("err getting site versions, %v", err)
+	}
+	defer rows.Close()
+
+	var versions []db.SiteVersion
+	for rows.Next() {
+		version, err := scanSiteVersion(rows)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, *version)
+	}
+
+	return versions, rows.Err()
+}
+
+func (r *SiteVersionRepo) NextSiteVersion(ctx context.Context, siteID uint64) (int, error) {
+	var next int
+	err := r.tx.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) + 1 FROM builder.site_versions WHERE site_id = $1", siteID).Scan(&next)
+	if err != nil {
+		return 0, fmt.Errorf("err getting next site version, %v", err)
+	}
+
+	return next, nil
+}
+
+func (r *SiteVersionRepo) InsertSiteVersion(ctx context.Context, version db.SiteVersion) error {
+	_, err := r.tx.Exec(ctx, "INSERT INTO builder.site_versions(site_id, version, fields, prefix, created_by, created_at) "+
+		"VALUES ($1, $2, $3, $4, $5, $6)", version.SiteID, version.Version, version.Fields, version.Prefix, version.CreatedBy,
+		version.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("err inserting site version, %v", err)
+	}
+
+	return nil
+}
+
+func scanSiteVersion(row pgx.Row) (*db.SiteVersion, error) {
+	var version db.SiteVersion
+	err := row.Scan(&version.ID, &version.SiteID, &version.Version, &version.Fields, &version.Prefix, &version.CreatedBy,
+		&version.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
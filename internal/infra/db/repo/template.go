@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const templateColumns = "id, name, fields, COALESCE(styles, ''), COALESCE(preview, '')"
+
+type TemplateRepo struct {
+	tx pgx.Tx
+}
+
+var _ interfaces.TemplateRepo = (*TemplateRepo)(nil)
+
+func NewTemplateRepo(tx pgx.Tx) *TemplateRepo {
+	return &TemplateRepo{tx: tx}
+}
+
+func (r *TemplateRepo) GetTemplate(ctx context.Context, id uint8) (*db.Template, error) {
+	return scanTemplate(r.tx.QueryRow(ctx, "SELECT "+templateColumns+" FROM builder.templates WHERE id = $1", id))
+}
+
+func (r *TemplateRepo) GetTemplateByName(ctx context.Context, name string) (*db.Template, error) {
+	return scanTemplate(r.tx.QueryRow(ctx, "SELECT "+templateColumns+" FROM builder.templates WHERE name = $1", name))
+}
+
+func (r *TemplateRepo) ListTemplateNames(ctx context.Context) ([]string, error) {
+	rows, err := r.tx.Query(ctx, "SELECT name FROM builder.templates ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("err getting templates, %v", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+func (r *TemplateRepo) InsertTemplate(ctx context.Context, template db.Template) (uint8, error) {
+	err := r.tx.QueryRow(ctx, "INSERT INTO builder.templates(name, fields) VALUES ($1, $2) RETURNING id",
+		template.Name, template.Fields).Scan(&template.ID)
+	if err != nil {
+		return 0, fmt.Errorf("err inserting template, %v", err)
+	}
+
+	return template.ID, nil
+}
+
+func (r *TemplateRepo) UpdateTemplate(ctx context.Context, id uint8, name *string, fileID *uuid.UUID) error {
+	_, err := r.tx.Exec(ctx, "UPDATE builder.templates SET file_id = COALESCE($1, file_id), name = COALESCE($2, name) WHERE id = $3",
+		fileID, name, id)
+	if err != nil {
+		return fmt.Errorf("err updating template, %v", err)
+	}
+
+	return nil
+}
+
+func (r *TemplateRepo) UpdateTemplateBuild(ctx context.Context, name, styles, preview string) error {
+	_, err := r.tx.Exec(ctx, "UPDATE builder.templates SET styles = $1, preview = $2 WHERE name = $3", styles, preview, name)
+	if err != nil {
+		return fmt.Errorf("err updating template build, %v", err)
+	}
+
+	return nil
+}
+
+func scanTemplate(row pgx.Row) (*db.Template, error) {
+	var template db.Template
+	err := row.Scan(&template.ID, &template.Name, &template.Fields, &template.Styles, &template.Preview)
+	if err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
@@ -0,0 +1,153 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/interfaces"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const userColumns = "id, COALESCE(stripe_id, ''), COALESCE(status, ''), COALESCE(first_name, ''), COALESCE(second_name, ''), email, COALESCE(created_at, 'epoch')"
+
+type UserRepo struct {
+	tx pgx.Tx
+}
+
+var _ interfaces.UserRepo = (*UserRepo)(nil)
+
+func NewUserRepo(tx pgx.Tx) *UserRepo {
+	return &UserRepo{tx: tx}
+}
+
+func (r *UserRepo) GetUser(ctx context.Context, id uuid.UUID) (*db.User, error) {
+	return scanUser(r.tx.QueryRow(ctx, "SELECT "+userColumns+" FROM builder.users WHERE id = $1", id))
+}
+
+func (r *UserRepo) GetUserByEmail(ctx context.Context, email string) (*db.User, error) {
+	return scanUser(r.tx.QueryRow(ctx, "SELECT "+userColumns+" FROM builder.users WHERE email = $1", email))
+}
+
+func (r *UserRepo) GetUserByStripeID(ctx context.Context, stripeID string) (*db.User, error) {
+	return scanUser(r.tx.QueryRow(ctx, "SELECT "+userColumns+" FROM builder.users WHERE stripe_id = $1", stripeID))
+}
+
+func (r *UserRepo) InsertUser(ctx context.Context, user db.User) error {
+	_, err := r.tx.Exec(ctx, `INSERT INTO builder.users(id, stripe_id, status, first_name, second_name, email, created_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7)`,
+		user.ID, user.StripeID, user.Status, user.FirstName, user.SecondName, user.Email, user.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("err inserting user, %v", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepo) UpdateUserStatus(ctx context.Context, id uuid.UUID, status consts.UserStatus) error {
+	_, err := r.tx.Exec(ctx, "UPDATE builder.users SET status = $1 WHERE id = $2", status, id)
+	if err != nil {
+		return fmt.Errorf("err updating user status, %v", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepo) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	_, err := r.tx.Exec(ctx, "DELETE FROM builder.users WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("err deleting user, %v", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepo) GetUserIDByIdentity(ctx context.Context, provider, sub string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.tx.QueryRow(ctx, "SELECT id FROM builder.user_identities WHERE provider = $1 AND sub = $2", provider, sub).Scan(&userID)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return userID, nil
+}
+
+func (r *UserRepo) GetIdentities(ctx context.Context, userID uuid.UUID) ([]db.UserIdentity, error) {
+	rows, err := r.tx.Query(ctx, "SELECT id, provider, sub FROM builder.user_identities WHERE id = $1", userID)
+	if err != nil {
+		return nil, fmt.Errorf("err getting user identities, %v", err)
+	}
+	defer rows.Close()
+
+	var identities []db.UserIdentity
+	for rows.Next() {
+		var identity db.UserIdentity
+		if err = rows.Scan(&identity.UserID, &identity.Provider, &identity.Sub); err != nil {
+			return nil, fmt.Errorf("err getting user identity, %v", err)
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, rows.Err()
+}
+
+func (r *UserRepo) InsertIdentity(ctx context.Context, identity db.UserIdentity) error {
+	_, err := r.tx.Exec(ctx, "INSERT INTO builder.user_identities(id, provider, sub) VALUES ($1,$2,$3)",
+		identity.UserID, identity.Provider, identity.Sub)
+	if err != nil {
+		return fmt.Errorf("err inserting user identity, %v", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepo) DeleteIdentities(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.tx.Exec(ctx, "DELETE FROM builder.user_identities WHERE id = $1", userID)
+	if err != nil {
+		return fmt.Errorf("err deleting user identities, %v", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepo) GetConfirmationCode(ctx context.Context, code uuid.UUID) (*db.ConfirmationCode, error) {
+	var confirmation db.ConfirmationCode
+	err := r.tx.QueryRow(ctx, "SELECT code, sub_id, COALESCE(email, ''), expires_at FROM builder.confirmation_codes WHERE code = $1", code).
+		Scan(&confirmation.Code, &confirmation.SubID, &confirmation.Email, &confirmation.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &confirmation, nil
+}
+
+func (r *UserRepo) InsertConfirmationCode(ctx context.Context, code db.ConfirmationCode) error {
+	_, err := r.tx.Exec(ctx, "INSERT INTO builder.confirmation_codes(code, sub_id, email, expires_at) VALUES ($1,$2,$3,$4)",
+		code.Code, code.SubID, code.Email, code.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("err inserting confirmation code, %v", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepo) DeleteConfirmationCode(ctx context.Context, code uuid.UUID) error {
+	_, err := r.tx.Exec(ctx, "DELETE FROM builder.confirmation_codes WHERE code = $1", code)
+	if err != nil {
+		return fmt.Errorf("err deleting confirmation code, %v", err)
+	}
+
+	return nil
+}
+
+func scanUser(row pgx.Row) (*db.User, error) {
+	var user db.User
+	err := row.Scan(&user.ID, &user.StripeID, &user.Status, &user.FirstName, &user.SecondName, &user.Email, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
@@ -2,6 +2,7 @@ package dns
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strconv"
@@ -106,26 +107,18 @@ func (d *DNSProvisioner) MapCfDistributionToS3(ctx context.Context, sitePath, s3
 		},
 	})
 	if err != nil {
-		slog.Error("err mapping s3 to cloudfront distr", "cf", err)
+		slog.ErrorContext(ctx, "err mapping s3 to cloudfront distr", "cf", err)
 		return nil, err
 	}
 	return res.Distribution, nil
 }
 
-func (d *DNSProvisioner) MapCfDistributionToS3GetURL(ctx context.Context, sitePath, s3WebDomain, domain, certificateArn string) (string, error) {
-	distribution, err := d.MapCfDistributionToS3(ctx, sitePath, s3WebDomain, domain, certificateArn)
+func (d *DNSProvisioner) CreateDistribution(ctx context.Context, originPath, originDomain, domain, certificateARN string) (Distribution, error) {
+	distribution, err := d.MapCfDistributionToS3(ctx, originPath, originDomain, domain, certificateARN)
 	if err != nil {
-		return "", err
+		return Distribution{}, err
 	}
-	return aws.ToString(distribution.DomainName), nil
-}
-
-func (d *DNSProvisioner) MapCfDistributionToS3GetID(ctx context.Context, sitePath, s3WebDomain, domain, certificateArn string) (string, error) {
-	distribution, err := d.MapCfDistributionToS3(ctx, sitePath, s3WebDomain, domain, certificateArn)
-	if err != nil {
-		return "", err
-	}
-	return aws.ToString(distribution.Id), nil
+	return Distribution{ID: aws.ToString(distribution.Id), Domain: aws.ToString(distribution.DomainName)}, nil
 }
 
 func (d *DNSProvisioner) WaitAndGetDistribution(ctx context.Context, distributionID string) (string, error) {
@@ -145,10 +138,10 @@ func (d *DNSProvisioner) WaitAndGetDistribution(ctx context.Context, distributio
 		}
 
 		status := *resp.Distribution.Status
-		slog.Info("Waiting for deployment", "status", status)
+		slog.InfoContext(ctx, "Waiting for deployment", "status", status)
 
 		if status == "Deployed" {
-			slog.Info("Distribution is deployed!")
+			slog.InfoContext(ctx, "Distribution is deployed!")
 			return aws.ToString(resp.Distribution.DomainName), nil
 		}
 
@@ -158,16 +151,27 @@ func (d *DNSProvisioner) WaitAndGetDistribution(ctx context.Context, distributio
 	return "", errs.RetryableError{Err: fmt.Errorf("timed out waiting for distribution to deploy")}
 }
 
-func (d *DNSProvisioner) DisableDistribution(ctx context.Context, distributionID, siteID, s3Domain string) error {
+func (d *DNSProvisioner) DisableDistribution(ctx context.Context, distributionID string) error {
+	return d.setDistributionEnabled(ctx, distributionID, false)
+}
+
+func (d *DNSProvisioner) EnableDistribution(ctx context.Context, distributionID string) error {
+	return d.setDistributionEnabled(ctx, distributionID, true)
+}
+
+func (d *DNSProvisioner) setDistributionEnabled(ctx context.Context, distributionID string, enabled bool) error {
 	cfg, err := d.cfClient.GetDistributionConfig(ctx, &cloudfront.GetDistributionConfigInput{
 		Id: &distributionID,
 	})
 	if err != nil {
 		return fmt.Errorf("err getting actual distribution cfg, %v", err)
 	}
+	if aws.ToBool(cfg.DistributionConfig.Enabled) == enabled {
+		return nil
+	}
 
 	// change config
-	cfg.DistributionConfig.Enabled = aws.Bool(false)
+	cfg.DistributionConfig.Enabled = aws.Bool(enabled)
 
 	_, err = d.cfClient.UpdateDistribution(ctx, &cloudfront.UpdateDistributionInput{
 		Id:                 &distributionID,
@@ -175,7 +179,67 @@ func (d *DNSProvisioner) DisableDistribution(ctx context.Context, distributionID
 		DistributionConfig: cfg.DistributionConfig,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to disable distribution: %w", err)
+		return fmt.Errorf("failed to update distribution: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DNSProvisioner) SetOriginPath(ctx context.Context, distributionID, originPath string) error {
+	cfg, err := d.cfClient.GetDistributionConfig(ctx, &cloudfront.GetDistributionConfigInput{
+		Id: &distributionID,
+	})
+	if err != nil {
+		return fmt.Errorf("err getting actual distribution cfg, %v", err)
+	}
+	origins := cfg.DistributionConfig.Origins
+	if origins == nil || len(origins.Items) == 0 {
+		return fmt.Errorf("distribution %v has no origins", distributionID)
+	}
+	if aws.ToString(origins.Items[0].OriginPath) == originPath {
+		return nil
+	}
+
+	origins.Items[0].OriginPath = aws.String(originPath)
+
+	_, err = d.cfClient.UpdateDistribution(ctx, &cloudfront.UpdateDistributionInput{
+		Id:                 &distributionID,
+		IfMatch:            cfg.ETag,
+		DistributionConfig: cfg.DistributionConfig,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update distribution: %w", err)
+	}
+
+	return nil
+}
+
+func (d *DNSProvisioner) DeleteDistribution(ctx context.Context, distributionID string) error {
+	res, err := d.cfClient.GetDistribution(ctx, &cloudfront.GetDistributionInput{Id: &distributionID})
+	if err != nil {
+		var notFound *types.NoSuchDistribution
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get distribution: %w", err)
+	}
+	if aws.ToBool(res.Distribution.DistributionConfig.Enabled) {
+		return fmt.Errorf("distribution %v is still enabled", distributionID)
+	}
+	if aws.ToString(res.Distribution.Status) != "Deployed" {
+		return errs.RetryableError{Err: fmt.Errorf("distribution %v is not disabled yet", distributionID)}
+	}
+
+	_, err = d.cfClient.DeleteDistribution(ctx, &cloudfront.DeleteDistributionInput{
+		Id:      &distributionID,
+		IfMatch: res.ETag,
+	})
+	if err != nil {
+		var notFound *types.NoSuchDistribution
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete distribution: %w", err)
 	}
 
 	return nil
@@ -215,13 +279,20 @@ func (d *DNSProvisioner) RequestDomain(ctx context.Context, domain string) (stri
 	return aws.ToString(res.OperationId), nil
 }
 
-func (d *DNSProvisioner) GetDomainStatus(ctx context.Context, operationID string) (rdTypes.OperationStatus, error) {
+func (d *DNSProvisioner) GetDomainStatus(ctx context.Context, operationID string) (RegistrationStatus, error) {
 	res, err := d.domainClient.GetOperationDetail(ctx, &route53domains.GetOperationDetailInput{OperationId: aws.String(operationID)})
 	if err != nil {
 		return "", err
 	}
 
-	return res.Status, nil
+	switch res.Status {
+	case rdTypes.OperationStatusSuccessful:
+		return RegistrationSucceeded, nil
+	case rdTypes.OperationStatusError, rdTypes.OperationStatusFailed:
+		return RegistrationFailed, nil
+	default:
+		return RegistrationInProgress, nil
+	}
 }
 
 func (d *DNSProvisioner) CheckAvailability(ctx context.Context, domain string) (bool, error) {
@@ -323,14 +394,17 @@ func (d *DNSProvisioner) DeleteSubdomain(ctx context.Context, baseDomain, domain
 	return nil
 }
 
-func (d *DNSProvisioner) InvalidateDistribution(ctx context.Context, distributionID string) error {
+func (d *DNSProvisioner) InvalidatePaths(ctx context.Context, distributionID string, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
 	_, err := d.cfClient.CreateInvalidation(ctx, &cloudfront.CreateInvalidationInput{
 		DistributionId: aws.String(distributionID),
 		InvalidationBatch: &types.InvalidationBatch{
 			CallerReference: aws.String(strconv.FormatInt(time.Now().UnixNano(), 10)),
 			Paths: &types.Paths{
-				Quantity: aws.Int32(1),
-				Items:    []string{"/*"},
+				Quantity: aws.Int32(int32(len(paths))),
+				Items:    paths,
 			},
 		},
 	})
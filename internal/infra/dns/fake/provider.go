@@ -0,0 +1,386 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
+)
+
+type Operation string
+
+const (
+	CreateDistribution     Operation = "CreateDistribution"
+	WaitAndGetDistribution Operation = "WaitAndGetDistribution"
+	DisableDistribution    Operation = "DisableDistribution"
+	EnableDistribution     Operation = "EnableDistribution"
+	SetOriginPath          Operation = "SetOriginPath"
+	DeleteDistribution     Operation = "DeleteDistribution"
+	InvalidatePaths        Operation = "InvalidatePaths"
+	CreateSubdomain        Operation = "CreateSubdomain"
+	DeleteSubdomain        Operation = "DeleteSubdomain"
+	CheckAvailability      Operation = "CheckAvailability"
+	RequestDomain          Operation = "RequestDomain"
+	GetDomainStatus        Operation = "GetDomainStatus"
+)
+
+type Config struct {
+	// DeployPolls - how many polls of a created or changed distribution return RetryableError, before it's deployed
+	DeployPolls int
+	// RegistrationPolls - how many polls of a registration return RegistrationInProgress, before it finishes
+	RegistrationPolls int
+	// Latency - every call waits for it or for its context
+	Latency time.Duration
+}
+
+// Distribution - state of a fake distribution
+type Distribution struct {
+	ID             string
+	Domain         string
+	OriginPath     string
+	OriginDomain   string
+	Alias          string
+	CertificateARN string
+	Enabled        bool
+	Invalidations  [][]string
+	// pendingPolls - polls left until the last change is deployed
+	pendingPolls int
+}
+
+func (d *Distribution) Deployed() bool {
+	return d.pendingPolls == 0
+}
+
+type registration struct {
+	domain       string
+	pendingPolls int
+	failed       bool
+}
+
+// Provider - deterministic in-memory dns.Provider, ids and domains are numbered in order of creation.
+// Deployments and registrations finish after a configured number of polls, failures of calls can be queued
+type Provider struct {
+	mu            sync.Mutex
+	cfg           Config
+	seq           int
+	distributions map[string]*Distribution
+	// records - full domain to the cdn domain it points to
+	records       map[string]string
+	registrations map[string]*registration
+	unavailable   map[string]bool
+	failingDomain map[string]bool
+	failures      map[Operation][]error
+	calls         map[Operation]int
+}
+
+var _ dns.Provider = (*Provider)(nil)
+
+func NewProvider(cfg Config) *Provider {
+	return &Provider{
+		cfg:           cfg,
+		distributions: make(map[string]*Distribution),
+		records:       make(map[string]string),
+		registrations: make(map[string]*registration),
+		unavailable:   make(map[string]bool),
+		failingDomain: make(map[string]bool),
+		failures:      make(map[Operation][]error),
+		calls:         make(map[Operation]int),
+	}
+}
+
+// Fail makes the next calls of op return errs, one per call in order
+func (p *Provider) Fail(op Operation, errs ...error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failures[op] = append(p.failures[op], errs...)
+}
+
+// SetUnavailable - domain is taken, so it can't be registered
+func (p *Provider) SetUnavailable(domain string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unavailable[domain] = true
+}
+
+// FailRegistration - registration of domain finishes with RegistrationFailed
+func (p *Provider) FailRegistration(domain string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failingDomain[domain] = true
+}
+
+// Distribution returns a copy of distribution's state
+func (p *Provider) Distribution(id string) (Distribution, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d, ok := p.distributions[id]
+	if !ok {
+		return Distribution{}, false
+	}
+	copied := *d
+	copied.Invalidations = append([][]string(nil), d.Invalidations...)
+	return copied, true
+}
+
+// Record returns the cdn domain, that a full domain points to
+func (p *Provider) Record(domain string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	target, ok := p.records[domain]
+	return target, ok
+}
+
+// Calls - how many times op was called, including failed calls
+func (p *Provider) Calls(op Operation) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls[op]
+}
+
+func (p *Provider) CreateDistribution(ctx context.Context, originPath, originDomain, domain, certificateARN string) (dns.Distribution, error) {
+	if err := p.call(ctx, CreateDistribution); err != nil {
+		return dns.Distribution{}, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, d := range p.distributions {
+		if d.Alias == domain {
+			return dns.Distribution{}, fmt.Errorf("alias %v is already used by distribution %v", domain, d.ID)
+		}
+	}
+
+	p.seq++
+	d := &Distribution{
+		ID:             fmt.Sprintf("E%06d", p.seq),
+		Domain:         fmt.Sprintf("d%06d.cdn.test", p.seq),
+		OriginPath:     originPath,
+		OriginDomain:   originDomain,
+		Alias:          domain,
+		CertificateARN: certificateARN,
+		Enabled:        true,
+		pendingPolls:   p.cfg.DeployPolls,
+	}
+	p.distributions[d.ID] = d
+
+	return dns.Distribution{ID: d.ID, Domain: d.Domain}, nil
+}
+
+func (p *Provider) WaitAndGetDistribution(ctx context.Context, distributionID string) (string, error) {
+	if err := p.call(ctx, WaitAndGetDistribution); err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d, err := p.distribution(distributionID)
+	if err != nil {
+		return "", err
+	}
+	if !d.Deployed() {
+		d.pendingPolls--
+		return "", errs.RetryableError{Err: fmt.Errorf("timed out waiting for distribution to deploy")}
+	}
+
+	return d.Domain, nil
+}
+
+func (p *Provider) DisableDistribution(ctx context.Context, distributionID string) error {
+	return p.setEnabled(ctx, DisableDistribution, distributionID, false)
+}
+
+func (p *Provider) EnableDistribution(ctx context.Context, distributionID string) error {
+	return p.setEnabled(ctx, EnableDistribution, distributionID, true)
+}
+
+func (p *Provider) SetOriginPath(ctx context.Context, distributionID, originPath string) error {
+	if err := p.call(ctx, SetOriginPath); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d, err := p.distribution(distributionID)
+	if err != nil {
+		return err
+	}
+	if d.OriginPath != originPath {
+		d.OriginPath = originPath
+		d.pendingPolls = p.cfg.DeployPolls
+	}
+
+	return nil
+}
+
+func (p *Provider) DeleteDistribution(ctx context.Context, distributionID string) error {
+	if err := p.call(ctx, DeleteDistribution); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d, ok := p.distributions[distributionID]
+	if !ok {
+		return nil
+	}
+	if d.Enabled {
+		return fmt.Errorf("distribution %v is still enabled", distributionID)
+	}
+	if !d.Deployed() {
+		d.pendingPolls--
+		return errs.RetryableError{Err: fmt.Errorf("distribution %v is not disabled yet", distributionID)}
+	}
+	delete(p.distributions, distributionID)
+
+	return nil
+}
+
+func (p *Provider) InvalidatePaths(ctx context.Context, distributionID string, paths []string) error {
+	if err := p.call(ctx, InvalidatePaths); err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d, err := p.distribution(distributionID)
+	if err != nil {
+		return err
+	}
+	d.Invalidations = append(d.Invalidations, append([]string(nil), paths...))
+
+	return nil
+}
+
+func (p *Provider) CreateSubdomain(ctx context.Context, baseDomain, domain, cdnDomain string) error {
+	if err := p.call(ctx, CreateSubdomain); err != nil {
+		return err
+	}
+	if domain != baseDomain && !strings.HasSuffix(domain, "."+baseDomain) {
+		return fmt.Errorf("domain %v is not in the zone of %v", domain, baseDomain)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.records[domain] = cdnDomain
+
+	return nil
+}
+
+func (p *Provider) DeleteSubdomain(ctx context.Context, baseDomain, subdomain, cdnDomain string) error {
+	if err := p.call(ctx, DeleteSubdomain); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	domain := subdomain + "." + baseDomain
+	// like in route53, only an existing record with the same value can be deleted
+	if target, ok := p.records[domain]; !ok || target != cdnDomain {
+		return fmt.Errorf("failed to delete alias record: record %v to %v not found", domain, cdnDomain)
+	}
+	delete(p.records, domain)
+
+	return nil
+}
+
+func (p *Provider) CheckAvailability(ctx context.Context, domain string) (bool, error) {
+	if err := p.call(ctx, CheckAvailability); err != nil {
+		return false, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.unavailable[domain], nil
+}
+
+func (p *Provider) RequestDomain(ctx context.Context, domain string) (string, error) {
+	if err := p.call(ctx, RequestDomain); err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.unavailable[domain] {
+		return "", fmt.Errorf("domain %v is not available", domain)
+	}
+
+	p.seq++
+	operationID := fmt.Sprintf("op-%06d", p.seq)
+	p.registrations[operationID] = &registration{
+		domain:       domain,
+		pendingPolls: p.cfg.RegistrationPolls,
+		failed:       p.failingDomain[domain],
+	}
+	p.unavailable[domain] = true
+
+	return operationID, nil
+}
+
+func (p *Provider) GetDomainStatus(ctx context.Context, operationID string) (dns.RegistrationStatus, error) {
+	if err := p.call(ctx, GetDomainStatus); err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, ok := p.registrations[operationID]
+	if !ok {
+		return "", fmt.Errorf("operation %v not found", operationID)
+	}
+	if r.pendingPolls > 0 {
+		r.pendingPolls--
+		return dns.RegistrationInProgress, nil
+	}
+	if r.failed {
+		return dns.RegistrationFailed, nil
+	}
+
+	return dns.RegistrationSucceeded, nil
+}
+
+// call counts the call, waits for the latency and returns a queued failure
+func (p *Provider) call(ctx context.Context, op Operation) error {
+	p.mu.Lock()
+	p.calls[op]++
+	var err error
+	if queued := p.failures[op]; len(queued) > 0 {
+		err, p.failures[op] = queued[0], queued[1:]
+	}
+	p.mu.Unlock()
+
+	if p.cfg.Latency > 0 {
+		timer := time.NewTimer(p.cfg.Latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return err
+}
+
+func (p *Provider) setEnabled(ctx context.Context, op Operation, distributionID string, enabled bool) error {
+	if err := p.call(ctx, op); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d, err := p.distribution(distributionID)
+	if err != nil {
+		return err
+	}
+	if d.Enabled != enabled {
+		d.Enabled = enabled
+		d.pendingPolls = p.cfg.DeployPolls
+	}
+
+	return nil
+}
+
+// distribution has to be called with mu held
+func (p *Provider) distribution(id string) (*Distribution, error) {
+	d, ok := p.distributions[id]
+	if !ok {
+		return nil, fmt.Errorf("distribution %v not found", id)
+	}
+	return d, nil
+}
@@ -0,0 +1,54 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/dns/fake"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WaitAndGetDistribution_When_Deployment_Is_Slow_Then_Retry_Until_Deployed(t *testing.T) {
+	ctx := context.Background()
+	SUT := fake.NewProvider(fake.Config{DeployPolls: 2})
+	SUT.Fail(fake.CreateDistribution, errors.New("throttled"))
+
+	_, err := SUT.CreateDistribution(ctx, "/sites/1/versions/1", "bucket.s3.test", "site.example.com", "arn")
+	require.Error(t, err)
+	distribution, err := SUT.CreateDistribution(ctx, "/sites/1/versions/1", "bucket.s3.test", "site.example.com", "arn")
+	require.NoError(t, err)
+	require.Equal(t, dns.Distribution{ID: "E000001", Domain: "d000001.cdn.test"}, distribution)
+
+	for range 2 {
+		_, err = SUT.WaitAndGetDistribution(ctx, distribution.ID)
+		require.ErrorAs(t, err, &errs.RetryableError{})
+	}
+	cdnDomain, err := SUT.WaitAndGetDistribution(ctx, distribution.ID)
+	require.NoError(t, err)
+	require.Equal(t, distribution.Domain, cdnDomain)
+
+	require.NoError(t, SUT.CreateSubdomain(ctx, "example.com", "site.example.com", cdnDomain))
+	target, ok := SUT.Record("site.example.com")
+	require.True(t, ok)
+	require.Equal(t, cdnDomain, target)
+	require.Equal(t, 2, SUT.Calls(fake.CreateDistribution))
+}
+
+func Test_DeleteDistribution_When_Disabling_Is_Not_Deployed_Then_Return_Retryable_Error(t *testing.T) {
+	ctx := context.Background()
+	SUT := fake.NewProvider(fake.Config{DeployPolls: 1})
+	distribution, err := SUT.CreateDistribution(ctx, "/previews/1/token", "bucket.s3.test", "preview.example.com", "arn")
+	require.NoError(t, err)
+
+	require.Error(t, SUT.DeleteDistribution(ctx, distribution.ID))
+	require.NoError(t, SUT.DisableDistribution(ctx, distribution.ID))
+	require.ErrorAs(t, SUT.DeleteDistribution(ctx, distribution.ID), &errs.RetryableError{})
+	require.NoError(t, SUT.DeleteDistribution(ctx, distribution.ID))
+
+	_, ok := SUT.Distribution(distribution.ID)
+	require.False(t, ok)
+	require.NoError(t, SUT.DeleteDistribution(ctx, distribution.ID))
+}
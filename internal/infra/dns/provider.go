@@ -0,0 +1,60 @@
+package dns
+
+import "context"
+
+// Distribution - cdn distribution, Domain is the target of dns records of the site
+type Distribution struct {
+	ID     string
+	Domain string
+}
+
+// CDNProvider serves prefixes of the bucket on domains. Changes of a distribution are deployed asynchronously
+type CDNProvider interface {
+	// CreateDistribution serves originPath of originDomain on domain with the certificate
+	CreateDistribution(ctx context.Context, originPath, originDomain, domain, certificateARN string) (Distribution, error)
+	// WaitAndGetDistribution returns domain of a deployed distribution, RetryableError while it's being deployed
+	WaitAndGetDistribution(ctx context.Context, distributionID string) (string, error)
+	DisableDistribution(ctx context.Context, distributionID string) error
+	// EnableDistribution reverts DisableDistribution, distribution has to be redeployed before it serves the site again
+	EnableDistribution(ctx context.Context, distributionID string) error
+	// SetOriginPath points distribution to another prefix, cached files have to be invalidated after it
+	SetOriginPath(ctx context.Context, distributionID, originPath string) error
+	// DeleteDistribution removes a disabled distribution, RetryableError until disabling is deployed.
+	// A missing distribution is treated as already deleted
+	DeleteDistribution(ctx context.Context, distributionID string) error
+	// InvalidatePaths removes cached paths, paths start with / and can end with a wildcard
+	InvalidatePaths(ctx context.Context, distributionID string, paths []string) error
+}
+
+// DNSZoneProvider manages records of hosted zones, that point domains of sites to their distributions
+type DNSZoneProvider interface {
+	// CreateSubdomain points domain, a full name in the zone of baseDomain, to cdnDomain
+	CreateSubdomain(ctx context.Context, baseDomain, domain, cdnDomain string) error
+	// DeleteSubdomain removes the record of subdomain, a name relative to baseDomain
+	DeleteSubdomain(ctx context.Context, baseDomain, subdomain, cdnDomain string) error
+}
+
+type RegistrationStatus string
+
+const (
+	RegistrationInProgress RegistrationStatus = "InProgress"
+	RegistrationSucceeded  RegistrationStatus = "Succeeded"
+	RegistrationFailed     RegistrationStatus = "Failed"
+)
+
+// DomainRegistrar registers separate domains of sites, registration can take up to a few days
+type DomainRegistrar interface {
+	CheckAvailability(ctx context.Context, domain string) (bool, error)
+	// RequestDomain starts registration of an available domain and returns id of the operation
+	RequestDomain(ctx context.Context, domain string) (string, error)
+	GetDomainStatus(ctx context.Context, operationID string) (RegistrationStatus, error)
+}
+
+// Provider - everything needed to serve a site on a domain
+type Provider interface {
+	CDNProvider
+	DNSZoneProvider
+	DomainRegistrar
+}
+
+var _ Provider = (*DNSProvisioner)(nil)
@@ -0,0 +1,55 @@
+package fake
+
+import (
+	"sync"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/mail"
+)
+
+// Mail - message passed to the sender
+type Mail struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Sender - mail.Sender, that keeps mails in memory instead of sending them
+type Sender struct {
+	mu   sync.Mutex
+	sent []Mail
+	// failures - errors returned by the next sends, one per send in order
+	failures []error
+}
+
+var _ mail.Sender = (*Sender)(nil)
+
+func NewSender() *Sender {
+	return &Sender{}
+}
+
+// Fail makes the next sends return errs, one per send in order. Failed mails aren't kept
+func (s *Sender) Fail(errs ...error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, errs...)
+}
+
+// Sent returns mails in order of sending
+func (s *Sender) Sent() []Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mail(nil), s.sent...)
+}
+
+func (s *Sender) SendMail(to []string, subject, body string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) > 0 {
+		err := s.failures[0]
+		s.failures = s.failures[1:]
+		return err
+	}
+	s.sent = append(s.sent, Mail{To: append([]string(nil), to...), Subject: subject, Body: body})
+
+	return nil
+}
@@ -6,6 +6,13 @@ import (
 	"strings"
 )
 
+// Sender delivers rendered html mails
+type Sender interface {
+	SendMail(to []string, subject, body string) error
+}
+
+var _ Sender = (*MailServer)(nil)
+
 type MailServer struct {
 	cfg  *MailConfig
 	auth smtp.Auth
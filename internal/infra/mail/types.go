@@ -5,6 +5,7 @@ type MailType string
 const (
 	SiteCreated         MailType = "SiteCreated"
 	SiteDeactivated     MailType = "SiteDeactivated"
+	SiteReactivated     MailType = "SiteReactivated"
 	RegistrationConfirm MailType = "RegistrationConfirm"
 	RegistrationSuccess MailType = "RegistrationSuccess"
 	FreeTrialEnds       MailType = "FreeTrialEnds"
@@ -62,6 +63,21 @@ func (s SiteDeactivatedData) GetSubject() string {
 	return "Your site was deactivated"
 }
 
+type SiteReactivatedData struct {
+	Year               string
+	SiteURL            string
+	CustomerFirstName  string
+	CustomerSecondName string
+}
+
+func (s SiteReactivatedData) GetMailType() MailType {
+	return SiteReactivated
+}
+
+func (s SiteReactivatedData) GetSubject() string {
+	return "Your site is active again"
+}
+
 type RegistrationConfirmData struct {
 	Year        string
 	RedirectURL string
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// listPageSize - keys in a page of a listing, it's also the limit of a batch delete in s3
+const listPageSize = 1000
+
+// ObjectInfo - ETag of an object is md5 of its content, unless it was uploaded in parts
+type ObjectInfo struct {
+	Key  string
+	ETag string
+	Size int64
+}
+
+// PutOptions - headers, that are returned to browsers with an object
+type PutOptions struct {
+	ContentType  string
+	CacheControl string
+}
+
+// BlobStore keeps objects by keys, separated with /. Prefixes are matched as strings, like in s3
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.ReadSeeker, opts PutOptions) error
+	// Get streams an object, it has to be closed by the caller. Missing object returns ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Head returns ErrNotFound, when object is missing
+	Head(ctx context.Context, key string) (ObjectInfo, error)
+	// List calls fn with pages of objects under prefix in order of their keys, until all are listed or fn fails
+	List(ctx context.Context, prefix string, fn func(page []ObjectInfo) error) error
+	// Copy copies an object with its headers without downloading it
+	Copy(ctx context.Context, src, dst string) error
+	// Delete ignores missing keys
+	Delete(ctx context.Context, keys []string) error
+	// URL - public url of an object
+	URL(key string) string
+}
@@ -0,0 +1,217 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LocalStore keeps objects as files under a directory, for development and tests without s3.
+// Headers of objects aren't kept, ETag is md5 of the content, like for objects uploaded to s3 in one part
+type LocalStore struct {
+	root    string
+	baseURL string
+}
+
+// NewLocalStore - urls of objects start with baseURL, files are served from root, when it's empty
+func NewLocalStore(root, baseURL string) (*LocalStore, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("err resolving storage dir, %v", err)
+	}
+	if err = os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("err creating storage dir, %v", err)
+	}
+	if baseURL == "" {
+		baseURL = "file://" + filepath.ToSlash(root)
+	}
+	return &LocalStore{root: root, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, body io.ReadSeeker, opts PutOptions) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return fmt.Errorf("err uploading %v, %v", key, err)
+	}
+	// written to a temp file, so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-")
+	if err != nil {
+		return fmt.Errorf("err uploading %v, %v", key, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("err uploading %v, %v", key, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("err uploading %v, %v", key, err)
+	}
+	if err = os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("err uploading %v, %v", key, err)
+	}
+
+	return nil
+}
+
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	filePath, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("err downloading %v, %w", key, ErrNotFound)
+		}
+		return nil, fmt.Errorf("err downloading %v, %v", key, err)
+	}
+
+	return file, nil
+}
+
+func (s *LocalStore) Head(ctx context.Context, key string) (ObjectInfo, error) {
+	filePath, err := s.path(key)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info, err := s.stat(key, filePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ObjectInfo{}, fmt.Errorf("err checking object %v, %w", key, ErrNotFound)
+		}
+		return ObjectInfo{}, fmt.Errorf("err checking object %v, %v", key, err)
+	}
+
+	return info, nil
+}
+
+func (s *LocalStore) List(ctx context.Context, prefix string, fn func(page []ObjectInfo) error) error {
+	// prefix can end in the middle of a name, so the walk starts from its last complete directory
+	dir := filepath.Join(s.root, filepath.FromSlash(path.Dir("/"+prefix)))
+	var keys []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("err listing objects under %v, %v", prefix, err)
+	}
+	sort.Strings(keys)
+
+	for start := 0; start < len(keys); start += listPageSize {
+		end := min(start+listPageSize, len(keys))
+		page := make([]ObjectInfo, 0, end-start)
+		for _, key := range keys[start:end] {
+			info, err := s.stat(key, filepath.Join(s.root, filepath.FromSlash(key)))
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return fmt.Errorf("err listing objects under %v, %v", prefix, err)
+			}
+			page = append(page, info)
+		}
+		if err = fn(page); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *LocalStore) Copy(ctx context.Context, src, dst string) error {
+	srcPath, err := s.path(src)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(srcPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("err copying %v, %w", src, ErrNotFound)
+		}
+		return fmt.Errorf("err copying %v, %v", src, err)
+	}
+	defer file.Close()
+
+	return s.Put(ctx, dst, file, PutOptions{})
+}
+
+func (s *LocalStore) Delete(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		filePath, err := s.path(key)
+		if err != nil {
+			return err
+		}
+		if err = os.Remove(filePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("err deleting %v, %v", key, err)
+		}
+		s.removeEmptyDirs(filepath.Dir(filePath))
+	}
+
+	return nil
+}
+
+func (s *LocalStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// path - file of an object, keys, that point outside of the root, are rejected
+func (s *LocalStore) path(key string) (string, error) {
+	filePath := filepath.Join(s.root, filepath.FromSlash(key))
+	if key == "" || !strings.HasPrefix(filePath, s.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filePath, nil
+}
+
+func (s *LocalStore) stat(key, filePath string) (ObjectInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer file.Close()
+	h := md5.New()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	return ObjectInfo{Key: key, ETag: hex.EncodeToString(h.Sum(nil)), Size: size}, nil
+}
+
+// removeEmptyDirs - s3 has no directories, so prefixes without objects disappear
+func (s *LocalStore) removeEmptyDirs(dir string) {
+	for dir != s.root && strings.HasPrefix(dir, s.root) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
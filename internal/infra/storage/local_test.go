@@ -0,0 +1,71 @@
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ListKeys_When_Prefix_Has_More_Than_A_Page_Then_List_All_Keys(t *testing.T) {
+	ctx := context.Background()
+	localStore, err := storage.NewLocalStore(t.TempDir(), "http://localhost:9000/bucket")
+	require.NoError(t, err)
+	SUT := storage.NewBlobStorage(localStore)
+	for i := range 1005 {
+		require.NoError(t, SUT.PutObject(ctx, fmt.Sprintf("templates/landing/%04d.txt", i), []byte("x"), "text/plain", ""))
+	}
+	require.NoError(t, SUT.PutObject(ctx, "templates/landing-v2/index.html", []byte("x"), "text/html", ""))
+
+	keys, err := SUT.ListKeys(ctx, "templates/landing/", 0)
+	require.NoError(t, err)
+	require.Len(t, keys, 1005)
+	require.Equal(t, "templates/landing/0000.txt", keys[0])
+
+	keys, err = SUT.ListKeys(ctx, "templates/landing", 0)
+	require.NoError(t, err)
+	require.Len(t, keys, 1006)
+	require.Equal(t, "http://localhost:9000/bucket/templates/landing-v2/index.html", SUT.GetFileURL(keys[0]))
+}
+
+func Test_SyncPrefix_When_Objects_Changed_Then_Copy_Changed_And_Delete_Orphaned(t *testing.T) {
+	ctx := context.Background()
+	localStore, err := storage.NewLocalStore(t.TempDir(), "")
+	require.NoError(t, err)
+	SUT := storage.NewBlobStorage(localStore)
+	put := func(key, content string) {
+		_, err := SUT.UploadFile(ctx, key, nil, strings.NewReader(content))
+		require.NoError(t, err)
+	}
+	put("build-cache/key/index.html", "<h1>new</h1>")
+	put("build-cache/key/about/index.html", "<h1>about</h1>")
+	put("build-cache/key/.complete", "hash")
+	put("sites/1/versions/2/about/index.html", "<h1>about</h1>")
+	put("sites/1/versions/2/index.html", "<h1>old</h1>")
+	put("sites/1/versions/2/old.html", "<h1>old</h1>")
+
+	changed, err := SUT.SyncPrefix(ctx, "build-cache/key", "sites/1/versions/2", func(key string) bool {
+		return key == ".complete"
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"index.html", "old.html"}, changed)
+
+	keys, err := SUT.ListKeys(ctx, "sites/1/", 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"sites/1/versions/2/about/index.html", "sites/1/versions/2/index.html"}, keys)
+	content, err := SUT.GetFile(ctx, "sites/1/versions/2/index.html")
+	require.NoError(t, err)
+	require.Equal(t, "<h1>new</h1>", string(content))
+
+	exists, err := SUT.ObjectExists(ctx, "sites/1/versions/2/old.html")
+	require.NoError(t, err)
+	require.False(t, exists)
+	deleted, err := SUT.DeletePrefix(ctx, "sites/1/")
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+	_, err = SUT.GetFile(ctx, "../outside")
+	require.Error(t, err)
+}
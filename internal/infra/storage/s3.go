@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Store keeps objects in a bucket of s3 or a compatible storage, f.e. minio
+type S3Store struct {
+	client *s3.Client
+	bucket string
+	region string
+}
+
+func NewS3Store(config aws.Config, bucket, region string) *S3Store {
+	client := s3.NewFromConfig(config, func(o *s3.Options) {
+		o.UsePathStyle = true
+	})
+	return &S3Store{client: client, bucket: bucket, region: region}
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body io.ReadSeeker, opts PutOptions) error {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+	if opts.ContentType != "" {
+		input.ContentType = aws.String(opts.ContentType)
+	}
+	if opts.CacheControl != "" {
+		input.CacheControl = aws.String(opts.CacheControl)
+	}
+	_, err := s.client.PutObject(ctx, input)
+	if err != nil {
+		return fmt.Errorf("err uploading %v, %v", key, err)
+	}
+
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &s.bucket,
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, fmt.Errorf("err downloading %v, %w", key, ErrNotFound)
+		}
+		return nil, fmt.Errorf("err downloading %v, %v", key, err)
+	}
+
+	return resp.Body, nil
+}
+
+func (s *S3Store) Head(ctx context.Context, key string) (ObjectInfo, error) {
+	resp, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &s.bucket,
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return ObjectInfo{}, fmt.Errorf("err checking object %v, %w", key, ErrNotFound)
+		}
+		return ObjectInfo{}, fmt.Errorf("err checking object %v, %v", key, err)
+	}
+
+	return ObjectInfo{
+		Key:  key,
+		ETag: strings.Trim(aws.ToString(resp.ETag), `"`),
+		Size: aws.ToInt64(resp.ContentLength),
+	}, nil
+}
+
+func (s *S3Store) List(ctx context.Context, prefix string, fn func(page []ObjectInfo) error) error {
+	p := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket:  &s.bucket,
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int32(listPageSize),
+	})
+
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("err listing objects under %v, %v", prefix, err)
+		}
+		if len(page.Contents) == 0 {
+			continue
+		}
+		objects := make([]ObjectInfo, 0, len(page.Contents))
+		for _, obj := range page.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:  aws.ToString(obj.Key),
+				ETag: strings.Trim(aws.ToString(obj.ETag), `"`),
+				Size: aws.ToInt64(obj.Size),
+			})
+		}
+		if err = fn(objects); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *S3Store) Copy(ctx context.Context, src, dst string) error {
+	// metadata of the source, f.e. content type, is copied with the object
+	_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     &s.bucket,
+		CopySource: aws.String(s.bucket + "/" + escapeKey(src)),
+		Key:        aws.String(dst),
+	})
+	if err != nil {
+		return fmt.Errorf("err copying %v to %v, %v", src, dst, err)
+	}
+
+	return nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, keys []string) error {
+	for start := 0; start < len(keys); start += listPageSize {
+		end := min(start+listPageSize, len(keys))
+		objects := make([]types.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			objects = append(objects, types.ObjectIdentifier{Key: aws.String(key)})
+		}
+		res, err := s.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: &s.bucket,
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return fmt.Errorf("err deleting objects, %v", err)
+		}
+		if len(res.Errors) > 0 {
+			return fmt.Errorf("err deleting %v, %v", aws.ToString(res.Errors[0].Key), aws.ToString(res.Errors[0].Message))
+		}
+	}
+
+	return nil
+}
+
+func (s *S3Store) URL(key string) string {
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", s.bucket, s.region, key)
+}
+
+// escapeKey url-encodes segments of a key, copy source has to be encoded
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,297 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Builder-Lawyers/builder-backend/pkg/env"
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// Storage - operations of the app over a BlobStore, so they work the same with s3 and a local directory
+type Storage struct {
+	blobs BlobStore
+}
+
+func NewStorage(config aws.Config) *Storage {
+	return NewBlobStorage(NewS3Store(
+		config,
+		env.GetEnv("S3_BUCKET", "sanity-web"),
+		env.GetEnv("AWS_DEFAULT_REGION", "eu-north-1"),
+	))
+}
+
+func NewBlobStorage(blobs BlobStore) *Storage {
+	return &Storage{blobs: blobs}
+}
+
+// SitePrefix - all files of a site are kept under it
+func SitePrefix(siteID uint64) string {
+	return "sites/" + strconv.FormatUint(siteID, 10)
+}
+
+// SiteVersionPrefix - build of a published version of a site
+func SiteVersionPrefix(siteID uint64, version int) string {
+	return SitePrefix(siteID) + "/versions/" + strconv.Itoa(version)
+}
+
+// SitePreviewPrefix - build of site's draft, served by a preview
+func SitePreviewPrefix(siteID uint64, token string) string {
+	return "previews/" + strconv.FormatUint(siteID, 10) + "/" + token
+}
+
+// BuildCachePrefix - output of a build, addressed by the hash of template's sources and site's fields
+func BuildCachePrefix(key string) string {
+	return "build-cache/" + key
+}
+
+func (s *Storage) UploadFile(ctx context.Context, key string, contentType *string, body io.Reader) (string, error) {
+	var ct string
+
+	data, err := io.ReadAll(body)
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("reading for content-type detection: %v", err)
+	}
+
+	if contentType == nil {
+		ct = http.DetectContentType(data)
+		if strings.HasSuffix(key, ".svg") {
+			ct = "image/svg+xml"
+		}
+		if strings.HasSuffix(key, ".css") {
+			ct = "text/css"
+		}
+	} else {
+		ct = *contentType
+	}
+	err = s.blobs.Put(ctx, key, bytes.NewReader(data), PutOptions{ContentType: ct})
+	if err != nil {
+		return "", err
+	}
+
+	return s.GetFileURL(key), nil
+}
+
+func (s *Storage) GetFileURL(key string) string {
+	return s.blobs.URL(key)
+}
+
+// ListKeys returns keys under prefix in order, all of them, when limit isn't positive
+func (s *Storage) ListKeys(ctx context.Context, prefix string, limit int) ([]string, error) {
+	errLimitReached := errors.New("limit reached")
+	var keys []string
+	err := s.blobs.List(ctx, prefix, func(page []ObjectInfo) error {
+		for _, obj := range page {
+			if limit > 0 && len(keys) == limit {
+				return errLimitReached
+			}
+			keys = append(keys, obj.Key)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errLimitReached) {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// DeletePrefix removes all objects under prefix, returns how many were deleted
+func (s *Storage) DeletePrefix(ctx context.Context, prefix string) (int, error) {
+	var deleted int
+	err := s.blobs.List(ctx, prefix, func(page []ObjectInfo) error {
+		keys := make([]string, 0, len(page))
+		for _, obj := range page {
+			keys = append(keys, obj.Key)
+		}
+		if err := s.blobs.Delete(ctx, keys); err != nil {
+			return err
+		}
+		deleted += len(keys)
+		return nil
+	})
+
+	return deleted, err
+}
+
+// ObjectExists checks, if an object is stored under the key
+func (s *Storage) ObjectExists(ctx context.Context, key string) (bool, error) {
+	_, err := s.blobs.Head(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ListObjects returns objects under prefix by their keys relative to it
+func (s *Storage) ListObjects(ctx context.Context, prefix string) (map[string]ObjectInfo, error) {
+	objects := make(map[string]ObjectInfo)
+	err := s.blobs.List(ctx, prefix+"/", func(page []ObjectInfo) error {
+		for _, obj := range page {
+			objects[strings.TrimPrefix(obj.Key, prefix+"/")] = obj
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// PutObject uploads an object with headers, that are returned to browsers
+func (s *Storage) PutObject(ctx context.Context, key string, data []byte, contentType, cacheControl string) error {
+	return s.blobs.Put(ctx, key, bytes.NewReader(data), PutOptions{ContentType: contentType, CacheControl: cacheControl})
+}
+
+// DeleteKeys deletes objects, missing ones are ignored
+func (s *Storage) DeleteKeys(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return s.blobs.Delete(ctx, keys)
+}
+
+// SyncPrefix makes objects under dst the same as under src, only changed objects are copied inside the bucket,
+// objects missing under src are deleted. Keys, that are accepted by skip, aren't copied or deleted.
+// Changed keys are returned relative to dst
+func (s *Storage) SyncPrefix(ctx context.Context, src, dst string, skip func(key string) bool) ([]string, error) {
+	srcObjects, err := s.ListObjects(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	dstObjects, err := s.ListObjects(ctx, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for key, obj := range srcObjects {
+		if skip != nil && skip(key) {
+			continue
+		}
+		if existing, ok := dstObjects[key]; ok && existing.ETag == obj.ETag {
+			continue
+		}
+		if err = s.blobs.Copy(ctx, src+"/"+key, dst+"/"+key); err != nil {
+			return changed, err
+		}
+		changed = append(changed, key)
+	}
+
+	var orphaned []string
+	for key := range dstObjects {
+		if _, ok := srcObjects[key]; !ok && (skip == nil || !skip(key)) {
+			orphaned = append(orphaned, dst+"/"+key)
+			changed = append(changed, key)
+		}
+	}
+	if err = s.DeleteKeys(ctx, orphaned); err != nil {
+		return changed, err
+	}
+
+	return changed, nil
+}
+
+// DiffPrefixes returns keys relative to prefixes, that differ between them
+func (s *Storage) DiffPrefixes(ctx context.Context, a, b string) ([]string, error) {
+	aObjects, err := s.ListObjects(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	bObjects, err := s.ListObjects(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for key, obj := range bObjects {
+		if existing, ok := aObjects[key]; !ok || existing.ETag != obj.ETag {
+			changed = append(changed, key)
+		}
+	}
+	for key := range aObjects {
+		if _, ok := bObjects[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+
+	return changed, nil
+}
+
+func (s *Storage) GetFile(ctx context.Context, key string) ([]byte, error) {
+	body, err := s.blobs.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file contents, %v", err)
+	}
+
+	return data, nil
+}
+
+// OpenFile streams an object, it has to be closed by the caller
+func (s *Storage) OpenFile(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.blobs.Get(ctx, key)
+}
+
+// destination - local path where to upload files
+// pathTo - relative path from root to template's folder
+func (s *Storage) DownloadFiles(ctx context.Context, keys []string, destination, pathTo string) error {
+	for _, key := range keys {
+		body, err := s.blobs.Get(ctx, key)
+		if err != nil {
+			return fmt.Errorf("error downloading key %s: %w", key, err)
+		}
+		destKey := strings.TrimPrefix(key, pathTo)
+
+		err = s.readAndCopyObjectTo(body, filepath.Join(destination, destKey))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Storage) readAndCopyObjectTo(content io.ReadCloser, destination string) error {
+	//slog.InfoContext(ctx, "saving file to", "dest", destination)
+	if err := os.MkdirAll(filepath.Dir(destination), os.ModePerm); err != nil {
+		return fmt.Errorf("error creating directories for %s: %w", destination, err)
+	}
+	outFile, err := os.Create(destination)
+	if err != nil {
+		return fmt.Errorf("error creating file %s: %w", destination, err)
+	}
+
+	defer func() {
+		_ = content.Close()
+		_ = outFile.Close()
+	}()
+
+	_, err = io.Copy(outFile, content)
+	if err != nil {
+		return fmt.Errorf("error writing to file %s: %w", destination, err)
+	}
+
+	return nil
+}
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"log/slog"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/Builder-Lawyers/builder-backend/internal/application/commands/template"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/correlation"
 	"github.com/Builder-Lawyers/builder-backend/pkg/env"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
@@ -19,7 +21,12 @@ type TemplateChangesPoller struct {
 	client  *sqs.Client
 	cfg     TemplateChangesConfig
 	handler *template.RebuildTemplate
-	stop    chan struct{}
+	// ctx is passed to rebuilds, it's cancelled only if shutdown deadline is exceeded
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
 }
 
 type TemplateChangesConfig struct {
@@ -41,78 +48,165 @@ type TemplatesChanges struct {
 	Templates []string `json:"templates"`
 }
 
+// releaseTimeout bounds the cleanup, done after the shutdown deadline is exceeded
+const releaseTimeout = 5 * time.Second
+
 func NewTemplateChangesPoller(client *sqs.Client, cfg TemplateChangesConfig, handler *template.RebuildTemplate) *TemplateChangesPoller {
-	return &TemplateChangesPoller{client: client, cfg: cfg, stop: make(chan struct{}), handler: handler}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TemplateChangesPoller{
+		client:  client,
+		cfg:     cfg,
+		handler: handler,
+		ctx:     ctx,
+		cancel:  cancel,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
 }
 
 func (p *TemplateChangesPoller) Start() {
 	slog.Info("Starting poll of TemplateChangesPoller...")
-	ctx := context.Background()
+	defer close(p.done)
 
-	for {
+	// long poll is interrupted on stop, so that shutdown doesn't wait for it
+	receiveCtx, cancelReceive := context.WithCancel(context.Background())
+	defer cancelReceive()
+	go func() {
 		select {
 		case <-p.stop:
+			cancelReceive()
+		case <-receiveCtx.Done():
+		}
+	}()
+
+	for {
+		if p.stopping() {
 			slog.Info("Stopping TemplateChangesPoller loop")
 			return
-		default:
-			slog.Debug("Template Changes poll")
-			out, err := p.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
-				QueueUrl:            aws.String(p.cfg.SqsURL),
-				MaxNumberOfMessages: 10,
-				WaitTimeSeconds:     20,
-				VisibilityTimeout:   30,
-			})
-			if err != nil {
-				slog.Info("err receiving from queue", "err", err)
-				time.Sleep(time.Second)
-				continue
-			}
-			if len(out.Messages) == 0 {
+		}
+
+		slog.Debug("Template Changes poll")
+		out, err := p.client.ReceiveMessage(receiveCtx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(p.cfg.SqsURL),
+			MaxNumberOfMessages: 10,
+			WaitTimeSeconds:     20,
+			VisibilityTimeout:   30,
+		})
+		if err != nil {
+			if p.stopping() {
 				continue
 			}
+			slog.Info("err receiving from queue", "err", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		if len(out.Messages) == 0 {
+			continue
+		}
 
-			// TODO: consume all messages and collect templates in set before processing
-			processedMessages := make([]types.DeleteMessageBatchRequestEntry, len(out.Messages))
-			changedTemplates := make(map[string]struct{})
-			for i, m := range out.Messages {
-				slog.Debug("msg received from queue", "msg", *m.Body)
-
-				var templatesChanges TemplatesChanges
-				err = json.Unmarshal([]byte(*m.Body), &templatesChanges)
-				if err != nil {
-					slog.Error("err unmarshalling msg", "id", m.MessageId, "err", err)
-				}
-
-				for _, templateToChange := range templatesChanges.Templates {
-					if _, ok := changedTemplates[templateToChange]; !ok {
-						changedTemplates[templateToChange] = struct{}{}
-					}
-				}
-
-				processedMessages[i] = types.DeleteMessageBatchRequestEntry{
-					Id:            m.MessageId,
-					ReceiptHandle: m.ReceiptHandle,
-				}
-			}
+		p.processMessages(out.Messages)
+	}
+}
 
-			for changedTemplate, _ := range changedTemplates {
-				err = p.handler.Execute(ctx, &dto.RebuildTemplatesRequest{Name: &changedTemplate})
-				if err != nil {
-					slog.Error("err updating template", "template", changedTemplate, "err", err)
-				}
-			}
+func (p *TemplateChangesPoller) processMessages(messages []types.Message) {
+	// a batch of template changes is traced as a single request
+	ctx := correlation.WithID(p.ctx, correlation.NewID())
+	processedMessages := make([]types.DeleteMessageBatchRequestEntry, len(messages))
+	changedTemplates := make(map[string]struct{})
+	for i, m := range messages {
+		slog.DebugContext(ctx, "msg received from queue", "msg", *m.Body)
+
+		var templatesChanges TemplatesChanges
+		err := json.Unmarshal([]byte(*m.Body), &templatesChanges)
+		if err != nil {
+			slog.ErrorContext(ctx, "err unmarshalling msg", "id", m.MessageId, "err", err)
+		}
 
-			_, err = p.client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
-				QueueUrl: aws.String(p.cfg.SqsURL),
-				Entries:  processedMessages,
-			})
-			if err != nil {
-				slog.Error("err deleting message", "err", err)
+		for _, templateToChange := range templatesChanges.Templates {
+			if _, ok := changedTemplates[templateToChange]; !ok {
+				changedTemplates[templateToChange] = struct{}{}
 			}
 		}
+
+		processedMessages[i] = types.DeleteMessageBatchRequestEntry{
+			Id:            m.MessageId,
+			ReceiptHandle: m.ReceiptHandle,
+		}
+	}
+
+	for changedTemplate, _ := range changedTemplates {
+		if p.stopping() {
+			// messages are redelivered after restart, template rebuilds are idempotent
+			p.releaseMessages(messages)
+			return
+		}
+		err := p.handler.Execute(ctx, &dto.RebuildTemplatesRequest{Name: &changedTemplate})
+		if err != nil {
+			slog.ErrorContext(ctx, "err updating template", "template", changedTemplate, "err", err)
+		}
+	}
+	if p.ctx.Err() != nil {
+		p.releaseMessages(messages)
+		return
+	}
+
+	_, err := p.client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+		QueueUrl: aws.String(p.cfg.SqsURL),
+		Entries:  processedMessages,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "err deleting message", "err", err)
 	}
 }
 
-func (p *TemplateChangesPoller) Stop() {
-	p.stop <- struct{}{}
+// releaseMessages makes unacknowledged messages visible again, without waiting for visibility timeout
+func (p *TemplateChangesPoller) releaseMessages(messages []types.Message) {
+	entries := make([]types.ChangeMessageVisibilityBatchRequestEntry, len(messages))
+	for i, m := range messages {
+		entries[i] = types.ChangeMessageVisibilityBatchRequestEntry{
+			Id:                m.MessageId,
+			ReceiptHandle:     m.ReceiptHandle,
+			VisibilityTimeout: 0,
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	_, err := p.client.ChangeMessageVisibilityBatch(ctx, &sqs.ChangeMessageVisibilityBatchInput{
+		QueueUrl: aws.String(p.cfg.SqsURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		slog.Error("err releasing messages", "err", err)
+		return
+	}
+	slog.Info("released unprocessed messages", "count", len(entries))
+}
+
+func (p *TemplateChangesPoller) stopping() bool {
+	select {
+	case <-p.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stop stops receiving messages and waits for a running rebuild until ctx is done,
+// after that the rebuild is cancelled. Messages of unfinished batch are released
+func (p *TemplateChangesPoller) Stop(ctx context.Context) error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	slog.Warn("shutdown deadline exceeded, cancelling template rebuilds")
+	p.cancel()
+	select {
+	case <-p.done:
+	case <-time.After(releaseTimeout):
+	}
+	return ctx.Err()
 }
@@ -0,0 +1,108 @@
+package rest
+
+import (
+	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListOutboxEventsParams are generated along with models, but referenced by server without a package
+type ListOutboxEventsParams = dto.ListOutboxEventsParams
+
+func (s *Server) ListOutboxEvents(c *fiber.Ctx, params ListOutboxEventsParams) error {
+	var err error
+	defer logError(c.UserContext(), &err, "ListOutboxEvents")
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	resp, err := s.queries.GetOutboxEvent.QueryList(c.UserContext(), &params, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *Server) GetOutboxEvent(c *fiber.Ctx, id uint64) error {
+	var err error
+	defer logError(c.UserContext(), &err, "GetOutboxEvent")
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	resp, err := s.queries.GetOutboxEvent.Query(c.UserContext(), id, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *Server) EnqueueOutboxEvent(c *fiber.Ctx) error {
+	var req dto.EnqueueOutboxEventRequest
+	var err error
+	defer logError(c.UserContext(), &err, "EnqueueOutboxEvent")
+	if err = c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+	id, err := s.commands.ManageOutbox.Enqueue(c.UserContext(), &req, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(dto.EnqueueOutboxEventResponse{Id: id})
+}
+
+func (s *Server) ReplayOutboxEvent(c *fiber.Ctx, id uint64) error {
+	var req dto.OutboxActionRequest
+	var err error
+	defer logError(c.UserContext(), &err, "ReplayOutboxEvent")
+	if err = parseOptionalBody(c, &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+	err = s.commands.ManageOutbox.Replay(c.UserContext(), id, &req, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (s *Server) CancelOutboxEvent(c *fiber.Ctx, id uint64) error {
+	var req dto.OutboxActionRequest
+	var err error
+	defer logError(c.UserContext(), &err, "CancelOutboxEvent")
+	if err = parseOptionalBody(c, &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+	err = s.commands.ManageOutbox.Cancel(c.UserContext(), id, &req, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func parseOptionalBody(c *fiber.Ctx, out interface{}) error {
+	if len(c.Body()) == 0 {
+		return nil
+	}
+	return c.BodyParser(out)
+}
@@ -5,13 +5,30 @@ package rest
 
 import (
 	"fmt"
+	"net/url"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/oapi-codegen/runtime"
+	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Lists outbox events
+	// (GET /admin/outbox)
+	ListOutboxEvents(c *fiber.Ctx, params ListOutboxEventsParams) error
+	// Enqueues an outbox event
+	// (POST /admin/outbox)
+	EnqueueOutboxEvent(c *fiber.Ctx) error
+	// Gets an outbox event
+	// (GET /admin/outbox/{id})
+	GetOutboxEvent(c *fiber.Ctx, id uint64) error
+	// Cancels a pending outbox event
+	// (POST /admin/outbox/{id}/cancel)
+	CancelOutboxEvent(c *fiber.Ctx, id uint64) error
+	// Replays a failed outbox event
+	// (POST /admin/outbox/{id}/replay)
+	ReplayOutboxEvent(c *fiber.Ctx, id uint64) error
 	// Enrich some user provided info using AI
 	// (POST /ai/enrich)
 	EnrichContent(c *fiber.Ctx) error
@@ -51,10 +68,13 @@ type ServerInterface interface {
 	// Gets a payment checkout session info
 	// (GET /payments/{id})
 	GetPaymentStatus(c *fiber.Ctx, id string) error
+	// Lists sites of the user
+	// (GET /sites)
+	ListSites(c *fiber.Ctx, params ListSitesParams) error
 	// Create a new site
 	// (POST /sites)
 	CreateSite(c *fiber.Ctx) error
-	// Delete a provisioned site
+	// Delete a site
 	// (DELETE /sites/{id})
 	DeleteSite(c *fiber.Ctx, id uint64) error
 	// Get info about an existing site
@@ -63,6 +83,36 @@ type ServerInterface interface {
 	// Update an existing site
 	// (PATCH /sites/{id})
 	UpdateSite(c *fiber.Ctx, id uint64) error
+	// Get status of site's build
+	// (GET /sites/{id}/builds/{jobId})
+	GetSiteBuild(c *fiber.Ctx, id uint64, jobId openapi_types.UUID) error
+	// Cancel site's build
+	// (POST /sites/{id}/builds/{jobId}/cancel)
+	CancelSiteBuild(c *fiber.Ctx, id uint64, jobId openapi_types.UUID) error
+	// Get output of site's build
+	// (GET /sites/{id}/builds/{jobId}/log)
+	GetSiteBuildLog(c *fiber.Ctx, id uint64, jobId openapi_types.UUID) error
+	// Get history of site's status and provision changes
+	// (GET /sites/{id}/history)
+	GetSiteHistory(c *fiber.Ctx, id uint64) error
+	// List active previews of a site
+	// (GET /sites/{id}/previews)
+	ListSitePreviews(c *fiber.Ctx, id uint64) error
+	// Preview site's draft
+	// (POST /sites/{id}/previews)
+	PreviewSite(c *fiber.Ctx, id uint64) error
+	// Reactivate a deactivated site
+	// (POST /sites/{id}/reactivate)
+	ReactivateSite(c *fiber.Ctx, id uint64) error
+	// List published versions of a site
+	// (GET /sites/{id}/versions)
+	ListSiteVersions(c *fiber.Ctx, id uint64) error
+	// Publish site's draft
+	// (POST /sites/{id}/versions)
+	PublishSite(c *fiber.Ctx, id uint64) error
+	// Roll a site back to an earlier version
+	// (POST /sites/{id}/versions/{version}/rollback)
+	RollbackSite(c *fiber.Ctx, id uint64, version int) error
 	// Rebuild templates
 	// (PATCH /template)
 	RebuildTemplates(c *fiber.Ctx) error
@@ -87,6 +137,119 @@ type ServerInterfaceWrapper struct {
 
 type MiddlewareFunc fiber.Handler
 
+// ListOutboxEvents operation middleware
+func (siw *ServerInterfaceWrapper) ListOutboxEvents(c *fiber.Ctx) error {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListOutboxEventsParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "event" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "event", query, &params.Event)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter event: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", query, &params.Status)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter status: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "siteID" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "siteID", query, &params.SiteID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter siteID: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "correlationID" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "correlationID", query, &params.CorrelationID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter correlationID: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "page" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "page", query, &params.Page)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter page: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "size" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "size", query, &params.Size)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter size: %w", err).Error())
+	}
+
+	return siw.Handler.ListOutboxEvents(c, params)
+}
+
+// EnqueueOutboxEvent operation middleware
+func (siw *ServerInterfaceWrapper) EnqueueOutboxEvent(c *fiber.Ctx) error {
+
+	return siw.Handler.EnqueueOutboxEvent(c)
+}
+
+// GetOutboxEvent operation middleware
+func (siw *ServerInterfaceWrapper) GetOutboxEvent(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id uint64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Params("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter id: %w", err).Error())
+	}
+
+	return siw.Handler.GetOutboxEvent(c, id)
+}
+
+// CancelOutboxEvent operation middleware
+func (siw *ServerInterfaceWrapper) CancelOutboxEvent(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id uint64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Params("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter id: %w", err).Error())
+	}
+
+	return siw.Handler.CancelOutboxEvent(c, id)
+}
+
+// ReplayOutboxEvent operation middleware
+func (siw *ServerInterfaceWrapper) ReplayOutboxEvent(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id uint64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Params("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter id: %w", err).Error())
+	}
+
+	return siw.Handler.ReplayOutboxEvent(c, id)
+}
+
 // EnrichContent operation middleware
 func (siw *ServerInterfaceWrapper) EnrichContent(c *fiber.Ctx) error {
 
@@ -185,6 +348,44 @@ func (siw *ServerInterfaceWrapper) GetPaymentStatus(c *fiber.Ctx) error {
 	return siw.Handler.GetPaymentStatus(c, id)
 }
 
+// ListSites operation middleware
+func (siw *ServerInterfaceWrapper) ListSites(c *fiber.Ctx) error {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params ListSitesParams
+
+	var query url.Values
+	query, err = url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for query string: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "status" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "status", query, &params.Status)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter status: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "page" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "page", query, &params.Page)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter page: %w", err).Error())
+	}
+
+	// ------------- Optional query parameter "size" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "size", query, &params.Size)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter size: %w", err).Error())
+	}
+
+	return siw.Handler.ListSites(c, params)
+}
+
 // CreateSite operation middleware
 func (siw *ServerInterfaceWrapper) CreateSite(c *fiber.Ctx) error {
 
@@ -239,6 +440,198 @@ func (siw *ServerInterfaceWrapper) UpdateSite(c *fiber.Ctx) error {
 	return siw.Handler.UpdateSite(c, id)
 }
 
+// GetSiteBuild operation middleware
+func (siw *ServerInterfaceWrapper) GetSiteBuild(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id uint64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Params("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter id: %w", err).Error())
+	}
+
+	// ------------- Path parameter "jobId" -------------
+	var jobId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "jobId", c.Params("jobId"), &jobId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter jobId: %w", err).Error())
+	}
+
+	return siw.Handler.GetSiteBuild(c, id, jobId)
+}
+
+// CancelSiteBuild operation middleware
+func (siw *ServerInterfaceWrapper) CancelSiteBuild(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id uint64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Params("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter id: %w", err).Error())
+	}
+
+	// ------------- Path parameter "jobId" -------------
+	var jobId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "jobId", c.Params("jobId"), &jobId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter jobId: %w", err).Error())
+	}
+
+	return siw.Handler.CancelSiteBuild(c, id, jobId)
+}
+
+// GetSiteBuildLog operation middleware
+func (siw *ServerInterfaceWrapper) GetSiteBuildLog(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id uint64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Params("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter id: %w", err).Error())
+	}
+
+	// ------------- Path parameter "jobId" -------------
+	var jobId openapi_types.UUID
+
+	err = runtime.BindStyledParameterWithOptions("simple", "jobId", c.Params("jobId"), &jobId, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter jobId: %w", err).Error())
+	}
+
+	return siw.Handler.GetSiteBuildLog(c, id, jobId)
+}
+
+// GetSiteHistory operation middleware
+func (siw *ServerInterfaceWrapper) GetSiteHistory(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id uint64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Params("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter id: %w", err).Error())
+	}
+
+	return siw.Handler.GetSiteHistory(c, id)
+}
+
+// ListSitePreviews operation middleware
+func (siw *ServerInterfaceWrapper) ListSitePreviews(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id uint64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Params("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter id: %w", err).Error())
+	}
+
+	return siw.Handler.ListSitePreviews(c, id)
+}
+
+// PreviewSite operation middleware
+func (siw *ServerInterfaceWrapper) PreviewSite(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id uint64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Params("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter id: %w", err).Error())
+	}
+
+	return siw.Handler.PreviewSite(c, id)
+}
+
+// ReactivateSite operation middleware
+func (siw *ServerInterfaceWrapper) ReactivateSite(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id uint64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Params("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter id: %w", err).Error())
+	}
+
+	return siw.Handler.ReactivateSite(c, id)
+}
+
+// ListSiteVersions operation middleware
+func (siw *ServerInterfaceWrapper) ListSiteVersions(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id uint64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Params("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter id: %w", err).Error())
+	}
+
+	return siw.Handler.ListSiteVersions(c, id)
+}
+
+// PublishSite operation middleware
+func (siw *ServerInterfaceWrapper) PublishSite(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id uint64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Params("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter id: %w", err).Error())
+	}
+
+	return siw.Handler.PublishSite(c, id)
+}
+
+// RollbackSite operation middleware
+func (siw *ServerInterfaceWrapper) RollbackSite(c *fiber.Ctx) error {
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id uint64
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", c.Params("id"), &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter id: %w", err).Error())
+	}
+
+	// ------------- Path parameter "version" -------------
+	var version int
+
+	err = runtime.BindStyledParameterWithOptions("simple", "version", c.Params("version"), &version, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, fmt.Errorf("Invalid format for parameter version: %w", err).Error())
+	}
+
+	return siw.Handler.RollbackSite(c, id, version)
+}
+
 // RebuildTemplates operation middleware
 func (siw *ServerInterfaceWrapper) RebuildTemplates(c *fiber.Ctx) error {
 
@@ -310,6 +703,16 @@ func RegisterHandlersWithOptions(router fiber.Router, si ServerInterface, option
 		router.Use(fiber.Handler(m))
 	}
 
+	router.Get(options.BaseURL+"/admin/outbox", wrapper.ListOutboxEvents)
+
+	router.Post(options.BaseURL+"/admin/outbox", wrapper.EnqueueOutboxEvent)
+
+	router.Get(options.BaseURL+"/admin/outbox/:id", wrapper.GetOutboxEvent)
+
+	router.Post(options.BaseURL+"/admin/outbox/:id/cancel", wrapper.CancelOutboxEvent)
+
+	router.Post(options.BaseURL+"/admin/outbox/:id/replay", wrapper.ReplayOutboxEvent)
+
 	router.Post(options.BaseURL+"/ai/enrich", wrapper.EnrichContent)
 
 	router.Post(options.BaseURL+"/auth/confirmation", wrapper.CreateConfirmation)
@@ -336,6 +739,8 @@ func RegisterHandlersWithOptions(router fiber.Router, si ServerInterface, option
 
 	router.Get(options.BaseURL+"/payments/:id", wrapper.GetPaymentStatus)
 
+	router.Get(options.BaseURL+"/sites", wrapper.ListSites)
+
 	router.Post(options.BaseURL+"/sites", wrapper.CreateSite)
 
 	router.Delete(options.BaseURL+"/sites/:id", wrapper.DeleteSite)
@@ -344,6 +749,26 @@ func RegisterHandlersWithOptions(router fiber.Router, si ServerInterface, option
 
 	router.Patch(options.BaseURL+"/sites/:id", wrapper.UpdateSite)
 
+	router.Get(options.BaseURL+"/sites/:id/builds/:jobId", wrapper.GetSiteBuild)
+
+	router.Post(options.BaseURL+"/sites/:id/builds/:jobId/cancel", wrapper.CancelSiteBuild)
+
+	router.Get(options.BaseURL+"/sites/:id/builds/:jobId/log", wrapper.GetSiteBuildLog)
+
+	router.Get(options.BaseURL+"/sites/:id/history", wrapper.GetSiteHistory)
+
+	router.Get(options.BaseURL+"/sites/:id/previews", wrapper.ListSitePreviews)
+
+	router.Post(options.BaseURL+"/sites/:id/previews", wrapper.PreviewSite)
+
+	router.Post(options.BaseURL+"/sites/:id/reactivate", wrapper.ReactivateSite)
+
+	router.Get(options.BaseURL+"/sites/:id/versions", wrapper.ListSiteVersions)
+
+	router.Post(options.BaseURL+"/sites/:id/versions", wrapper.PublishSite)
+
+	router.Post(options.BaseURL+"/sites/:id/versions/:version/rollback", wrapper.RollbackSite)
+
 	router.Patch(options.BaseURL+"/template", wrapper.RebuildTemplates)
 
 	router.Post(options.BaseURL+"/template", wrapper.CreateTemplate)
@@ -0,0 +1,21 @@
+package rest
+
+import (
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/correlation"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Correlation assigns a correlation ID to every request, the one provided by client in header is reused.
+// ID is returned in response header and passed with request's context to logs and outbox events
+func Correlation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(correlation.Header)
+		if !correlation.Valid(id) {
+			id = correlation.NewID()
+		}
+		c.Set(correlation.Header, id)
+		c.SetUserContext(correlation.WithID(c.UserContext(), id))
+
+		return c.Next()
+	}
+}
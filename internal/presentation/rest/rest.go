@@ -1,12 +1,15 @@
 package rest
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/Builder-Lawyers/builder-backend/internal/application"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/dto"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/auth"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -26,7 +29,7 @@ func NewServer(queries *application.Queries, commands *application.Commands) *Se
 func (s *Server) CreateSite(c *fiber.Ctx) error {
 	var req dto.CreateSiteRequest
 	var err error
-	defer logError(&err, "CreateSite")
+	defer logError(c.UserContext(), &err, "CreateSite")
 	if err = c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
 	}
@@ -37,7 +40,7 @@ func (s *Server) CreateSite(c *fiber.Ctx) error {
 	}
 	siteID, err := s.commands.CreateSite.Execute(c.UserContext(), &req, identity)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
 	}
 
 	resp := dto.CreateSiteResponse{
@@ -50,7 +53,7 @@ func (s *Server) CreateSite(c *fiber.Ctx) error {
 func (s *Server) UpdateSite(c *fiber.Ctx, id uint64) error {
 	var req dto.UpdateSiteRequest
 	var err error
-	defer logError(&err, "UpdateSite")
+	defer logError(c.UserContext(), &err, "UpdateSite")
 	if err = c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
 	}
@@ -61,7 +64,7 @@ func (s *Server) UpdateSite(c *fiber.Ctx, id uint64) error {
 	}
 	updatedSiteID, err := s.commands.UpdateSite.Execute(c.UserContext(), id, &req, identity)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
 	}
 
 	resp := dto.UpdateSiteResponse{
@@ -73,7 +76,7 @@ func (s *Server) UpdateSite(c *fiber.Ctx, id uint64) error {
 
 func (s *Server) DeleteSite(c *fiber.Ctx, id uint64) error {
 	var err error
-	defer logError(&err, "DeleteSite")
+	defer logError(c.UserContext(), &err, "DeleteSite")
 	identity, err := s.getIdentity(c)
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
@@ -81,15 +84,160 @@ func (s *Server) DeleteSite(c *fiber.Ctx, id uint64) error {
 
 	err = s.commands.DeleteSite.Execute(c.UserContext(), id, identity)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+func (s *Server) ReactivateSite(c *fiber.Ctx, id uint64) error {
+	var err error
+	defer logError(c.UserContext(), &err, "ReactivateSite")
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	err = s.commands.ReactivateSite.Execute(c.UserContext(), id, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+func (s *Server) ListSiteVersions(c *fiber.Ctx, id uint64) error {
+	var err error
+	defer logError(c.UserContext(), &err, "ListSiteVersions")
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	resp, err := s.queries.ListVersions.Query(c.UserContext(), id, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *Server) PublishSite(c *fiber.Ctx, id uint64) error {
+	var err error
+	defer logError(c.UserContext(), &err, "PublishSite")
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	resp, err := s.commands.ManageVersions.Publish(c.UserContext(), id, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(resp)
+}
+
+func (s *Server) RollbackSite(c *fiber.Ctx, id uint64, version int) error {
+	var err error
+	defer logError(c.UserContext(), &err, "RollbackSite")
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	err = s.commands.ManageVersions.Rollback(c.UserContext(), id, version, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (s *Server) ListSitePreviews(c *fiber.Ctx, id uint64) error {
+	var err error
+	defer logError(c.UserContext(), &err, "ListSitePreviews")
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	resp, err := s.queries.ListPreviews.Query(c.UserContext(), id, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *Server) PreviewSite(c *fiber.Ctx, id uint64) error {
+	var err error
+	defer logError(c.UserContext(), &err, "PreviewSite")
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	resp, err := s.commands.PreviewSite.Execute(c.UserContext(), id, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(resp)
+}
+
+func (s *Server) GetSiteBuild(c *fiber.Ctx, id uint64, jobId uuid.UUID) error {
+	var err error
+	defer logError(c.UserContext(), &err, "GetSiteBuild")
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	resp, err := s.queries.GetBuild.Query(c.UserContext(), id, jobId, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *Server) GetSiteBuildLog(c *fiber.Ctx, id uint64, jobId uuid.UUID) error {
+	var err error
+	defer logError(c.UserContext(), &err, "GetSiteBuildLog")
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	log, err := s.queries.GetBuild.QueryLog(c.UserContext(), id, jobId, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	return c.Status(fiber.StatusOK).SendString(log)
+}
+
+func (s *Server) CancelSiteBuild(c *fiber.Ctx, id uint64, jobId uuid.UUID) error {
+	var err error
+	defer logError(c.UserContext(), &err, "CancelSiteBuild")
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	err = s.commands.CancelBuild.Execute(c.UserContext(), id, jobId, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
 func (s *Server) CreateTemplate(c *fiber.Ctx) error {
 	var err error
-	defer logError(&err, "CreateTemplate")
+	defer logError(c.UserContext(), &err, "CreateTemplate")
 	var req dto.CreateTemplateRequest
 	if err = c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
@@ -109,7 +257,7 @@ func (s *Server) CreateTemplate(c *fiber.Ctx) error {
 
 func (s *Server) RebuildTemplates(c *fiber.Ctx) error {
 	var err error
-	defer logError(&err, "RebuildTemplates")
+	defer logError(c.UserContext(), &err, "RebuildTemplates")
 	var req dto.RebuildTemplatesRequest
 	if err = c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
@@ -125,7 +273,7 @@ func (s *Server) RebuildTemplates(c *fiber.Ctx) error {
 
 func (s *Server) UpdateTemplate(c *fiber.Ctx, id int) error {
 	var err error
-	defer logError(&err, "UpdateTemplates")
+	defer logError(c.UserContext(), &err, "UpdateTemplates")
 	var req dto.UpdateTemplateRequest
 	if err = c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
@@ -142,7 +290,7 @@ func (s *Server) UpdateTemplate(c *fiber.Ctx, id int) error {
 func (s *Server) EnrichContent(c *fiber.Ctx) error {
 	var req dto.EnrichContentRequest
 	var err error
-	defer logError(&err, "EnrichContent")
+	defer logError(c.UserContext(), &err, "EnrichContent")
 	if err = c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
 	}
@@ -161,7 +309,7 @@ func (s *Server) EnrichContent(c *fiber.Ctx) error {
 
 func (s *Server) FileUpload(c *fiber.Ctx) error {
 	var err error
-	defer logError(&err, "FileUpload")
+	defer logError(c.UserContext(), &err, "FileUpload")
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
@@ -177,7 +325,7 @@ func (s *Server) FileUpload(c *fiber.Ctx) error {
 
 func (s *Server) GetTemplate(c *fiber.Ctx, id uint16) error {
 	var err error
-	defer logError(&err, "GetTemplate")
+	defer logError(c.UserContext(), &err, "GetTemplate")
 	templateInfo, err := s.queries.GetTemplate.Query(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
@@ -188,7 +336,7 @@ func (s *Server) GetTemplate(c *fiber.Ctx, id uint16) error {
 
 func (s *Server) ListTemplates(c *fiber.Ctx) error {
 	var err error
-	defer logError(&err, "ListTemplates")
+	defer logError(c.UserContext(), &err, "ListTemplates")
 	var req dto.ListTemplatePaginator
 	if err = c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
@@ -204,7 +352,7 @@ func (s *Server) ListTemplates(c *fiber.Ctx) error {
 
 func (s *Server) CheckDomain(c *fiber.Ctx, domain string) error {
 	var err error
-	defer logError(&err, "CheckDomain")
+	defer logError(c.UserContext(), &err, "CheckDomain")
 	available, err := s.queries.CheckDomain.Query(c.UserContext(), domain)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
@@ -218,14 +366,47 @@ func (s *Server) CheckDomain(c *fiber.Ctx, domain string) error {
 
 func (s *Server) GetSite(c *fiber.Ctx, id uint64) error {
 	var err error
-	defer logError(&err, "GetSite")
+	defer logError(c.UserContext(), &err, "GetSite")
 	identity, err := s.getIdentity(c)
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
 	}
 	resp, err := s.queries.GetSite.Query(c.UserContext(), id, identity)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// ListSitesParams is generated along with models, but referenced by server without a package
+type ListSitesParams = dto.ListSitesParams
+
+func (s *Server) ListSites(c *fiber.Ctx, params ListSitesParams) error {
+	var err error
+	defer logError(c.UserContext(), &err, "ListSites")
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+	resp, err := s.queries.ListSites.Query(c.UserContext(), &params, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (s *Server) GetSiteHistory(c *fiber.Ctx, id uint64) error {
+	var err error
+	defer logError(c.UserContext(), &err, "GetSiteHistory")
+	identity, err := s.getIdentity(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
+	}
+	resp, err := s.queries.GetSiteHistory.Query(c.UserContext(), id, identity)
+	if err != nil {
+		return c.Status(errorStatus(err)).JSON(dto.ErrorResponse{Error: err.Error()})
 	}
 
 	return c.Status(fiber.StatusOK).JSON(resp)
@@ -233,7 +414,7 @@ func (s *Server) GetSite(c *fiber.Ctx, id uint64) error {
 
 func (s *Server) GetSession(c *fiber.Ctx) error {
 	var err error
-	defer logError(&err, "GetSession")
+	defer logError(c.UserContext(), &err, "GetSession")
 	sessionID, err := s.getSessionID(c)
 	if err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(dto.ErrorResponse{Error: err.Error()})
@@ -250,7 +431,7 @@ func (s *Server) GetSession(c *fiber.Ctx) error {
 func (s *Server) CreateSession(c *fiber.Ctx) error {
 	var req dto.CreateSession
 	var err error
-	defer logError(&err, "CreateSession")
+	defer logError(c.UserContext(), &err, "CreateSession")
 	if err = c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
 	}
@@ -275,7 +456,7 @@ func (s *Server) CreateSession(c *fiber.Ctx) error {
 func (s *Server) CreateConfirmation(c *fiber.Ctx) error {
 	var req dto.CreateConfirmation
 	var err error
-	defer logError(&err, "CreateConfirmation")
+	defer logError(c.UserContext(), &err, "CreateConfirmation")
 	if err = c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
 	}
@@ -291,7 +472,7 @@ func (s *Server) CreateConfirmation(c *fiber.Ctx) error {
 func (s *Server) VerifyUser(c *fiber.Ctx) error {
 	var req dto.VerifyCode
 	var err error
-	defer logError(&err, "VerifyUser")
+	defer logError(c.UserContext(), &err, "VerifyUser")
 	if err = c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
 	}
@@ -316,7 +497,7 @@ func (s *Server) VerifyUser(c *fiber.Ctx) error {
 func (s *Server) VerifyOauthToken(c *fiber.Ctx) error {
 	var req dto.VerifyOauthToken
 	var err error
-	defer logError(&err, "VerifyOauthToken")
+	defer logError(c.UserContext(), &err, "VerifyOauthToken")
 	if err = c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
 	}
@@ -341,7 +522,7 @@ func (s *Server) VerifyOauthToken(c *fiber.Ctx) error {
 func (s *Server) DeleteUser(c *fiber.Ctx) error {
 	var req dto.DeleteUserRequest
 	var err error
-	defer logError(&err, "DeleteUser")
+	defer logError(c.UserContext(), &err, "DeleteUser")
 	if err = c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
 	}
@@ -356,7 +537,7 @@ func (s *Server) DeleteUser(c *fiber.Ctx) error {
 
 func (s *Server) ListPaymentPlans(c *fiber.Ctx) error {
 	var err error
-	defer logError(&err, "ListPaymentPlans")
+	defer logError(c.UserContext(), &err, "ListPaymentPlans")
 	resp, err := s.commands.Payment.ListPaymentPlans(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
@@ -367,7 +548,7 @@ func (s *Server) ListPaymentPlans(c *fiber.Ctx) error {
 
 func (s *Server) CreatePayment(c *fiber.Ctx) error {
 	var err error
-	defer logError(&err, "CreatePayment")
+	defer logError(c.UserContext(), &err, "CreatePayment")
 	var req dto.CreatePaymentRequest
 	if err = c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(dto.ErrorResponse{Error: err.Error()})
@@ -391,7 +572,7 @@ func (s *Server) CreatePayment(c *fiber.Ctx) error {
 
 func (s *Server) GetPaymentStatus(c *fiber.Ctx, id string) error {
 	var err error
-	defer logError(&err, "GetPaymentStatus")
+	defer logError(c.UserContext(), &err, "GetPaymentStatus")
 	paymentInfo, err := s.commands.Payment.GetPaymentInfo(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(dto.ErrorResponse{Error: err.Error()})
@@ -402,7 +583,7 @@ func (s *Server) GetPaymentStatus(c *fiber.Ctx, id string) error {
 
 func (s *Server) HandleEvent(c *fiber.Ctx) error {
 	var err error
-	defer logError(&err, "HandleEvent")
+	defer logError(c.UserContext(), &err, "HandleEvent")
 	signatureHeader := c.Get("Stripe-Signature")
 
 	err = s.commands.Payment.Webhook(c.UserContext(), c.Body(), signatureHeader)
@@ -430,9 +611,31 @@ func (s *Server) getSessionID(c *fiber.Ctx) (uuid.UUID, error) {
 	return s.commands.Auth.ParseCookie(c.UserContext(), c.Cookies("ID", ""))
 }
 
-func logError(err *error, endpoint string) {
+// errorStatus maps application errors to response status, unknown errors are internal
+func errorStatus(err error) int {
+	var (
+		permissionsErr errs.PermissionsError
+		notFoundErr    errs.NotFoundError
+		stateErr       errs.InvalidStateError
+		validationErr  errs.ValidationError
+	)
+	switch {
+	case errors.As(err, &permissionsErr):
+		return fiber.StatusForbidden
+	case errors.As(err, &notFoundErr):
+		return fiber.StatusNotFound
+	case errors.As(err, &stateErr):
+		return fiber.StatusConflict
+	case errors.As(err, &validationErr):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+func logError(ctx context.Context, err *error, endpoint string) {
 	if *err != nil {
-		slog.Error("server error", "endpoint", endpoint, "err", *err)
+		slog.ErrorContext(ctx, "server error", "endpoint", endpoint, "err", *err)
 	}
 }
 
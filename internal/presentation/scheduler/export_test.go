@@ -0,0 +1,8 @@
+package scheduler
+
+import "context"
+
+// ReapExpiredLeases runs the reaper without waiting for the interval
+func (o *OutboxPoller) ReapExpiredLeases(ctx context.Context) {
+	o.reapExpiredLeases(ctx)
+}
@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+)
+
+var (
+	errLeaseLost    = errors.New("lease on event was lost, it is processed by another worker")
+	errLeaseExpired = errors.New("lease expired before event was processed")
+)
+
+// keepLease prolongs worker's claim on an event while it is being handled,
+// so that long-running handlers (site builds) aren't reaped
+func (o *OutboxPoller) keepLease(ctx context.Context, eventID uint64) {
+	t := time.NewTicker(o.cfg.lease / 3)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			res, err := o.uowFactory.Pool.Exec(ctx,
+				"UPDATE builder.outbox SET locked_until = now() + make_interval(secs => $1) WHERE id = $2 AND locked_by = $3 AND status = $4",
+				o.cfg.lease.Seconds(), eventID, o.cfg.workerID, consts.Processing)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.ErrorContext(ctx, "err extending lease", "id", eventID, "err", err)
+				}
+				continue
+			}
+			if res.RowsAffected() == 0 {
+				slog.WarnContext(ctx, "lease on event is lost", "id", eventID)
+				return
+			}
+		}
+	}
+}
+
+// reapExpiredLeases returns events, claimed by crashed or stuck workers, back to the queue.
+// Expired lease counts as a failed attempt, so an event crashing a worker is eventually dead-lettered
+func (o *OutboxPoller) reapExpiredLeases(ctx context.Context) {
+	uow := o.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error in reaper", "err", err)
+		return
+	}
+
+	rows, err := tx.Query(ctx, `UPDATE builder.outbox
+		SET status = $1, attempts = attempts + 1, last_error = $2, next_attempt_at = now(), locked_by = NULL, locked_until = NULL
+		WHERE id IN (
+			SELECT id FROM builder.outbox
+			WHERE status = $3 AND locked_until < now()
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event, payload, attempts, created_at`, consts.NotProcessed, errLeaseExpired.Error(), consts.Processing)
+	if err != nil {
+		_ = uow.Rollback()
+		slog.ErrorContext(ctx, "error reaping expired leases", "err", err)
+		return
+	}
+
+	var reaped int
+	var exhausted []uint64
+	var compensated []events.Envelope
+	for rows.Next() {
+		var envelope events.Envelope
+		if err = rows.Scan(&envelope.ID, &envelope.Event, &envelope.Payload, &envelope.Attempts, &envelope.CreatedAt); err != nil {
+			rows.Close()
+			_ = uow.Rollback()
+			slog.ErrorContext(ctx, "error reaping expired leases", "err", err)
+			return
+		}
+		reaped++
+		slog.WarnContext(ctx, "lease on event expired, returning it to queue", "id", envelope.ID, "event", envelope.Event,
+			"attempts", envelope.Attempts)
+		if o.retryPolicy(envelope.Event).Exhausted(envelope.Attempts) {
+			exhausted = append(exhausted, envelope.ID)
+			compensated = append(compensated, envelope)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		_ = uow.Rollback()
+		slog.ErrorContext(ctx, "error reaping expired leases", "err", err)
+		return
+	}
+
+	if len(exhausted) > 0 {
+		_, err = tx.Exec(ctx, "UPDATE builder.outbox SET status = $1 WHERE id = ANY($2)", consts.DeadLettered, exhausted)
+		if err != nil {
+			_ = uow.Rollback()
+			slog.ErrorContext(ctx, "error dead-lettering reaped events", "err", err)
+			return
+		}
+		slog.ErrorContext(ctx, "events are dead-lettered after expired leases", "ids", exhausted)
+		for _, envelope := range compensated {
+			o.compensate(ctx, tx, envelope, errLeaseExpired)
+		}
+	}
+
+	if err = uow.Commit(); err != nil {
+		slog.ErrorContext(ctx, "error committing reaped events", "err", err)
+		return
+	}
+
+	if reaped > 0 {
+		slog.InfoContext(ctx, "reaped expired leases", "count", reaped)
+	}
+}
@@ -0,0 +1,232 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	"github.com/Builder-Lawyers/builder-backend/internal/testinfra"
+	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+	"github.com/stretchr/testify/require"
+)
+
+// expireLease - event looks claimed by a worker, that crashed while handling it
+func expireLease(t *testing.T, id uint64) {
+	t.Helper()
+	_, err := testinfra.Pool.Exec(context.Background(), `UPDATE builder.outbox
+		SET status = $1, locked_by = 'crashed-worker', locked_until = now() - interval '1 second' WHERE id = $2`,
+		consts.Processing, id)
+	require.NoError(t, err)
+}
+
+// stealLease - lease of the event was taken over by another worker, while it was handled
+func stealLease(ctx context.Context, id uint64) error {
+	_, err := testinfra.Pool.Exec(ctx, "UPDATE builder.outbox SET locked_by = 'thief', locked_until = now() + interval '1 hour' WHERE id = $1", id)
+	return err
+}
+
+func Test_ProcessDue_When_Two_Pollers_Run_Concurrently_Then_They_Claim_Disjoint_Batches(t *testing.T) {
+	resetOutbox(t)
+	t.Setenv("SCHEDULER_LIMIT", "5")
+	handler := newProbeHandler()
+	handler.handle = func(ctx context.Context, event probe) (shared.UoW, error) {
+		// batches overlap in time, so both pollers claim while the other one holds events
+		time.Sleep(100 * time.Millisecond)
+		return nil, nil
+	}
+	first := newPoller(t, handler, testPolicy)
+	second := newPoller(t, handler, testPolicy)
+	var ids []uint64
+	for n := 0; n < 20; n++ {
+		ids = append(ids, insertProbe(t, n))
+	}
+
+	var wg sync.WaitGroup
+	var handledByFirst, handledBySecond int
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		handledByFirst = first.ProcessDue(context.Background())
+	}()
+	go func() {
+		defer wg.Done()
+		handledBySecond = second.ProcessDue(context.Background())
+	}()
+	wg.Wait()
+
+	require.Equal(t, len(ids), handledByFirst+handledBySecond)
+	for n, id := range ids {
+		require.Equal(t, 1, handler.calls(n), "event %v", n)
+		event := getOutbox(t, id)
+		require.Equal(t, int(consts.Processed), event.Status)
+		require.Equal(t, 1, event.Attempts)
+	}
+}
+
+func Test_ReapExpiredLeases_When_Lease_Expired_Then_Event_Is_Returned_And_Claimed_Again(t *testing.T) {
+	resetOutbox(t)
+	handler := newProbeHandler()
+	SUT := newPoller(t, handler, testPolicy)
+	id := insertProbe(t, 1)
+	expireLease(t, id)
+	// claimed by the crashed worker, so it isn't due
+	require.Equal(t, 0, SUT.ProcessDue(context.Background()))
+
+	SUT.ReapExpiredLeases(context.Background())
+
+	event := getOutbox(t, id)
+	require.Equal(t, int(consts.NotProcessed), event.Status)
+	require.Equal(t, 1, event.Attempts)
+	require.Equal(t, "lease expired before event was processed", event.LastError.String)
+	require.False(t, event.LockedBy.Valid)
+	require.Equal(t, 1, SUT.ProcessDue(context.Background()))
+	event = getOutbox(t, id)
+	require.Equal(t, int(consts.Processed), event.Status)
+	require.Equal(t, 2, event.Attempts)
+}
+
+func Test_ReapExpiredLeases_When_Attempts_Are_Exhausted_Then_Dead_Letter(t *testing.T) {
+	resetOutbox(t)
+	handler := newProbeHandler()
+	policy := testPolicy
+	policy.MaxAttempts = 1
+	SUT := newPoller(t, handler, policy)
+	id := insertProbe(t, 1)
+	expireLease(t, id)
+
+	SUT.ReapExpiredLeases(context.Background())
+
+	require.Equal(t, int(consts.DeadLettered), getOutbox(t, id).Status)
+	require.Equal(t, 0, SUT.ProcessDue(context.Background()))
+	require.Equal(t, 0, handler.calls(1))
+}
+
+func Test_ReapExpiredLeases_When_Event_Is_Dead_Lettered_Then_It_Is_Compensated(t *testing.T) {
+	resetOutbox(t)
+	handler := newProbeHandler()
+	handler.compensate = compensateWithProbe
+	policy := testPolicy
+	policy.MaxAttempts = 1
+	SUT := newPoller(t, handler, policy)
+	id := insertProbe(t, 1)
+	expireLease(t, id)
+
+	SUT.ReapExpiredLeases(context.Background())
+
+	require.Equal(t, int(consts.DeadLettered), getOutbox(t, id).Status)
+	require.Equal(t, 1, countProbes(t, 101))
+}
+
+func Test_ProcessDue_When_Handler_Outlives_Lease_Then_Lease_Is_Extended(t *testing.T) {
+	resetOutbox(t)
+	t.Setenv("SCHEDULER_LEASE", "1")
+	handler := newProbeHandler()
+	handler.handle = func(ctx context.Context, event probe) (shared.UoW, error) {
+		time.Sleep(2500 * time.Millisecond)
+		return nil, nil
+	}
+	SUT := newPoller(t, handler, testPolicy)
+	reaper := newPoller(t, newProbeHandler(), testPolicy)
+	id := insertProbe(t, 1)
+
+	done := make(chan int)
+	go func() {
+		done <- SUT.ProcessDue(context.Background())
+	}()
+	time.Sleep(1500 * time.Millisecond)
+	reaper.ReapExpiredLeases(context.Background())
+
+	require.Equal(t, 1, <-done)
+	event := getOutbox(t, id)
+	require.Equal(t, int(consts.Processed), event.Status)
+	require.Equal(t, 1, event.Attempts)
+	require.Equal(t, 1, handler.calls(1))
+}
+
+func Test_ProcessDue_When_Lease_Is_Stolen_Then_Handler_Result_Is_Discarded(t *testing.T) {
+	resetOutbox(t)
+	uowFactory := dbs.NewUoWFactory(testinfra.Pool)
+	var id uint64
+	handler := newProbeHandler()
+	handler.handle = func(ctx context.Context, event probe) (shared.UoW, error) {
+		if err := stealLease(ctx, id); err != nil {
+			return nil, err
+		}
+		uow := uowFactory.GetUoW()
+		tx, err := uow.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return uow, repo.NewEventRepo(tx).InsertEvent(ctx, probe{N: 2})
+	}
+	SUT := newPoller(t, handler, testPolicy)
+	id = insertProbe(t, 1)
+
+	require.Equal(t, 1, SUT.ProcessDue(context.Background()))
+
+	event := getOutbox(t, id)
+	require.Equal(t, int(consts.Processing), event.Status)
+	require.Equal(t, "thief", event.LockedBy.String)
+	require.Equal(t, 0, event.Attempts)
+	var count int
+	require.NoError(t, testinfra.Pool.QueryRow(context.Background(), "SELECT count(*) FROM builder.outbox").Scan(&count))
+	require.Equal(t, 1, count, "event inserted by the handler is rolled back")
+}
+
+func Test_ProcessDue_When_Lease_Is_Stolen_From_Failed_Handler_Then_Retry_Isnt_Scheduled(t *testing.T) {
+	resetOutbox(t)
+	var id uint64
+	handler := newProbeHandler()
+	handler.handle = func(ctx context.Context, event probe) (shared.UoW, error) {
+		if err := stealLease(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("build failed")
+	}
+	SUT := newPoller(t, handler, testPolicy)
+	id = insertProbe(t, 1)
+
+	require.Equal(t, 1, SUT.ProcessDue(context.Background()))
+
+	event := getOutbox(t, id)
+	require.Equal(t, int(consts.Processing), event.Status)
+	require.Equal(t, "thief", event.LockedBy.String)
+	require.Equal(t, 0, event.Attempts)
+	require.False(t, event.LastError.Valid)
+}
+
+func Test_Stop_When_Deadline_Is_Exceeded_Then_Claims_Are_Released_Without_Counting_Attempt(t *testing.T) {
+	resetOutbox(t)
+	t.Setenv("SCHEDULER_INTERVAL", "1")
+	started := make(chan struct{}, 1)
+	handler := newProbeHandler()
+	handler.handle = func(ctx context.Context, event probe) (shared.UoW, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	SUT := newPoller(t, handler, testPolicy)
+	id := insertProbe(t, 1)
+	go SUT.Start()
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "event wasn't claimed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := SUT.Stop(ctx)
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	event := getOutbox(t, id)
+	require.Equal(t, int(consts.NotProcessed), event.Status)
+	require.Equal(t, 0, event.Attempts)
+	require.False(t, event.LockedBy.Valid)
+	require.False(t, event.LockedUntil.Valid)
+}
@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	"github.com/jackc/pgx/v5"
+)
+
+const listenReconnectDelay = 5 * time.Second
+
+// listen holds a dedicated connection subscribed to outbox notifications and wakes the poller on each of them.
+// Connection is re-established on failure, polling by interval keeps working meanwhile
+func (o *OutboxPoller) listen(ctx context.Context) {
+	for {
+		err := o.waitForNotifications(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Error("outbox listener disconnected, reconnecting", "err", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenReconnectDelay):
+		}
+	}
+}
+
+func (o *OutboxPoller) waitForNotifications(ctx context.Context) error {
+	// LISTEN is bound to a session, so pooled connections can't be used
+	conn, err := pgx.ConnectConfig(ctx, o.uowFactory.Pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "LISTEN "+repo.OutboxChannel); err != nil {
+		return err
+	}
+	slog.Info("Listening for outbox notifications", "channel", repo.OutboxChannel)
+	// events inserted while listener was disconnected
+	o.wakeUp()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		slog.Debug("outbox notification", "event", notification.Payload)
+		o.wakeUp()
+	}
+}
+
+// wakeUp requests an immediate poll, notifications received while poll is pending are coalesced
+func (o *OutboxPoller) wakeUp() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
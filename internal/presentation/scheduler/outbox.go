@@ -3,32 +3,46 @@ package scheduler
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"os"
 	"strconv"
 	"sync"
 	"time"
 
-	"github.com/Builder-Lawyers/builder-backend/internal/application"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
 	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/correlation"
 	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
 	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
 	"github.com/Builder-Lawyers/builder-backend/pkg/env"
 	"github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
 type OutboxPoller struct {
-	processors *application.Processors
+	registry   *events.Registry
 	uowFactory *dbs.UOWFactory
 	cfg        *OutboxConfig
-	stop       chan struct{}
+	// ctx is passed to handlers, it's cancelled only if shutdown deadline is exceeded
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stop     chan struct{}
+	wake     chan struct{}
+	mu       sync.Mutex
+	stopped  bool
+	inFlight sync.WaitGroup
 }
 
 type OutboxConfig struct {
 	limit    uint8
 	interval uint16
+	workerID string
+	lease    time.Duration
+	// maxAttempts - overrides attempts of events on the default retry policy, 0 keeps the policy
+	maxAttempts int
 }
 
 func NewOutboxConfig() *OutboxConfig {
@@ -41,107 +55,137 @@ func NewOutboxConfig() *OutboxConfig {
 		limit = 5
 	}
 
-	intervalString := env.GetEnv("SCHEDULER_INTERVAL", "5")
+	// events are picked up on notifications, interval is only a fallback
+	intervalString := env.GetEnv("SCHEDULER_INTERVAL", "30")
 	interval, err = strconv.Atoi(intervalString)
 	if err != nil {
-		interval = 5
+		interval = 30
 	}
+
+	leaseString := env.GetEnv("SCHEDULER_LEASE", "600")
+	lease, err := strconv.Atoi(leaseString)
+	if err != nil || lease <= 0 {
+		lease = 600
+	}
+
+	// handlers with their own retry policy keep it, the rest use the default one
+	maxAttempts, err := strconv.Atoi(env.GetEnv("SCHEDULER_MAX_ATTEMPTS", "0"))
+	if err != nil || maxAttempts < 0 {
+		maxAttempts = 0
+	}
+
 	return &OutboxConfig{
-		limit:    uint8(limit),
-		interval: uint16(interval),
+		limit:       uint8(limit),
+		interval:    uint16(interval),
+		workerID:    env.GetEnv("SCHEDULER_WORKER_ID", defaultWorkerID()),
+		lease:       time.Duration(lease) * time.Second,
+		maxAttempts: maxAttempts,
 	}
 }
 
-func NewOutboxPoller(processors *application.Processors, uowFactory *dbs.UOWFactory, cfg *OutboxConfig) *OutboxPoller {
-	return &OutboxPoller{processors: processors, uowFactory: uowFactory, cfg: cfg, stop: make(chan struct{})}
+// defaultWorkerID identifies a replica holding a claim on events, unique per process
+func defaultWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()[:8])
+}
+
+func NewOutboxPoller(registry *events.Registry, uowFactory *dbs.UOWFactory, cfg *OutboxConfig) *OutboxPoller {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &OutboxPoller{
+		registry:   registry,
+		uowFactory: uowFactory,
+		cfg:        cfg,
+		ctx:        ctx,
+		cancel:     cancel,
+		stop:       make(chan struct{}),
+		wake:       make(chan struct{}, 1),
+	}
 }
 
 func (o *OutboxPoller) Start() {
 	slog.Info("Starting outbox poller...")
 	t := time.NewTimer(time.Duration(o.cfg.interval) * time.Second)
-	ctx, cancel := context.WithCancel(context.Background())
+	defer t.Stop()
+	ctx := o.ctx
+	go o.listen(ctx)
 	for {
 		select {
 		case <-t.C:
-			o.pollTable(ctx)
-			t = time.NewTimer(time.Duration(o.cfg.interval) * time.Second)
+			o.reapExpiredLeases(ctx)
+			o.drain(ctx)
+			t.Reset(time.Duration(o.cfg.interval) * time.Second)
+		case <-o.wake:
+			o.drain(ctx)
+			t.Reset(time.Duration(o.cfg.interval) * time.Second)
 		case <-o.stop:
-			slog.Info("Cancelling current execution")
-			cancel()
+			slog.Info("Outbox poller stopped claiming events")
+			return
 		}
-		// wait after poll finishes
 	}
 }
 
 func (o *OutboxPoller) StartParallel() {
 	ticker := time.NewTicker(time.Duration(o.cfg.interval) * time.Second)
-	ctx, cancel := context.WithCancel(context.Background())
 	defer ticker.Stop()
+	ctx := o.ctx
 
 	slog.Info("Starting outbox poller...")
+	go o.listen(ctx)
 	for {
 		select {
 		case <-ticker.C:
+			o.reapExpiredLeases(ctx)
 			go o.pollTable(ctx)
+		case <-o.wake:
+			go o.drain(ctx)
 		case <-o.stop:
-			slog.Info("Cancelling current execution")
-			cancel()
+			slog.Info("Outbox poller stopped claiming events")
+			return
 		}
 	}
 }
 
-func (o *OutboxPoller) pollTable(ctx context.Context) {
-	uow := o.uowFactory.GetUoW()
-	tx, err := uow.Begin()
-	if err != nil {
-		slog.Error("error in poller", "err", err)
-		return
-	}
-
-	var eventsPolled int
-	countQuery := "SELECT count(*) FROM builder.outbox WHERE status = 0	"
-	err = tx.QueryRow(ctx, countQuery).Scan(&eventsPolled)
-	if err != nil {
-		slog.Error("error counting events", "err", err)
-		return
-	}
-	if eventsPolled == 0 {
-		_ = uow.Rollback()
-		slog.Debug("no events to process")
-		return
-	}
-
-	query := "SELECT * FROM builder.outbox WHERE status = 0 ORDER BY created_at FOR NO KEY UPDATE LIMIT $1"
-	rows, err := tx.Query(ctx, query, o.cfg.limit)
-	if err != nil {
-		slog.Error("error in poller", "err", err)
-		return
+// drain polls until the backlog of due events is smaller than a batch
+func (o *OutboxPoller) drain(ctx context.Context) {
+	for ctx.Err() == nil && !o.isStopped() {
+		if claimed := o.pollTable(ctx); claimed == 0 || claimed < int(o.cfg.limit) {
+			return
+		}
 	}
+}
 
-	defer rows.Close()
-	var eventsToProcess []db.Outbox
-	var eventIDs []int64
-	for rows.Next() {
-		var event db.Outbox
-		if err = rows.Scan(&event.ID, &event.Event, &event.Status, &event.Payload, &event.CreatedAt); err != nil {
-			slog.Error("error in poller", "err", err)
-			continue
+// ProcessDue handles due events batch by batch until none is left and returns how many were handled.
+// Events are handled without waiting for notifications or the interval, so tests and tools can drive the outbox
+func (o *OutboxPoller) ProcessDue(ctx context.Context) int {
+	var handled int
+	for ctx.Err() == nil && !o.isStopped() {
+		claimed := o.pollTable(ctx)
+		if claimed == 0 {
+			break
 		}
-		eventIDs = append(eventIDs, int64(event.ID))
-		eventsToProcess = append(eventsToProcess, event)
+		handled += claimed
 	}
+	return handled
+}
 
-	if err = rows.Err(); err != nil {
-		slog.Error("error reading result sets", "err", err)
+// pollTable processes a batch of due events and returns its size
+func (o *OutboxPoller) pollTable(ctx context.Context) int {
+	if !o.track() {
+		return 0
 	}
+	defer o.inFlight.Done()
 
-	_, err = tx.Exec(ctx, "UPDATE builder.outbox SET status = $1 WHERE id = ANY($2)", consts.Processing, eventIDs)
+	eventsToProcess, err := o.claimEvents(ctx)
 	if err != nil {
-		slog.Error("error setting events status to processing", "err", err)
+		slog.ErrorContext(ctx, "error claiming events", "err", err)
+		return 0
 	}
-
-	if err := uow.Commit(); err != nil {
-		slog.Error("err committing", "err", err)
+	if len(eventsToProcess) == 0 {
+		slog.DebugContext(ctx, "no events to process")
+		return 0
 	}
 
 	var wg sync.WaitGroup
@@ -149,103 +193,247 @@ func (o *OutboxPoller) pollTable(ctx context.Context) {
 		wg.Add(1)
 		go func(ev db.Outbox) {
 			defer wg.Done()
-			if err := o.handleEvent(ctx, ev); err != nil {
-				slog.Error("handler error", "event", ev.ID, "err", err)
+			evCtx := withCorrelation(ctx, ev)
+			leaseCtx, stopLease := context.WithCancel(evCtx)
+			go o.keepLease(leaseCtx, ev.ID)
+			defer stopLease()
+			if err := o.handleEvent(evCtx, ev); err != nil {
+				slog.ErrorContext(evCtx, "handler error", "event", ev.ID, "err", err)
 			}
 		}(event)
 	}
 
 	wg.Wait()
-	slog.Debug("Finished poller thread processing")
+	slog.DebugContext(ctx, "Finished poller thread processing")
+	return len(eventsToProcess)
+}
+
+// withCorrelation passes event's correlation ID to logs and events inserted by its handler.
+// Events stored without one start a new chain
+func withCorrelation(ctx context.Context, outbox db.Outbox) context.Context {
+	if outbox.CorrelationID.Valid && outbox.CorrelationID.String != "" {
+		return correlation.WithID(ctx, outbox.CorrelationID.String)
+	}
+	return correlation.WithID(ctx, correlation.NewID())
+}
+
+// claimEvents atomically moves due events to Processing under this worker's lease,
+// rows locked by other replicas are skipped
+func (o *OutboxPoller) claimEvents(ctx context.Context) ([]db.Outbox, error) {
+	uow := o.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE builder.outbox SET status = $1, locked_by = $2, locked_until = now() + make_interval(secs => $3)
+		WHERE id IN (
+			SELECT id FROM builder.outbox
+			WHERE status IN ($4, $5) AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $6
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event, status, payload, attempts, next_attempt_at, last_error, locked_by, locked_until, correlation_id, created_at`
+	rows, err := tx.Query(ctx, query, consts.Processing, o.cfg.workerID, o.cfg.lease.Seconds(),
+		consts.NotProcessed, consts.InError, o.cfg.limit)
+	if err != nil {
+		_ = uow.Rollback()
+		return nil, err
+	}
+
+	var claimed []db.Outbox
+	for rows.Next() {
+		var event db.Outbox
+		if err = rows.Scan(&event.ID, &event.Event, &event.Status, &event.Payload, &event.Attempts,
+			&event.NextAttemptAt, &event.LastError, &event.LockedBy, &event.LockedUntil, &event.CorrelationID, &event.CreatedAt); err != nil {
+			rows.Close()
+			_ = uow.Rollback()
+			return nil, err
+		}
+		claimed = append(claimed, event)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		_ = uow.Rollback()
+		return nil, fmt.Errorf("error reading result sets, %w", err)
+	}
+
+	if err = uow.Commit(); err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
 }
 
 func (o *OutboxPoller) handleEvent(ctx context.Context, outbox db.Outbox) error {
 	var (
-		uow    interfaces.UoW
-		tx     pgx.Tx
-		err    error
-		status = consts.Processed
+		uow interfaces.UoW
+		tx  pgx.Tx
+		err error
 	)
 
-	slog.Info("Handling event", "event", outbox.Event, "id", outbox.ID)
+	slog.InfoContext(ctx, "Handling event", "event", outbox.Event, "id", outbox.ID, "attempt", outbox.Attempts+1)
 
-	switch outbox.Event {
-	case events.SiteAwaitingProvision{}.GetType():
-		event := db.MapOutboxModelToSiteAwaitingProvisionEvent(outbox)
-		uow, err = o.processors.ProvisionSite.Handle(ctx, event)
-		if err != nil {
-			status = consts.InError
-		}
-		break
-	case events.ProvisionCDN{}.GetType():
-		event := db.MapOutboxModelToProvisionCDN(outbox)
-		uow, err = o.processors.ProvisionCDN.Handle(ctx, event)
-		if err != nil {
-			status = consts.InError
-		}
-		break
-	case events.FinalizeProvision{}.GetType():
-		event := db.MapOutboxModelToFinalizeProvision(outbox)
-		uow, err = o.processors.FinalizeProvision.Handle(ctx, event)
-		if err != nil {
-			var r errs.RetryableError
-			if errors.As(err, &r) {
-				slog.Warn("Distribution still deploying, will retry later")
-				status = consts.NotProcessed
-			} else {
-				status = consts.InError
-			}
-		}
-		break
-	case events.SendMail{}.GetType():
-		event := db.MapOutboxModelToSendMail(outbox)
-		uow, err = o.processors.SendMail.Handle(ctx, event)
-		if err != nil {
-			status = consts.InError
-		}
-		break
-	case events.DeactivateSite{}.GetType():
-		event := db.MapOutboxModelToDeactivateSite(outbox)
-		uow, err = o.processors.DeactivateSite.Handle(ctx, event)
-		if err != nil {
-			status = consts.InError
-		}
-		break
+	registration, ok := o.registry.Lookup(outbox.Event)
+	if !ok {
+		return o.markUnhandled(ctx, outbox)
 	}
 
+	uow, err = registration.Dispatch(ctx, db.MapOutboxModelToEnvelope(outbox))
 	if err != nil {
-		slog.Error("error in handler", "event", outbox.Event, "id", outbox.ID, "err", err)
+		slog.ErrorContext(ctx, "error in handler", "event", outbox.Event, "id", outbox.ID, "err", err)
+		if uow != nil {
+			// changes made by a failed handler must not be committed along with event status
+			_ = uow.Rollback()
+		}
+		return o.scheduleRetry(ctx, outbox, err)
 	}
 
 	if uow == nil {
 		var errTx error
 		// open new transaction if there was none in event handler
 		uow = o.uowFactory.GetUoW()
-		tx, errTx = uow.Begin()
+		tx, errTx = uow.Begin(ctx)
 		if errTx != nil {
-			return errors.Join(err, errTx)
+			return errTx
 		}
 	} else {
 		tx = uow.GetTx()
 	}
 
-	_, err = tx.Exec(ctx, "UPDATE builder.outbox SET status = $1 WHERE id = $2", status, outbox.ID)
+	res, err := tx.Exec(ctx, `UPDATE builder.outbox SET status = $1, attempts = attempts + 1, locked_by = NULL, locked_until = NULL
+		WHERE id = $2 AND locked_by = $3`, consts.Processed, outbox.ID, o.cfg.workerID)
 	if err != nil {
 		errRollback := uow.Rollback()
-		slog.Error("error in poller", "err", err)
+		slog.ErrorContext(ctx, "error in poller", "err", err)
 		return errors.Join(err, errRollback)
 	}
+	if res.RowsAffected() == 0 {
+		// lease expired and event was reclaimed, handler's changes are discarded so that they aren't applied twice
+		errRollback := uow.Rollback()
+		return errors.Join(errLeaseLost, errRollback)
+	}
 
 	if err = uow.Commit(); err != nil {
-		slog.Error("error in poller", "err", err)
+		slog.ErrorContext(ctx, "error in poller", "err", err)
 		return err
 	}
 
-	slog.Info("processed event", "id", outbox.ID)
+	slog.InfoContext(ctx, "processed event", "id", outbox.ID)
 	return nil
 }
 
-func (o *OutboxPoller) Stop() {
-	slog.Info("Stopping poller")
-	o.stop <- struct{}{}
+// scheduleRetry records a failed attempt, event is either rescheduled with a backoff
+// or dead-lettered if event's retry policy is exhausted
+func (o *OutboxPoller) scheduleRetry(ctx context.Context, outbox db.Outbox, handlerErr error) error {
+	policy := o.retryPolicy(outbox.Event)
+	attempts := outbox.Attempts + 1
+	status := consts.InError
+
+	var r errs.RetryableError
+	if errors.As(handlerErr, &r) {
+		// event is waiting for some external resource, it's not a failure
+		status = consts.NotProcessed
+	}
+
+	var p errs.PermanentError
+	var d errs.DeferredError
+	nextAttemptAt := time.Now().Add(policy.NextDelay(attempts))
+	if errors.As(handlerErr, &d) {
+		// event wasn't really attempted, so it's neither counted nor dead-lettered
+		status = consts.NotProcessed
+		attempts = outbox.Attempts
+		delay := d.Delay
+		if delay <= 0 {
+			delay = policy.InitialDelay
+		}
+		nextAttemptAt = time.Now().Add(delay)
+		slog.InfoContext(ctx, "event is deferred", "event", outbox.Event, "id", outbox.ID, "nextAttemptAt", nextAttemptAt)
+	} else if policy.Exhausted(attempts) || errors.As(handlerErr, &p) {
+		status = consts.DeadLettered
+		slog.ErrorContext(ctx, "event retries are exhausted, dead-lettering", "event", outbox.Event, "id", outbox.ID, "attempts", attempts)
+	} else {
+		slog.WarnContext(ctx, "event will be retried", "event", outbox.Event, "id", outbox.ID, "attempts", attempts, "nextAttemptAt", nextAttemptAt)
+	}
+
+	uow := o.uowFactory.GetUoW()
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(ctx, `UPDATE builder.outbox
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, locked_by = NULL, locked_until = NULL
+		WHERE id = $5 AND locked_by = $6`,
+		status, attempts, nextAttemptAt, handlerErr.Error(), outbox.ID, o.cfg.workerID)
+	if err != nil {
+		errRollback := uow.Rollback()
+		slog.ErrorContext(ctx, "error in poller", "err", err)
+		return errors.Join(err, errRollback)
+	}
+	if res.RowsAffected() == 0 {
+		errRollback := uow.Rollback()
+		return errors.Join(errLeaseLost, errRollback)
+	}
+	if status == consts.DeadLettered {
+		o.compensate(ctx, tx, db.MapOutboxModelToEnvelope(outbox), handlerErr)
+	}
+
+	if err = uow.Commit(); err != nil {
+		slog.ErrorContext(ctx, "error in poller", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// compensate runs compensation of a dead-lettered event in a savepoint of tx,
+// a failed compensation is rolled back and logged, event is dead-lettered anyway
+func (o *OutboxPoller) compensate(ctx context.Context, tx pgx.Tx, envelope events.Envelope, cause error) {
+	registration, ok := o.registry.Lookup(envelope.Event)
+	if !ok {
+		return
+	}
+
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error compensating dead-lettered event", "event", envelope.Event, "id", envelope.ID, "err", err)
+		return
+	}
+	if err = registration.Compensate(ctx, savepoint, envelope, cause); err != nil {
+		_ = savepoint.Rollback(ctx)
+		slog.ErrorContext(ctx, "error compensating dead-lettered event", "event", envelope.Event, "id", envelope.ID, "err", err)
+		return
+	}
+	if err = savepoint.Commit(ctx); err != nil {
+		slog.ErrorContext(ctx, "error compensating dead-lettered event", "event", envelope.Event, "id", envelope.ID, "err", err)
+	}
+}
+
+// retryPolicy returns event's policy, SCHEDULER_MAX_ATTEMPTS applies to events on the default policy
+func (o *OutboxPoller) retryPolicy(event string) events.RetryPolicy {
+	policy := o.registry.RetryPolicy(event)
+	if o.cfg.maxAttempts > 0 && policy == events.DefaultRetryPolicy {
+		policy.MaxAttempts = o.cfg.maxAttempts
+	}
+	return policy
+}
+
+// markUnhandled flags an event, which type has no registered handler, it's left for manual inspection
+func (o *OutboxPoller) markUnhandled(ctx context.Context, outbox db.Outbox) error {
+	slog.ErrorContext(ctx, "no handler registered for event", "event", outbox.Event, "id", outbox.ID)
+	res, err := o.uowFactory.Pool.Exec(ctx, `UPDATE builder.outbox
+		SET status = $1, last_error = $2, locked_by = NULL, locked_until = NULL
+		WHERE id = $3 AND locked_by = $4`,
+		consts.Unhandled, fmt.Sprintf("no handler registered for event %v", outbox.Event), outbox.ID, o.cfg.workerID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return errLeaseLost
+	}
+
+	return nil
 }
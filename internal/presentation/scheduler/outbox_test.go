@@ -0,0 +1,319 @@
+package scheduler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/errs"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/events"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	"github.com/Builder-Lawyers/builder-backend/internal/presentation/scheduler"
+	"github.com/Builder-Lawyers/builder-backend/internal/testinfra"
+	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	shared "github.com/Builder-Lawyers/builder-backend/pkg/interfaces"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type probe struct {
+	N int
+}
+
+func (probe) GetType() string {
+	return "Probe"
+}
+
+// probeHandler counts handled events and returns errors queued for them
+type probeHandler struct {
+	mu      sync.Mutex
+	handled map[int]int
+	errs    []error
+	handle  func(ctx context.Context, event probe) (shared.UoW, error)
+	// compensate is registered for dead-lettered probes, when set
+	compensate func(ctx context.Context, tx pgx.Tx, event probe, cause error) error
+}
+
+func newProbeHandler(errs ...error) *probeHandler {
+	return &probeHandler{handled: make(map[int]int), errs: errs}
+}
+
+func (h *probeHandler) Handle(ctx context.Context, event probe) (shared.UoW, error) {
+	h.mu.Lock()
+	h.handled[event.N]++
+	var err error
+	if len(h.errs) > 0 {
+		err, h.errs = h.errs[0], h.errs[1:]
+	}
+	h.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if h.handle != nil {
+		return h.handle(ctx, event)
+	}
+	return nil, nil
+}
+
+func (h *probeHandler) calls(n int) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.handled[n]
+}
+
+// newPoller - poller with a fresh config, so env set by the test is applied
+func newPoller(t *testing.T, handler *probeHandler, policy events.RetryPolicy) *scheduler.OutboxPoller {
+	t.Helper()
+	registry := events.NewRegistry()
+	events.Register(registry, handler.Handle, nil, policy)
+	if handler.compensate != nil {
+		events.OnDeadLetter(registry, handler.compensate)
+	}
+	poller := scheduler.NewOutboxPoller(registry, dbs.NewUoWFactory(testinfra.Pool), scheduler.NewOutboxConfig())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = poller.Stop(ctx)
+	})
+	return poller
+}
+
+// resetOutbox - pollers claim every due event, so events of previous tests are removed
+func resetOutbox(t *testing.T) {
+	t.Helper()
+	_, err := testinfra.Pool.Exec(context.Background(), "DELETE FROM builder.outbox")
+	require.NoError(t, err)
+}
+
+func insertProbe(t *testing.T, n int) uint64 {
+	t.Helper()
+	ctx := context.Background()
+	uow := dbs.NewUoWFactory(testinfra.Pool).GetUoW()
+	tx, err := uow.Begin(ctx)
+	require.NoError(t, err)
+	payload, err := json.Marshal(probe{N: n})
+	require.NoError(t, err)
+	id, err := repo.NewEventRepo(tx).InsertRawEvent(ctx, probe{}.GetType(), payload)
+	if err != nil {
+		_ = uow.Rollback()
+		require.NoError(t, err)
+	}
+	require.NoError(t, uow.Commit())
+	return id
+}
+
+func getOutbox(t *testing.T, id uint64) db.Outbox {
+	t.Helper()
+	var event db.Outbox
+	err := testinfra.Pool.QueryRow(context.Background(), `SELECT id, event, status, attempts, next_attempt_at, last_error,
+		locked_by, locked_until FROM builder.outbox WHERE id = $1`, id).
+		Scan(&event.ID, &event.Event, &event.Status, &event.Attempts, &event.NextAttemptAt, &event.LastError,
+			&event.LockedBy, &event.LockedUntil)
+	require.NoError(t, err)
+	return event
+}
+
+// makeDue skips the backoff of a rescheduled event
+func makeDue(t *testing.T, id uint64) {
+	t.Helper()
+	_, err := testinfra.Pool.Exec(context.Background(), "UPDATE builder.outbox SET next_attempt_at = now() WHERE id = $1", id)
+	require.NoError(t, err)
+}
+
+var testPolicy = events.RetryPolicy{MaxAttempts: 3, InitialDelay: time.Minute, MaxDelay: time.Hour, Multiplier: 2}
+
+func Test_ProcessDue_When_Handler_Fails_Then_Event_Is_Rescheduled_With_Backoff(t *testing.T) {
+	resetOutbox(t)
+	handler := newProbeHandler(errors.New("smtp is down"))
+	SUT := newPoller(t, handler, testPolicy)
+	id := insertProbe(t, 1)
+
+	require.Equal(t, 1, SUT.ProcessDue(context.Background()))
+
+	event := getOutbox(t, id)
+	require.Equal(t, int(consts.InError), event.Status)
+	require.Equal(t, 1, event.Attempts)
+	require.Equal(t, "smtp is down", event.LastError.String)
+	require.WithinDuration(t, time.Now().Add(time.Minute), event.NextAttemptAt, 10*time.Second)
+	require.False(t, event.LockedBy.Valid)
+	require.False(t, event.LockedUntil.Valid)
+	// not due until the backoff passes
+	require.Equal(t, 0, SUT.ProcessDue(context.Background()))
+
+	makeDue(t, id)
+	require.Equal(t, 1, SUT.ProcessDue(context.Background()))
+	event = getOutbox(t, id)
+	require.Equal(t, int(consts.Processed), event.Status)
+	require.Equal(t, 2, event.Attempts)
+	require.Equal(t, 2, handler.calls(1))
+}
+
+func Test_ProcessDue_When_Handler_Waits_For_Resource_Then_Event_Stays_Not_Processed(t *testing.T) {
+	resetOutbox(t)
+	handler := newProbeHandler(errs.RetryableError{Err: errors.New("distribution is deploying")})
+	SUT := newPoller(t, handler, testPolicy)
+	id := insertProbe(t, 1)
+
+	require.Equal(t, 1, SUT.ProcessDue(context.Background()))
+
+	event := getOutbox(t, id)
+	require.Equal(t, int(consts.NotProcessed), event.Status)
+	require.Equal(t, 1, event.Attempts)
+	require.WithinDuration(t, time.Now().Add(time.Minute), event.NextAttemptAt, 10*time.Second)
+}
+
+func Test_ProcessDue_When_Error_Is_Permanent_Then_Dead_Letter_At_Once(t *testing.T) {
+	resetOutbox(t)
+	handler := newProbeHandler(errs.PermanentError{Err: errors.New("template is missing")})
+	SUT := newPoller(t, handler, testPolicy)
+	id := insertProbe(t, 1)
+
+	require.Equal(t, 1, SUT.ProcessDue(context.Background()))
+
+	event := getOutbox(t, id)
+	require.Equal(t, int(consts.DeadLettered), event.Status)
+	require.Equal(t, 1, event.Attempts)
+	makeDue(t, id)
+	require.Equal(t, 0, SUT.ProcessDue(context.Background()))
+	require.Equal(t, 1, handler.calls(1))
+}
+
+func Test_ProcessDue_When_Attempts_Are_Exhausted_Then_Dead_Letter_And_Never_Claim_Again(t *testing.T) {
+	resetOutbox(t)
+	failure := errors.New("bucket is unavailable")
+	handler := newProbeHandler(failure, failure, failure, failure)
+	SUT := newPoller(t, handler, testPolicy)
+	id := insertProbe(t, 1)
+
+	for attempt := 1; attempt <= testPolicy.MaxAttempts; attempt++ {
+		makeDue(t, id)
+		require.Equal(t, 1, SUT.ProcessDue(context.Background()))
+		require.Equal(t, attempt, getOutbox(t, id).Attempts)
+	}
+
+	event := getOutbox(t, id)
+	require.Equal(t, int(consts.DeadLettered), event.Status)
+	require.Equal(t, failure.Error(), event.LastError.String)
+	makeDue(t, id)
+	require.Equal(t, 0, SUT.ProcessDue(context.Background()))
+	require.Equal(t, testPolicy.MaxAttempts, handler.calls(1))
+}
+
+func Test_ProcessDue_When_Max_Attempts_Are_Configured_Then_Override_Only_Default_Policy(t *testing.T) {
+	resetOutbox(t)
+	t.Setenv("SCHEDULER_MAX_ATTEMPTS", "1")
+	failure := errors.New("bucket is unavailable")
+	onDefault := newPoller(t, newProbeHandler(failure), events.DefaultRetryPolicy)
+	id := insertProbe(t, 1)
+
+	require.Equal(t, 1, onDefault.ProcessDue(context.Background()))
+	require.Equal(t, int(consts.DeadLettered), getOutbox(t, id).Status)
+
+	resetOutbox(t)
+	onOwn := newPoller(t, newProbeHandler(failure), testPolicy)
+	id = insertProbe(t, 1)
+
+	require.Equal(t, 1, onOwn.ProcessDue(context.Background()))
+	require.Equal(t, int(consts.InError), getOutbox(t, id).Status)
+}
+
+// countProbes returns how many probes with n are in the outbox
+func countProbes(t *testing.T, n int) int {
+	t.Helper()
+	var count int
+	err := testinfra.Pool.QueryRow(context.Background(), "SELECT count(*) FROM builder.outbox WHERE payload->>'N' = $1",
+		strconv.Itoa(n)).Scan(&count)
+	require.NoError(t, err)
+	return count
+}
+
+// compensateWithProbe inserts a probe with n+100, so the test can see that compensation was committed
+func compensateWithProbe(ctx context.Context, tx pgx.Tx, event probe, cause error) error {
+	return repo.NewEventRepo(tx).InsertEvent(ctx, probe{N: event.N + 100})
+}
+
+func Test_ProcessDue_When_Event_Is_Dead_Lettered_Then_Compensation_Is_Committed_With_It(t *testing.T) {
+	resetOutbox(t)
+	handler := newProbeHandler(errs.PermanentError{Err: errors.New("template is missing")})
+	handler.compensate = compensateWithProbe
+	SUT := newPoller(t, handler, testPolicy)
+	id := insertProbe(t, 1)
+
+	// probe inserted by the compensation is due at once, so it's handled too
+	require.Equal(t, 2, SUT.ProcessDue(context.Background()))
+
+	require.Equal(t, int(consts.DeadLettered), getOutbox(t, id).Status)
+	require.Equal(t, 1, countProbes(t, 101))
+	require.Equal(t, 1, handler.calls(101))
+}
+
+func Test_ProcessDue_When_Event_Is_Retried_Then_It_Isnt_Compensated(t *testing.T) {
+	resetOutbox(t)
+	handler := newProbeHandler(errors.New("smtp is down"))
+	handler.compensate = compensateWithProbe
+	SUT := newPoller(t, handler, testPolicy)
+	id := insertProbe(t, 1)
+
+	require.Equal(t, 1, SUT.ProcessDue(context.Background()))
+
+	require.Equal(t, int(consts.InError), getOutbox(t, id).Status)
+	require.Equal(t, 0, countProbes(t, 101))
+}
+
+func Test_ProcessDue_When_Compensation_Fails_Then_Its_Changes_Are_Rolled_Back_And_Event_Is_Dead_Lettered(t *testing.T) {
+	resetOutbox(t)
+	handler := newProbeHandler(errs.PermanentError{Err: errors.New("template is missing")})
+	handler.compensate = func(ctx context.Context, tx pgx.Tx, event probe, cause error) error {
+		if err := compensateWithProbe(ctx, tx, event, cause); err != nil {
+			return err
+		}
+		return errors.New("site is gone")
+	}
+	SUT := newPoller(t, handler, testPolicy)
+	id := insertProbe(t, 1)
+
+	require.Equal(t, 1, SUT.ProcessDue(context.Background()))
+
+	require.Equal(t, int(consts.DeadLettered), getOutbox(t, id).Status)
+	require.Equal(t, 0, countProbes(t, 101))
+}
+
+func Test_ProcessDue_When_Handler_Is_Deferred_Then_Attempt_Isnt_Counted(t *testing.T) {
+	resetOutbox(t)
+	queueFull := errs.DeferredError{Err: errors.New("build queue is full"), Delay: 10 * time.Minute}
+	deferrals := testPolicy.MaxAttempts + 1
+	var queued []error
+	for range deferrals {
+		queued = append(queued, queueFull)
+	}
+	handler := newProbeHandler(queued...)
+	SUT := newPoller(t, handler, testPolicy)
+	id := insertProbe(t, 1)
+
+	require.Equal(t, 1, SUT.ProcessDue(context.Background()))
+	event := getOutbox(t, id)
+	require.Equal(t, int(consts.NotProcessed), event.Status)
+	require.Equal(t, 0, event.Attempts)
+	require.WithinDuration(t, time.Now().Add(10*time.Minute), event.NextAttemptAt, 10*time.Second)
+	require.False(t, event.LockedBy.Valid)
+
+	// deferrals beyond max attempts don't dead-letter the event
+	for range deferrals - 1 {
+		makeDue(t, id)
+		require.Equal(t, 1, SUT.ProcessDue(context.Background()))
+	}
+	require.Equal(t, int(consts.NotProcessed), getOutbox(t, id).Status)
+
+	makeDue(t, id)
+	require.Equal(t, 1, SUT.ProcessDue(context.Background()))
+	event = getOutbox(t, id)
+	require.Equal(t, int(consts.Processed), event.Status)
+	require.Equal(t, 1, event.Attempts)
+	require.Equal(t, deferrals+1, handler.calls(1))
+}
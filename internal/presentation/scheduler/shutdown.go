@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+)
+
+// releaseTimeout bounds the cleanup, done after the shutdown deadline is exceeded
+const releaseTimeout = 5 * time.Second
+
+// track registers a poll as in-flight, polls aren't started after Stop
+func (o *OutboxPoller) track() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.stopped {
+		return false
+	}
+	o.inFlight.Add(1)
+	return true
+}
+
+func (o *OutboxPoller) isStopped() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.stopped
+}
+
+// Stop stops claiming new events and waits for in-flight handlers until ctx is done.
+// After that handlers are cancelled and claims of unfinished events are released,
+// so they can be picked up by another replica without waiting for lease expiration
+func (o *OutboxPoller) Stop(ctx context.Context) error {
+	slog.Info("Stopping poller")
+	o.mu.Lock()
+	if !o.stopped {
+		o.stopped = true
+		close(o.stop)
+	}
+	o.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		o.inFlight.Wait()
+		close(finished)
+	}()
+
+	var err error
+	select {
+	case <-finished:
+		slog.Info("In-flight events are processed")
+	case <-ctx.Done():
+		err = ctx.Err()
+		slog.Warn("shutdown deadline exceeded, cancelling in-flight events")
+		o.cancel()
+		select {
+		case <-finished:
+		case <-time.After(releaseTimeout):
+		}
+	}
+	// stops listener and lease keepers
+	o.cancel()
+
+	o.releaseClaims()
+	return err
+}
+
+// releaseClaims returns events, claimed by this worker and left unfinished, back to the queue.
+// Interrupted attempt isn't counted against event's retry policy
+func (o *OutboxPoller) releaseClaims() {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	res, err := o.uowFactory.Pool.Exec(ctx, `UPDATE builder.outbox
+		SET status = $1, next_attempt_at = now(), locked_by = NULL, locked_until = NULL
+		WHERE locked_by = $2 AND status = $3`, consts.NotProcessed, o.cfg.workerID, consts.Processing)
+	if err != nil {
+		slog.Error("err releasing claimed events", "err", err)
+		return
+	}
+	if res.RowsAffected() > 0 {
+		slog.Warn("released unfinished events", "count", res.RowsAffected())
+	}
+}
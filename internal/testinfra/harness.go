@@ -0,0 +1,272 @@
+package testinfra
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Builder-Lawyers/builder-backend/internal/application"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/commands/site"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/consts"
+	"github.com/Builder-Lawyers/builder-backend/internal/application/publishing"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/build"
+	buildFake "github.com/Builder-Lawyers/builder-backend/internal/infra/build/fake"
+	certsFake "github.com/Builder-Lawyers/builder-backend/internal/infra/certs/fake"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/config"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/repo"
+	dnsFake "github.com/Builder-Lawyers/builder-backend/internal/infra/dns/fake"
+	mailFake "github.com/Builder-Lawyers/builder-backend/internal/infra/mail/fake"
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/storage"
+	"github.com/Builder-Lawyers/builder-backend/internal/presentation/scheduler"
+	dbs "github.com/Builder-Lawyers/builder-backend/pkg/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	BaseDomain = "sites.test"
+	// maxOutboxRounds - guard against events, that are retried forever
+	maxOutboxRounds = 100
+)
+
+// templateSources - minimal sources of the seeded templates, the fake runner doesn't need more
+var templateSources = map[string]string{
+	"package.json":                            `{"name":"templates","private":true}`,
+	"templates/template-v1/package.json":      `{"name":"template-v1"}`,
+	"templates/template-v1/src/index.astro":   "---\n---\n<h1>{fields.title}</h1>",
+	"templates/template-v2/package.json":      `{"name":"template-v2"}`,
+	"templates/template-v2/src/index.astro":   "---\n---\n<h1>{fields.title}</h1>",
+	"templates/template-v2/public/robots.txt": "User-agent: *",
+}
+
+// Harness - processors and site commands wired to the test database, with external services replaced by fakes.
+// Outbox isn't polled in the background, events are handled by RunOutbox
+type Harness struct {
+	UoWFactory  *dbs.UOWFactory
+	Storage     *storage.Storage
+	DNS         *dnsFake.Provider
+	Certs       *certsFake.Certificates
+	Mail        *mailFake.Sender
+	Runner      *buildFake.Runner
+	Config      config.ProvisionConfig
+	CreateSite  *site.CreateSite
+	UpdateSite  *site.UpdateSite
+	PreviewSite *site.PreviewSite
+	// ManageVersions publishes with the same publisher as RebuildSite
+	ManageVersions *site.ManageVersions
+	poller         *scheduler.OutboxPoller
+}
+
+func NewHarness(t *testing.T) *Harness {
+	t.Helper()
+	root := t.TempDir()
+	blobs, err := storage.NewLocalStore(filepath.Join(root, "bucket"), "https://bucket.s3.test")
+	require.NoError(t, err)
+	store := storage.NewBlobStorage(blobs)
+
+	cfg := config.ProvisionConfig{
+		BuildFolder:             filepath.Join(root, "templates-repo"),
+		TemplatesFolder:         filepath.Join(root, "templates-repo", "templates"),
+		WorkspacesFolder:        filepath.Join(root, "builds"),
+		TemplateSrcBucketPath:   "templates-sources/",
+		TemplateBuildBucketPath: "templates-builds/",
+		Filename:                "pages.json",
+		BaseDomain:              BaseDomain,
+		DeletionGracePeriod:     24 * time.Hour,
+		PreviewTTL:              time.Hour,
+		Defaults: &config.Defaults{
+			S3Domain: "bucket.s3.test",
+			CertARN:  "arn:aws:acm:us-east-1:000000000000:certificate/default",
+		},
+	}
+	require.NoError(t, os.MkdirAll(cfg.TemplatesFolder, 0o755))
+	for key, content := range templateSources {
+		require.NoError(t, store.PutObject(context.Background(), cfg.TemplateSrcBucketPath+key, []byte(content), "", ""))
+	}
+
+	h := &Harness{
+		UoWFactory: dbs.NewUoWFactory(Pool),
+		Storage:    store,
+		// deployments take a few polls, so the saga goes through retries
+		DNS:    dnsFake.NewProvider(dnsFake.Config{DeployPolls: 2, RegistrationPolls: 2}),
+		Certs:  certsFake.NewCertificates(),
+		Mail:   mailFake.NewSender(),
+		Runner: buildFake.NewRunner(cfg.Filename),
+		Config: cfg,
+	}
+	repos := repo.NewRepositories()
+	templateBuild := build.NewTemplateBuild(store, cfg, h.Runner)
+	processors := application.NewProcessors(h.UoWFactory, store, templateBuild, h.Certs, cfg, h.DNS, h.Mail)
+	h.poller = scheduler.NewOutboxPoller(processors.Registry, h.UoWFactory, scheduler.NewOutboxConfig())
+	h.CreateSite = site.NewCreateSite(h.UoWFactory, repos)
+	h.UpdateSite = site.NewUpdateSite(h.UoWFactory, repos)
+	h.PreviewSite = site.NewPreviewSite(h.UoWFactory, repos)
+	h.ManageVersions = site.NewManageVersions(h.UoWFactory, repos,
+		publishing.NewPublisher(repos, templateBuild, h.DNS, store, cfg))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = h.poller.Stop(ctx)
+	})
+
+	return h
+}
+
+// NewUser inserts a confirmed user with a unique email
+func (h *Harness) NewUser(t *testing.T) db.User {
+	t.Helper()
+	id := uuid.New()
+	user := db.User{
+		ID:         id,
+		Status:     consts.UserConfirmed,
+		FirstName:  "Jane",
+		SecondName: "Doe",
+		Email:      fmt.Sprintf("%v@mailinator.com", id),
+		CreatedAt:  time.Now(),
+	}
+	h.inTx(t, func(tx pgx.Tx) error {
+		return repo.NewUserRepo(tx).InsertUser(context.Background(), user)
+	})
+	return user
+}
+
+// Subscribe attaches a subscription to the site, like a payment webhook does
+func (h *Harness) Subscribe(t *testing.T, siteID uint64) {
+	t.Helper()
+	h.inTx(t, func(tx pgx.Tx) error {
+		return repo.NewSiteRepo(tx).UpdateSiteSubscription(context.Background(), siteID, fmt.Sprintf("sub_%v", siteID))
+	})
+}
+
+// RunOutbox handles events until none is left. Events waiting for a retry are made due at once,
+// so the test doesn't wait for backoff. It returns how many times events were handled
+func (h *Harness) RunOutbox(t *testing.T) int {
+	t.Helper()
+	ctx := context.Background()
+	var handled int
+	for range maxOutboxRounds {
+		handled += h.poller.ProcessDue(ctx)
+		res, err := Pool.Exec(ctx, `UPDATE builder.outbox SET next_attempt_at = now()
+			WHERE status IN ($1, $2) AND next_attempt_at > now()`, consts.NotProcessed, consts.InError)
+		require.NoError(t, err)
+		if res.RowsAffected() == 0 {
+			return handled
+		}
+	}
+	require.FailNow(t, "outbox isn't drained", "events are still retried after %v rounds", maxOutboxRounds)
+	return handled
+}
+
+// Events returns events of the outbox in order of insertion
+func (h *Harness) Events(t *testing.T) []db.Outbox {
+	t.Helper()
+	rows, err := Pool.Query(context.Background(), `SELECT id, event, status, payload, attempts, last_error, created_at
+		FROM builder.outbox ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var result []db.Outbox
+	for rows.Next() {
+		var event db.Outbox
+		require.NoError(t, rows.Scan(&event.ID, &event.Event, &event.Status, &event.Payload, &event.Attempts,
+			&event.LastError, &event.CreatedAt))
+		result = append(result, event)
+	}
+	require.NoError(t, rows.Err())
+	return result
+}
+
+// Mails returns mails saved by the SendMail processor to recipient
+func (h *Harness) Mails(t *testing.T, recipient string) []db.Mail {
+	t.Helper()
+	rows, err := Pool.Query(context.Background(), `SELECT id, type, recipients, subject, content, sent_at
+		FROM builder.mails WHERE recipients = $1 ORDER BY id`, recipient)
+	require.NoError(t, err)
+	defer rows.Close()
+	var result []db.Mail
+	for rows.Next() {
+		var mail db.Mail
+		require.NoError(t, rows.Scan(&mail.ID, &mail.MailType, &mail.Recipients, &mail.Subject, &mail.Content, &mail.SentAt))
+		result = append(result, mail)
+	}
+	require.NoError(t, rows.Err())
+	return result
+}
+
+func (h *Harness) Site(t *testing.T, siteID uint64) *db.Site {
+	t.Helper()
+	var result *db.Site
+	h.inTx(t, func(tx pgx.Tx) (err error) {
+		result, err = repo.NewSiteRepo(tx).GetSite(context.Background(), siteID)
+		return err
+	})
+	return result
+}
+
+func (h *Harness) Provision(t *testing.T, siteID uint64) *db.Provision {
+	t.Helper()
+	var provision *db.Provision
+	h.inTx(t, func(tx pgx.Tx) (err error) {
+		provision, err = repo.NewProvisionRepo(tx).GetProvisionByID(context.Background(), siteID)
+		return err
+	})
+	return provision
+}
+
+func (h *Harness) Versions(t *testing.T, siteID uint64) []db.SiteVersion {
+	t.Helper()
+	var versions []db.SiteVersion
+	h.inTx(t, func(tx pgx.Tx) (err error) {
+		versions, err = repo.NewSiteVersionRepo(tx).ListSiteVersions(context.Background(), siteID)
+		return err
+	})
+	return versions
+}
+
+func (h *Harness) Build(t *testing.T, id uuid.UUID) *db.SiteBuild {
+	t.Helper()
+	var build *db.SiteBuild
+	h.inTx(t, func(tx pgx.Tx) (err error) {
+		build, err = repo.NewSiteBuildRepo(tx).GetBuild(context.Background(), id)
+		return err
+	})
+	return build
+}
+
+func (h *Harness) Preview(t *testing.T, token string) *db.SitePreview {
+	t.Helper()
+	var preview *db.SitePreview
+	h.inTx(t, func(tx pgx.Tx) (err error) {
+		preview, err = repo.NewSitePreviewRepo(tx).GetPreview(context.Background(), token)
+		return err
+	})
+	return preview
+}
+
+// StoredFiles returns keys under prefix relative to it
+func (h *Harness) StoredFiles(t *testing.T, prefix string) []string {
+	t.Helper()
+	keys, err := h.Storage.ListKeys(context.Background(), prefix+"/", 0)
+	require.NoError(t, err)
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, prefix+"/")
+	}
+	return keys
+}
+
+func (h *Harness) inTx(t *testing.T, fn func(tx pgx.Tx) error) {
+	t.Helper()
+	uow := h.UoWFactory.GetUoW()
+	tx, err := uow.Begin(context.Background())
+	require.NoError(t, err)
+	if err = fn(tx); err != nil {
+		_ = uow.Rollback()
+		require.NoError(t, err)
+	}
+	require.NoError(t, uow.Commit())
+}
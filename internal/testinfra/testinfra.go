@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/Builder-Lawyers/builder-backend/internal/infra/db/migrations"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -71,45 +72,13 @@ func SetupDB() *pgxpool.Pool {
 		log.Panic("db did not respond after 20 attempts")
 	}
 
-	_, err = pool.Exec(ctx, `
-		CREATE SCHEMA IF NOT EXISTS builder;
-		CREATE TABLE IF NOT EXISTS builder.users (
-		  id UUID PRIMARY KEY,
-		  email TEXT UNIQUE NOT NULL
-		);
-		CREATE TABLE IF NOT EXISTS builder.sites (
-			id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
-			template_id SMALLINT NOT NULL,
-			creator_id UUID NOT NULL,
-			plan_id SMALLINT NOT NULL,
-			subscription_id VARCHAR(60),
-			status VARCHAR(30) NOT NULL,
-			fields JSONB,
-			created_at TIMESTAMPTZ NOT NULL,
-			updated_at TIMESTAMPTZ
-		);
-		CREATE TABLE IF NOT EXISTS builder.sessions (
-		  id UUID PRIMARY KEY,
-		  user_id UUID NOT NULL REFERENCES builder.users(id),
-		  refresh_token TEXT,
-		  expires_at TIMESTAMP WITH TIME ZONE
-		);
-		CREATE TABLE IF NOT EXISTS builder.files (
-			id UUID PRIMARY KEY
-		);
-		CREATE TABLE IF NOT EXISTS builder.provisions (
-			site_id BIGINT PRIMARY KEY,
-			"type" VARCHAR(40) NOT NULL,
-			status VARCHAR(40) NOT NULL,
-			domain VARCHAR(80),
-			cert_arn VARCHAR(120),
-			cloudfront_id VARCHAR(60),
-			created_at TIMESTAMPTZ,
-			updated_at TIMESTAMPTZ
-		);
-	`)
+	// schema is the same as in production, built by the same migrations
+	migrator, err := migrations.NewMigrator(pool)
 	if err != nil {
-		log.Panicf("create tables: %v", err)
+		log.Panicf("load migrations: %v", err)
+	}
+	if _, err = migrator.Up(ctx); err != nil {
+		log.Panicf("migrate: %v", err)
 	}
 
 	return pool
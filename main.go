@@ -1,9 +1,17 @@
 package main
 
-import "github.com/Builder-Lawyers/builder-backend/cmd"
+import (
+	"os"
+
+	"github.com/Builder-Lawyers/builder-backend/cmd"
+)
 
 //go:generate go tool oapi-codegen -config .\api\cfg.models.yaml .\api\openapi.yaml
 //go:generate go tool oapi-codegen -config .\api\cfg.yaml .\api\openapi.yaml
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		cmd.Migrate(os.Args[2:])
+		return
+	}
 	cmd.Init()
 }
@@ -4,13 +4,15 @@ go 1.24.5
 
 require (
 	github.com/Builder-Lawyers/builder-backend/pkg/env v0.0.0-20250718130208-cbe7c367d13d
+	github.com/Builder-Lawyers/builder-backend/pkg/interfaces v0.0.0-20250718142413-4b5cdd8a3540
 	github.com/jackc/pgx/v5 v5.7.5
 )
 
 require (
-	github.com/Builder-Lawyers/builder-backend/pkg/interfaces v0.0.0-20250718142413-4b5cdd8a3540 // indirect
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
 	golang.org/x/crypto v0.37.0 // indirect
 	golang.org/x/text v0.24.0 // indirect
 )
+
+replace github.com/Builder-Lawyers/builder-backend/pkg/interfaces => ../interfaces
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 
@@ -10,28 +11,68 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+type txKey struct{}
+
+// WithTx returns ctx carrying tx, units of work begun with it are nested into tx as savepoints
+func WithTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the transaction, ctx was given by WithTx or RunInTx
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	return tx, ok
+}
+
 type UOW struct {
 	Pool *pgxpool.Pool
 	Conn *pgxpool.Conn
 	Tx   pgx.Tx
+
+	ctx          context.Context
+	readOnly     bool
+	rollbackOnly bool
+	nested       bool
+	finished     bool
 }
 
 var _ interfaces.UoW = (*UOW)(nil)
 
-func (u *UOW) Begin() (pgx.Tx, error) {
-	conn, err := u.Pool.Acquire(context.Background())
+func (u *UOW) Begin(ctx context.Context) (pgx.Tx, error) {
+	if u.Tx != nil && !u.finished {
+		return nil, fmt.Errorf("transaction is already started")
+	}
+	u.ctx = ctx
+	u.finished = false
+
+	if outer, ok := TxFromContext(ctx); ok {
+		tx, err := outer.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("can't create savepoint, %w", err)
+		}
+		u.Tx = tx
+		u.nested = true
+		return u.Tx, nil
+	}
+
+	conn, err := u.Pool.Acquire(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("can't acquire conn, %w", err)
 	}
-	slog.Debug("acquired conn", "pid", conn.Conn().PgConn().PID())
+	slog.DebugContext(ctx, "acquired conn", "pid", conn.Conn().PgConn().PID())
 
-	tx, err := conn.BeginTx(context.Background(), pgx.TxOptions{})
+	opts := pgx.TxOptions{}
+	if u.readOnly {
+		opts.AccessMode = pgx.ReadOnly
+	}
+	tx, err := conn.BeginTx(ctx, opts)
 	if err != nil {
 		conn.Release()
 		return nil, fmt.Errorf("can't begin tx, %w", err)
 	}
 	u.Tx = tx
 	u.Conn = conn
+	u.nested = false
 	return u.Tx, nil
 }
 
@@ -39,31 +80,60 @@ func (u *UOW) Commit() error {
 	if u.Tx == nil {
 		return fmt.Errorf("transaction is not started yet")
 	}
-	defer u.Conn.Release()
-	return u.Tx.Commit(context.Background())
+	if u.finished {
+		return fmt.Errorf("transaction is already finished")
+	}
+	defer u.finish()
+	return u.Tx.Commit(u.ctx)
 }
 
 func (u *UOW) Rollback() error {
 	if u.Tx == nil {
 		return fmt.Errorf("transaction is not started yet")
 	}
-	defer u.Conn.Release()
-	return u.Tx.Rollback(context.Background())
+	if u.finished {
+		return nil
+	}
+	defer u.finish()
+	// cancelled request must not leave the transaction open
+	return u.Tx.Rollback(context.WithoutCancel(u.ctx))
 }
 
 func (u *UOW) Finalize(err *error) {
-	if *err != nil {
-		_ = u.Rollback()
-		slog.Debug("tx rollbacked")
+	if u.Tx == nil || u.finished {
 		return
 	}
-	_ = u.Commit()
+	if *err != nil || u.rollbackOnly {
+		if errRollback := u.Rollback(); errRollback != nil {
+			slog.ErrorContext(u.ctx, "err rolling back tx", "err", errRollback)
+			return
+		}
+		slog.DebugContext(u.ctx, "tx rollbacked")
+		return
+	}
+	if errCommit := u.Commit(); errCommit != nil {
+		slog.ErrorContext(u.ctx, "err committing tx", "err", errCommit)
+		*err = errCommit
+	}
+}
+
+func (u *UOW) SetRollbackOnly() {
+	u.rollbackOnly = true
 }
 
 func (u *UOW) GetTx() pgx.Tx {
 	return u.Tx
 }
 
+// finish releases the connection, savepoints leave it to the outer transaction
+func (u *UOW) finish() {
+	u.finished = true
+	if !u.nested && u.Conn != nil {
+		u.Conn.Release()
+		u.Conn = nil
+	}
+}
+
 type UOWFactory struct {
 	Pool *pgxpool.Pool
 }
@@ -74,6 +144,49 @@ func (u *UOWFactory) GetUoW() interfaces.UoW {
 	}
 }
 
+// GetReadOnlyUoW returns a UoW, which begins read-only transactions and never commits them
+func (u *UOWFactory) GetReadOnlyUoW() interfaces.UoW {
+	return &UOW{
+		Pool:         u.Pool,
+		readOnly:     true,
+		rollbackOnly: true,
+	}
+}
+
+// RunInTx runs fn in a transaction, which is committed when fn succeeds and rolled back otherwise.
+// ctx passed to fn carries the transaction, so units of work begun with it are nested as savepoints
+func (u *UOWFactory) RunInTx(ctx context.Context, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	return run(ctx, u.GetUoW(), fn)
+}
+
+// RunInReadOnlyTx runs fn in a read-only transaction, which is always rolled back
+func (u *UOWFactory) RunInReadOnlyTx(ctx context.Context, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	return run(ctx, u.GetReadOnlyUoW(), fn)
+}
+
+func run(ctx context.Context, uow interfaces.UoW, fn func(ctx context.Context, tx pgx.Tx) error) (err error) {
+	tx, err := uow.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = uow.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(WithTx(ctx, tx), tx); err != nil {
+		if errRollback := uow.Rollback(); errRollback != nil {
+			return errors.Join(err, fmt.Errorf("err rolling back tx, %w", errRollback))
+		}
+		return err
+	}
+	uow.Finalize(&err)
+
+	return err
+}
+
 func NewUoWFactory(pool *pgxpool.Pool) *UOWFactory {
 	return &UOWFactory{
 		Pool: pool,
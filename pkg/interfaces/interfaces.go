@@ -1,19 +1,34 @@
 package interfaces
 
-import "github.com/jackc/pgx/v5"
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
 
 type UoW interface {
 	Commit() error
 	Rollback() error
-	Begin() (pgx.Tx, error)
+	// Begin starts a transaction bound to ctx, or a savepoint when ctx already carries a transaction
+	Begin(ctx context.Context) (pgx.Tx, error)
 	GetTx() pgx.Tx
+	// Finalize commits, unless err is set or the UoW is rollback-only, does nothing when already finished
 	Finalize(err *error)
+	// SetRollbackOnly makes Finalize roll back, changes can still be kept by an explicit Commit
+	SetRollbackOnly()
 }
 
 type Event interface {
 	GetType() string
 }
 
+// ScheduledEvent is delivered to its handler not earlier than GetNotBefore
+type ScheduledEvent interface {
+	Event
+	GetNotBefore() time.Time
+}
+
 type EventHandler interface {
 	Handle(event Event) (any, error)
 }